@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/sandbox"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// 独立的沙箱命令：不经过判题服务直接用SystemCallSandbox执行程序，结果以JSON输出到标准输出
+// 用于在节点上手工复现判题结果，以及编写不依赖Kafka与HTTP的沙箱回归脚本
+// 用法：judge-sandbox [flags] -- program [args...]
+// 例如：judge-sandbox -time 1000 -memory 262144 -seccomp cpp -stdin 1.in -stdout 1.out -- ./main
+
+// 可重复的字符串参数（如-env、-ro）
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// 输出的执行结果
+type report struct {
+	Verdict string                 `json:"verdict"` // 执行状态名称，见sandbox.StatusName
+	Result  *sandbox.ExecuteResult `json:"result"`  // 包含ResourceUsageDetail（resource_usage）
+}
+
+func main() {
+	// 沙箱以辅助模式重新执行本程序，与判题服务相同
+	if sandbox.IsSandboxHelper() {
+		sandbox.RunSandboxHelper()
+	}
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	flags := flag.NewFlagSet("judge-sandbox", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: judge-sandbox [flags] -- program [args...]")
+		flags.PrintDefaults()
+	}
+
+	config := &sandbox.SandboxConfig{}
+	var env, readOnlyPaths, writablePaths stringList
+
+	// 身份与文件系统
+	flags.IntVar(&config.UID, "uid", 65534, "the uid to run the program as")
+	flags.IntVar(&config.GID, "gid", 65534, "the gid to run the program as")
+	flags.StringVar(&config.WorkDir, "workdir", "", "the working directory (default the current directory)")
+	flags.StringVar(&config.Chroot, "chroot", "", "the chroot directory")
+	flags.BoolVar(&config.EnableRootfs, "rootfs", false, "pivot_root into a minimal root filesystem")
+	flags.Var(&readOnlyPaths, "ro", "a host path bind-mounted read-only into the rootfs (repeatable)")
+	flags.Var(&writablePaths, "rw", "a writable path in the rootfs (repeatable)")
+
+	// 资源限制
+	flags.Int64Var(&config.TimeLimit, "time", 1000, "the time limit in milliseconds")
+	flags.Int64Var(&config.WallTimeLimit, "wall", 0, "the wall time limit in milliseconds (default time+1000)")
+	flags.StringVar(&config.TimeLimitMetric, "time-metric", sandbox.TimeLimitMetricCPU, "the time limit metric: cpu or wall")
+	flags.Int64Var(&config.MemoryLimit, "memory", 262144, "the memory limit in KB")
+	flags.Int64Var(&config.StackLimit, "stack", 8192, "the stack limit in KB")
+	flags.Int64Var(&config.FileSizeLimit, "fsize", 10240, "the file size limit in KB")
+	flags.IntVar(&config.ProcessLimit, "procs", 64, "the process limit")
+	flags.Int64Var(&config.OutputLimit, "output-limit", 0, "the stdout/stderr byte limit, 0 for unlimited")
+	flags.BoolVar(&config.SkipAddressSpaceLimit, "no-as-limit", false, "do not limit the address space (JVM, V8, Go)")
+	flags.Int64Var(&config.ResourceSampleIntervalMs, "sample", 0, "sample memory and CPU time every N milliseconds, 0 to disable")
+
+	// 系统调用
+	seccompProfile := flags.String("seccomp", "", "enable seccomp with the built-in profile of a language (cpp, c, java, python, go, javascript, typescript)")
+	allowSyscalls := flags.String("allow", "", "extra syscalls to allow, comma separated names")
+	denySyscalls := flags.String("deny", "", "extra syscalls to deny, comma separated names")
+	ruleNames := flags.String("rules", "", "argument rule presets replacing the profile's, comma separated (\"none\" to disable)")
+	flags.BoolVar(&config.AllowLocalSockets, "local-sockets", false, "allow socket syscalls on the loopback-only network")
+	flags.BoolVar(&config.TraceSyscalls, "trace", false, "learning mode: record syscalls without restricting them")
+
+	// 输入输出
+	flags.StringVar(&config.InputFile, "stdin", "", "the stdin file")
+	flags.StringVar(&config.OutputFile, "stdout", "", "the stdout file (default discarded)")
+	flags.StringVar(&config.ErrorFile, "stderr", "", "the stderr file (default discarded)")
+	flags.Var(&env, "env", "an environment variable KEY=VALUE (repeatable, default PATH=/usr/bin:/bin)")
+
+	// Namespace
+	flags.BoolVar(&config.EnableUserNS, "userns", false, "enable the user namespace")
+	flags.BoolVar(&config.EnableUTSNS, "utsns", false, "enable the UTS namespace")
+	flags.BoolVar(&config.EnableIPCNS, "ipcns", false, "enable the IPC namespace")
+	flags.BoolVar(&config.EnableCgroupNS, "cgroupns", false, "enable the cgroup namespace")
+	flags.StringVar(&config.Hostname, "hostname", "sandbox", "the hostname in the UTS namespace")
+	flags.BoolVar(&config.Rootless, "rootless", false, "run without root: map the sandbox root to the current user and drop all capabilities")
+
+	// cgroups
+	flags.BoolVar(&config.EnableCgroups, "cgroups", false, "enable cgroups resource control")
+	flags.StringVar(&config.CgroupsMode, "cgroups-mode", "hybrid", "the cgroups mode: primary, fallback or hybrid")
+	flags.IntVar(&config.CPUQuotaPercent, "cpu-quota", 0, "the CPU quota percentage (1-100)")
+	flags.StringVar(&config.CPUSetCores, "cpuset", "", "the CPU cores to bind (e.g. 0-1)")
+	flags.Float64Var(&config.MemorySwapRatio, "swap-ratio", 0, "the memory+swap limit as a ratio of the memory limit")
+	flags.IntVar(&config.IOWeightPercent, "io-weight", 0, "the I/O weight percentage (1-100)")
+	flags.StringVar(&config.Language, "language", "cli", "the language used for cgroup grouping")
+	flags.StringVar(&config.TaskID, "task-id", "", "the task id used for cgroup naming (default the pid)")
+	flags.StringVar(&config.CgroupParent, "cgroup-parent", "", "the cgroup v2 directory to create cgroups in (default /sys/fs/cgroup, the delegated own cgroup with -rootless)")
+
+	verbose := flags.Bool("v", false, "print sandbox logs to stderr")
+	flags.Parse(args)
+
+	argv := flags.Args()
+	if len(argv) == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	// 标准输出只用于结果JSON，日志写到标准错误
+	logx.SetWriter(logx.NewWriter(os.Stderr))
+	if !*verbose {
+		logx.SetLevel(logx.ErrorLevel)
+	}
+
+	if config.WorkDir == "" {
+		dir, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "judge-sandbox: %v\n", err)
+			return 1
+		}
+		config.WorkDir = dir
+	}
+	if config.WallTimeLimit == 0 {
+		config.WallTimeLimit = config.TimeLimit + 1000
+	}
+	if config.TaskID == "" {
+		config.TaskID = fmt.Sprintf("%d", os.Getpid())
+	}
+	config.Environment = env
+	if len(config.Environment) == 0 {
+		config.Environment = []string{"PATH=/usr/bin:/bin"}
+	}
+	config.ReadOnlyPaths = readOnlyPaths
+	config.WritablePaths = writablePaths
+	if config.EnableUserNS {
+		config.UidMapInside, config.UidMapOutside = config.UID, config.UID
+		config.GidMapInside, config.GidMapOutside = config.GID, config.GID
+	}
+
+	if config.Rootless && config.EnableCgroups {
+		parent, err := sandbox.PrepareDelegatedCgroup(config.CgroupParent)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "judge-sandbox: %v\n", err)
+			return 1
+		}
+		config.CgroupParent = parent
+	}
+
+	if err := configureSeccomp(config, *seccompProfile, *allowSyscalls, *denySyscalls, *ruleNames); err != nil {
+		fmt.Fprintf(os.Stderr, "judge-sandbox: %v\n", err)
+		return 2
+	}
+
+	// Ctrl-C时终止整个进程树
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	result, err := sandbox.NewSystemCallSandbox(config).Execute(ctx, argv[0], argv[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "judge-sandbox: %v\n", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(&report{Verdict: sandbox.StatusName(result.Status), Result: result}); err != nil {
+		fmt.Fprintf(os.Stderr, "judge-sandbox: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// 按语言的内置配置启用seccomp，再叠加命令行指定的系统调用与参数规则
+func configureSeccomp(config *sandbox.SandboxConfig, profile, allow, deny, rules string) error {
+	if profile == "" {
+		if allow != "" || deny != "" || rules != "" {
+			return fmt.Errorf("-allow, -deny and -rules require -seccomp")
+		}
+		return nil
+	}
+
+	switch profile {
+	case "cpp", "c", "java", "python", "go", "javascript", "typescript":
+	default:
+		// 未知语言会得到最小权限集合，几乎无法运行任何程序
+		return fmt.Errorf("unknown seccomp profile: %s", profile)
+	}
+	allowed := sandbox.GetSyscallWhitelist(profile)
+	extraAllowed, err := parseSyscallNames(allow)
+	if err != nil {
+		return err
+	}
+	extraDenied, err := parseSyscallNames(deny)
+	if err != nil {
+		return err
+	}
+
+	config.EnableSeccomp = true
+	config.AllowedSyscalls = append(allowed, extraAllowed...)
+	config.DeniedSyscalls = append(sandbox.GetSyscallDenylist(profile), extraDenied...)
+	config.SyscallArgRules = sandbox.GetSyscallArgRules(profile)
+
+	switch rules {
+	case "":
+	case "none":
+		config.SyscallArgRules = nil
+	default:
+		argRules, err := sandbox.ResolveSyscallArgRules(strings.Split(rules, ","))
+		if err != nil {
+			return err
+		}
+		config.SyscallArgRules = argRules
+	}
+	return nil
+}
+
+// 解析逗号分隔的系统调用名称
+func parseSyscallNames(names string) ([]int, error) {
+	if names == "" {
+		return nil, nil
+	}
+
+	var numbers []int
+	for _, name := range strings.Split(names, ",") {
+		number, ok := sandbox.SyscallNumber(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("unknown syscall: %s", name)
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers, nil
+}
@@ -0,0 +1,7 @@
+#include <stdio.h>
+
+int main(void) {
+    for (;;) {
+        fputs("output output output output output output output output\n", stdout);
+    }
+}
@@ -0,0 +1,16 @@
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+int main(void) {
+    /* 以16MB为单位分配并写入，共4GB */
+    for (int i = 0; i < 256; i++) {
+        char *block = malloc(16 << 20);
+        if (block == NULL) {
+            return 1;
+        }
+        memset(block, 1, 16 << 20);
+    }
+    puts("ESCAPED");
+    return 0;
+}
@@ -0,0 +1,11 @@
+#include <stdio.h>
+#include <sys/ptrace.h>
+#include <unistd.h>
+
+int main(void) {
+    if (ptrace(PTRACE_ATTACH, getppid(), NULL, NULL) != 0) {
+        return 1;
+    }
+    puts("ESCAPED");
+    return 0;
+}
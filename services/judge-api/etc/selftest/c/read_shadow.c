@@ -0,0 +1,14 @@
+#include <stdio.h>
+
+int main(void) {
+    FILE *file = fopen("/etc/shadow", "r");
+    if (file == NULL) {
+        return 1;
+    }
+    char line[256];
+    if (fgets(line, sizeof(line), file) == NULL) {
+        return 1;
+    }
+    puts("ESCAPED");
+    return 0;
+}
@@ -0,0 +1,10 @@
+#include <stdio.h>
+
+int main(void) {
+    FILE *file = fopen("../selftest_escape.txt", "w");
+    if (file == NULL || fputs("escaped\n", file) < 0 || fclose(file) != 0) {
+        return 1;
+    }
+    puts("ESCAPED");
+    return 0;
+}
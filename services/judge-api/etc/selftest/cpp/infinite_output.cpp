@@ -0,0 +1,8 @@
+#include <iostream>
+
+int main() {
+    std::ios::sync_with_stdio(false);
+    for (;;) {
+        std::cout << "output output output output output output output output\n";
+    }
+}
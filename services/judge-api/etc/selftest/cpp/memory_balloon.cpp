@@ -0,0 +1,15 @@
+#include <cstring>
+#include <iostream>
+#include <vector>
+
+int main() {
+    // 以16MB为单位分配并写入，共4GB；分配失败时抛出std::bad_alloc
+    std::vector<char *> blocks;
+    for (int i = 0; i < 256; i++) {
+        char *block = new char[16 << 20];
+        std::memset(block, 1, 16 << 20);
+        blocks.push_back(block);
+    }
+    std::cout << "ESCAPED" << std::endl;
+    return 0;
+}
@@ -0,0 +1,20 @@
+#include <arpa/inet.h>
+#include <netinet/in.h>
+#include <cstdio>
+#include <sys/socket.h>
+
+int main() {
+    int fd = socket(AF_INET, SOCK_STREAM, 0);
+    if (fd < 0) {
+        return 1;
+    }
+    struct sockaddr_in addr = {};
+    addr.sin_family = AF_INET;
+    addr.sin_port = htons(80);
+    inet_pton(AF_INET, "1.1.1.1", &addr.sin_addr);
+    if (connect(fd, (struct sockaddr *)&addr, sizeof(addr)) != 0) {
+        return 1;
+    }
+    puts("ESCAPED");
+    return 0;
+}
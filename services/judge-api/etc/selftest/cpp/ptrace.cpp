@@ -0,0 +1,11 @@
+#include <cstdio>
+#include <sys/ptrace.h>
+#include <unistd.h>
+
+int main() {
+    if (ptrace(PTRACE_ATTACH, getppid(), nullptr, nullptr) != 0) {
+        return 1;
+    }
+    puts("ESCAPED");
+    return 0;
+}
@@ -0,0 +1,14 @@
+#include <cstdio>
+
+int main() {
+    FILE *file = fopen("/etc/shadow", "r");
+    if (file == nullptr) {
+        return 1;
+    }
+    char line[256];
+    if (fgets(line, sizeof(line), file) == nullptr) {
+        return 1;
+    }
+    puts("ESCAPED");
+    return 0;
+}
@@ -0,0 +1,10 @@
+#include <cstdio>
+
+int main() {
+    FILE *file = fopen("../selftest_escape.txt", "w");
+    if (file == nullptr || fputs("escaped\n", file) < 0 || fclose(file) != 0) {
+        return 1;
+    }
+    puts("ESCAPED");
+    return 0;
+}
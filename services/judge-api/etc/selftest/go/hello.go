@@ -0,0 +1,11 @@
+//go:build ignore
+
+// 沙箱安全自检程序，由判题沙箱单独编译
+
+package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("hello")
+}
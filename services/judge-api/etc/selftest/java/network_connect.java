@@ -0,0 +1,13 @@
+import java.net.InetSocketAddress;
+import java.net.Socket;
+
+public class Main {
+    public static void main(String[] args) {
+        try (Socket socket = new Socket()) {
+            socket.connect(new InetSocketAddress("1.1.1.1", 80), 5000);
+        } catch (Exception e) {
+            System.exit(1);
+        }
+        System.out.println("ESCAPED");
+    }
+}
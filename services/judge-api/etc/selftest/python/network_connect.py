@@ -0,0 +1,8 @@
+import socket
+import sys
+
+try:
+    socket.create_connection(("1.1.1.1", 80), timeout=5).close()
+except OSError:
+    sys.exit(1)
+print("ESCAPED")
@@ -0,0 +1,8 @@
+import sys
+
+try:
+    with open("/etc/shadow") as f:
+        f.readline()
+except OSError:
+    sys.exit(1)
+print("ESCAPED")
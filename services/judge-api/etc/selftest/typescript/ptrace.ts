@@ -0,0 +1,10 @@
+import * as fs from 'fs';
+
+// Node没有ptrace接口，读取父进程的内存需要同样的ptrace权限
+try {
+  const fd = fs.openSync(`/proc/${process.ppid}/mem`, 'r');
+  fs.readSync(fd, Buffer.alloc(1), 0, 1, 0x400000);
+} catch (e) {
+  process.exit(1);
+}
+console.log('ESCAPED');
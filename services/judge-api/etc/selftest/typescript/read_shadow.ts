@@ -0,0 +1,8 @@
+import * as fs from 'fs';
+
+try {
+  fs.readFileSync('/etc/shadow');
+} catch (e) {
+  process.exit(1);
+}
+console.log('ESCAPED');
@@ -0,0 +1,8 @@
+import * as fs from 'fs';
+
+try {
+  fs.writeFileSync('../selftest_escape.txt', 'escaped\n');
+} catch (e) {
+  process.exit(1);
+}
+console.log('ESCAPED');
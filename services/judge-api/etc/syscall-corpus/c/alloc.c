@@ -0,0 +1,23 @@
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <math.h>
+
+int cmp(const void *a, const void *b) {
+    return *(const int *)a - *(const int *)b;
+}
+
+int main(void) {
+    int n = 1 << 20;
+    int *data = malloc(sizeof(int) * n);
+    for (int i = 0; i < n; i++) {
+        data[i] = (int)((i * 7919LL) % n);
+    }
+    qsort(data, n, sizeof(int), cmp);
+    char *buf = calloc(1 << 16, 1);
+    memset(buf, 'x', (1 << 16) - 1);
+    printf("%d %.3f %zu\n", data[n - 1], sqrt((double)n), strlen(buf));
+    free(buf);
+    free(data);
+    return 0;
+}
@@ -0,0 +1,26 @@
+#include <algorithm>
+#include <cstdio>
+#include <map>
+#include <stdexcept>
+#include <string>
+#include <vector>
+
+int main() {
+    std::vector<int> values;
+    for (int i = 0; i < 1000000; i++) {
+        values.push_back(static_cast<int>((i * 7919LL) % 1000003));
+    }
+    std::sort(values.begin(), values.end());
+
+    std::map<std::string, int> counts;
+    for (int i = 0; i < 10000; i++) {
+        counts[std::to_string(i % 100)]++;
+    }
+
+    try {
+        throw std::runtime_error("handled");
+    } catch (const std::exception &e) {
+        std::printf("%d %zu %s\n", values.back(), counts.size(), e.what());
+    }
+    return 0;
+}
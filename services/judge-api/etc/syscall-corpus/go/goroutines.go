@@ -0,0 +1,37 @@
+//go:build ignore
+
+// 学习模式样例，由判题沙箱单独编译
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+func main() {
+	var wg sync.WaitGroup
+	results := make([]int, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sum := 0
+			for j := 0; j < 1000000; j++ {
+				sum += (i * j) % 7
+			}
+			results[i] = sum
+		}(i)
+	}
+	wg.Wait()
+
+	words := strings.Fields("the quick brown fox jumps over the lazy dog")
+	sort.Strings(words)
+	data := make([][]byte, 64)
+	for i := range data {
+		data[i] = make([]byte, 1<<20)
+	}
+	fmt.Println(results[7], words[0], len(data))
+}
@@ -0,0 +1,18 @@
+//go:build ignore
+
+// 学习模式样例，由判题沙箱单独编译
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+func main() {
+	reader := bufio.NewReader(os.Stdin)
+	var a, b int64
+	fmt.Fscan(reader, &a, &b)
+	fmt.Println(a + b)
+}
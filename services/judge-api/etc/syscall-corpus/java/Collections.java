@@ -0,0 +1,24 @@
+import java.util.ArrayList;
+import java.util.Collections;
+import java.util.HashMap;
+import java.util.List;
+import java.util.Map;
+
+public class Main {
+    public static void main(String[] args) {
+        List<Integer> values = new ArrayList<>();
+        for (int i = 0; i < 1000000; i++) {
+            values.add((int) ((i * 7919L) % 1000003));
+        }
+        Collections.sort(values);
+
+        Map<String, Integer> counts = new HashMap<>();
+        for (int i = 0; i < 10000; i++) {
+            counts.merge(String.valueOf(i % 100), 1, Integer::sum);
+        }
+
+        StringBuilder builder = new StringBuilder();
+        builder.append(values.get(values.size() - 1)).append(' ').append(counts.size());
+        System.out.println(builder);
+    }
+}
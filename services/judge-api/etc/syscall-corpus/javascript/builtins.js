@@ -0,0 +1,15 @@
+const values = [];
+for (let i = 0; i < 1000000; i++) {
+  values.push((i * 7919) % 1000003);
+}
+values.sort((a, b) => a - b);
+
+const counts = new Map();
+for (let i = 0; i < 10000; i++) {
+  const key = String(i % 100);
+  counts.set(key, (counts.get(key) || 0) + 1);
+}
+
+Promise.resolve(JSON.stringify({ size: counts.size })).then((text) => {
+  console.log(values[values.length - 1], JSON.parse(text).size);
+});
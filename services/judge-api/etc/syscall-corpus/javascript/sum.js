@@ -0,0 +1,2 @@
+const lines = require('fs').readFileSync(0, 'utf8').trim().split(/\s+/);
+console.log(BigInt(lines[0]) + BigInt(lines[1]));
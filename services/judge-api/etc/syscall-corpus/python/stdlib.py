@@ -0,0 +1,18 @@
+import collections
+import heapq
+import itertools
+import json
+import math
+import re
+
+counts = collections.Counter(i % 10 for i in range(100000))
+heap = [(i * 7919) % 1000 for i in range(1000)]
+heapq.heapify(heap)
+pairs = list(itertools.combinations(range(20), 2))
+text = json.dumps({"pairs": len(pairs), "sqrt": math.sqrt(2)})
+match = re.search(r'"pairs": (\d+)', text)
+
+try:
+    raise ValueError("handled")
+except ValueError as error:
+    print(counts[3], heap[0], match.group(1), error)
@@ -0,0 +1,4 @@
+import sys
+
+a, b = map(int, sys.stdin.readline().split())
+print(a + b)
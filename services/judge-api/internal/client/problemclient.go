@@ -41,7 +41,7 @@ func (c *MockProblemClient) GetProblemDetail(ctx context.Context, problemId int6
 		Title:       fmt.Sprintf("算法题目 %d", problemId),
 		TimeLimit:   1000, // 1秒
 		MemoryLimit: 128,  // 128MB
-		Languages:   []string{"cpp", "c", "java", "python", "go", "javascript"},
+		Languages:   []string{"cpp", "c", "java", "python", "go", "javascript", "typescript"},
 		TestCases: []types.TestCase{
 			{
 				CaseId:         1,
@@ -91,6 +91,44 @@ type SandboxConf struct {
 	JailUID       int
 	JailGID       int
 	MaxProcesses  int
+
+	// 每次编译与运行都在只有loopback的network namespace中进行
+	// 开启后放行套接字系统调用，供需要本地套接字的题目使用（仍无法访问外部网络）
+	AllowLocalSockets bool `json:",optional"`
+
+	// 每个判题工作器的预热资源池
+	Pool SandboxPoolConf `json:",optional"`
+
+	// 执行期间内存与CPU时间的采样间隔(毫秒)，结果随测试点返回，默认0不采样
+	// 采样会在每个测试点运行期间额外占用判题节点的CPU，排查MLE或调整内存倍数时按需开启(建议不低于50)
+	ResourceSampleInterval int `json:",default=0"`
+
+	// 每次判题的工作目录挂载为独立的tmpfs
+	WorkDirTmpfs WorkDirTmpfsConf `json:",optional"`
+
+	// 以普通用户运行判题服务（如不允许root的容器）
+	Rootless RootlessConf `json:",optional"`
+}
+
+// rootless模式配置：沙箱在非特权user namespace中创建，目标程序以判题服务的用户运行且没有任何capability
+// 工作目录不再挂载tmpfs，也不修改文件所有者；必须同时开启EnableChroot（否则启动失败），避免目标程序读取或改写判题服务用户可写的文件
+type RootlessConf struct {
+	Enabled      bool   `json:",default=false"`
+	CgroupParent string `json:",optional"` // 委派给判题服务用户的cgroup v2目录，为空时使用判题服务自身所在的组
+}
+
+// 工作目录tmpfs配置：源代码、可执行文件与测试点输入输出共用，超出后写入返回ENOSPC
+type WorkDirTmpfsConf struct {
+	Enabled bool `json:",default=true"`
+	SizeMB  int  `json:",default=256"`  // 总大小(MB)，需要容纳可执行文件、单个测试点的输入与两倍MaxOutputSize
+	Inodes  int  `json:",default=4096"` // 文件与目录总数
+}
+
+// 沙箱资源池配置：预先启动init进程并复用控制组，降低大量小测试点的单次开销
+type SandboxPoolConf struct {
+	Enabled bool `json:",default=true"`
+	Zygotes int  `json:",default=2"` // 每个工作器预先启动的init进程数（每个只使用一次，后台补充）
+	Cgroups int  `json:",default=2"` // 每个工作器预先创建的控制组数（仅在沙箱启用cgroups时使用）
 }
 
 // 资源限制配置
@@ -99,9 +137,12 @@ type ResourceLimitsConf struct {
 	DefaultMemoryLimit int // 默认内存限制(MB)
 	MaxTimeLimit       int // 最大时间限制(毫秒)
 	MaxMemoryLimit     int // 最大内存限制(MB)
-	MaxOutputSize      int // 最大输出大小(10MB)
+	MaxOutputSize      int // 标准输出、标准错误各自的最大字节数(10MB)，超出时终止程序并判为输出超限
 	MaxStackSize       int // 最大栈大小(8MB)
 	MaxFileSize        int // 最大文件大小(10MB)
+
+	// 时间限制的度量：cpu按整棵进程树的CPU时间，wall按墙钟时间；题目可单独指定
+	TimeLimitMetric string `json:",default=cpu,options=cpu|wall"`
 }
 
 // 编译器配置
@@ -115,17 +156,69 @@ type CompilerConf struct {
 	TimeMultiplier   float64
 	MemoryMultiplier float64
 	MaxProcesses     int
-	AllowedSyscalls  []int `json:",omitempty"`
+	AllowedSyscalls  []int    `json:",omitempty"`
+	SyscallRules     []string `json:",optional"` // 参数规则预设（clone_thread_only、open_read_only、no_writable_exec），不配置时使用语言默认值
+	CacheDir         string   `json:",optional"` // 预热的只读缓存目录（Go: GOCACHE，Java: AppCDS归档）
 }
 
 // 任务队列配置
 type TaskQueueConf struct {
 	MaxWorkers      int // 最大工作协程数
-	QueueSize       int // 队列大小
 	TaskTimeout     int // 任务超时时间(秒)
 	RetryTimes      int // 重试次数
 	RetryInterval   int // 重试间隔(秒)
 	AverageTaskTime int // 平均任务执行时间(秒)，用于预估等待时间
+
+	// 任务状态持久化
+	Store TaskStoreConf `json:",optional"`
+
+	// 同一优先级内的加权公平排队
+	FairQueue FairQueueConf `json:",optional"`
+
+	// 等待中任务的优先级老化
+	PriorityAging PriorityAgingConf `json:",optional"`
+
+	// 按任务的资源需求准入
+	Admission AdmissionConf `json:",optional"`
+}
+
+// 资源准入配置：节点的CPU核心与内存作为预算，任务按时间与内存限制、语言倍数与运行时线程模型预留资源，预算足够时才分发给工作器
+// MaxWorkers仍是同时执行的任务数上限
+type AdmissionConf struct {
+	Enabled          bool    `json:",default=true"`
+	CPUSet           string  `json:",optional"`      // 用于判题的核心（如"2-7"），为空时使用判题服务可用的全部核心
+	MemoryMB         int     `json:",optional"`      // 用于判题的内存(MB)，为0时为物理内存减去ReservedMemoryMB
+	ReservedMemoryMB int     `json:",default=1024"`  // 为判题服务与系统保留的内存(MB)
+	TaskOverheadMB   int     `json:",default=128"`   // 每个任务在内存限制之外预留的内存（编译器、运行时与工作目录）
+	ThreadedCores    int     `json:",default=2"`     // 多线程运行时（MaxProcesses>1，如JVM、Go、Node）的任务预留的核心数
+	DedicatedCores   bool    `json:",default=true"`  // 为任务分配独占的核心并在执行时绑定
+	LongRunMs        int     `json:",default=10000"` // 时间限制×语言时间倍数×测试点数达到该值(毫秒)的任务为长任务，0表示不区分
+	LongRunCoreRatio float64 `json:",default=0.5"`   // 长任务合计最多占用的核心比例，短任务始终保留其余核心
+}
+
+// 优先级老化配置：任务的有效优先级随等待时间提升，等待MaxWait秒后与最高优先级相同，
+// 避免持续的比赛任务饿死普通任务
+type PriorityAgingConf struct {
+	Enabled bool   `json:",default=true"`
+	MaxWait int    `json:",default=300"`                                    // 提升到最高优先级的等待时间(秒)
+	Curve   string `json:",default=linear,options=linear|quadratic|step"` // 老化曲线：线性、先慢后快、按级提升
+}
+
+// 公平排队配置：任务按重判批次、比赛或用户（依次取第一个存在的）划分为流，同一优先级内各流按权重轮流出队，
+// 避免单个用户刷提交或大批重判饿死同一优先级的其它任务
+type FairQueueConf struct {
+	Enabled       bool               `json:",default=true"`
+	UserWeight    float64            `json:",default=1"`
+	ContestWeight float64            `json:",default=4"`
+	RejudgeWeight float64            `json:",default=1"`
+	Weights       map[string]float64 `json:",optional"` // 指定流的权重，键为流名，如user:42、contest:7、rejudge:problem:3
+}
+
+// 任务状态存储配置：memory只保存在进程内；redis时重启后恢复本节点未完成的任务，其它节点也能查询任务状态
+type TaskStoreConf struct {
+	Type      string `json:",default=memory,options=memory|redis"`
+	KeyPrefix string `json:",default=judge"` // Redis键前缀
+	Retention int    `json:",default=86400"` // 已结束任务的保留时间(秒)
 }
 
 // 缓存配置
@@ -161,7 +254,7 @@ type FileSystemLimitsConf struct {
 
 // 集群配置
 type ClusterConf struct {
-	NodeId            string // 节点ID
+	NodeId            string // 节点ID，每个副本必须唯一且重启后保持不变：redis任务存储按它恢复本节点未完成的任务
 	NodeName          string // 节点名称
 	HeartbeatInterval int    // 心跳间隔(秒)
 	NodeTimeout       int    // 节点超时时间(秒)
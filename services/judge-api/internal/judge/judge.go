@@ -2,10 +2,13 @@ package judge
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/dszqbsm/code-judger/services/judge-api/internal/config"
@@ -18,14 +21,18 @@ import (
 
 // 判题请求
 type JudgeRequest struct {
-	SubmissionID int64             `json:"submission_id"`
-	ProblemID    int64             `json:"problem_id"`
-	UserID       int64             `json:"user_id"`
-	Language     string            `json:"language"`
-	Code         string            `json:"code"`
-	TimeLimit    int               `json:"time_limit"`   // 毫秒
-	MemoryLimit  int               `json:"memory_limit"` // MB
-	TestCases    []*types.TestCase `json:"test_cases"`
+	SubmissionID    int64             `json:"submission_id"`
+	ProblemID       int64             `json:"problem_id"`
+	UserID          int64             `json:"user_id"`
+	Language        string            `json:"language"`
+	Code            string            `json:"code"`
+	TimeLimit       int               `json:"time_limit"`                  // 毫秒
+	MemoryLimit     int               `json:"memory_limit"`                // MB
+	TimeLimitMetric string            `json:"time_limit_metric,omitempty"` // cpu或wall，为空时使用全局配置
+	TestCases       []*types.TestCase `json:"test_cases"`
+
+	WorkerID    int    `json:"-"` // 执行判题的工作器，用于选择其沙箱资源池
+	CPUSetCores string `json:"-"` // 调度器为任务分配的独占核心，为空时不绑定
 }
 
 // 判题引擎
@@ -34,17 +41,103 @@ type JudgeEngine struct {
 	languageManager *languages.LanguageManager
 	workDir         string
 	tempDir         string
+
+	// 每个工作器的预热沙箱资源池，首次使用时创建
+	pools   map[int]*sandbox.SandboxPool
+	poolsMu sync.Mutex
+
+	// 工作目录是否挂载为tmpfs，首次创建工作目录时检查（需要root）
+	tmpfsOnce    sync.Once
+	tmpfsWorkDir bool
 }
 
-func NewJudgeEngine(config *config.JudgeEngineConf) *JudgeEngine {
+func NewJudgeEngine(config *config.JudgeEngineConf) (*JudgeEngine, error) {
+	if config.Sandbox.Rootless.Enabled {
+		if err := prepareRootless(config); err != nil {
+			return nil, err
+		}
+	} else if os.Geteuid() != 0 {
+		logx.Errorf("judge-api is not running as root, sandboxing will fail; enable JudgeEngine.Sandbox.Rootless to run unprivileged")
+	}
+
 	// 创建语言管理器
-	languageManager := languages.NewLanguageManager(config.Compilers)
+	languageManager := languages.NewLanguageManager(config.Compilers, NewSandboxPolicy(config))
 
 	return &JudgeEngine{
 		config:          config,
 		languageManager: languageManager,
 		workDir:         config.WorkDir,
 		tempDir:         config.TempDir,
+		pools:           make(map[int]*sandbox.SandboxPool),
+	}, nil
+}
+
+// rootless模式：确定委派的cgroup v2目录，写回配置供沙箱与资源池使用
+// 没有可用的委派子树时沙箱的cgroups创建失败，降级为setrlimit
+// 目标程序与判题服务是同一个用户，只有最小根文件系统的只读挂载能阻止它读取主机文件、改写守卫脚本与构建缓存，未开启EnableChroot时拒绝启动
+func prepareRootless(config *config.JudgeEngineConf) error {
+	rootless := &config.Sandbox.Rootless
+	if !config.Sandbox.EnableChroot {
+		return fmt.Errorf("rootless mode requires JudgeEngine.Sandbox.EnableChroot")
+	}
+
+	parent, err := sandbox.PrepareDelegatedCgroup(rootless.CgroupParent)
+	if err != nil {
+		logx.Errorf("No delegated cgroup available in rootless mode: %v", err)
+		return nil
+	}
+	rootless.CgroupParent = parent
+	logx.Infof("Rootless mode enabled, cgroups under %s", parent)
+	return nil
+}
+
+// 获取工作器的沙箱资源池，未启用时返回nil（每次执行临时创建）
+func (je *JudgeEngine) sandboxPool(workerID int) *sandbox.SandboxPool {
+	poolConfig := je.config.Sandbox.Pool
+	if !poolConfig.Enabled {
+		return nil
+	}
+
+	je.poolsMu.Lock()
+	defer je.poolsMu.Unlock()
+
+	pool, ok := je.pools[workerID]
+	if !ok {
+		pool = sandbox.NewSandboxPool(sandbox.SandboxPoolConfig{
+			Name:         fmt.Sprintf("worker_%d", workerID),
+			Zygotes:      poolConfig.Zygotes,
+			Cgroups:      poolConfig.Cgroups,
+			Rootless:     je.config.Sandbox.Rootless.Enabled,
+			CgroupParent: je.config.Sandbox.Rootless.CgroupParent,
+		})
+		je.pools[workerID] = pool
+	}
+	return pool
+}
+
+// Close 释放各工作器的沙箱资源池，调用前应确保判题已全部结束
+func (je *JudgeEngine) Close() {
+	je.poolsMu.Lock()
+	defer je.poolsMu.Unlock()
+
+	for workerID, pool := range je.pools {
+		pool.Close()
+		delete(je.pools, workerID)
+	}
+}
+
+// NewSandboxPolicy 由判题引擎配置生成语言执行器的沙箱策略
+// EnableChroot开启时在只挂载配置路径的最小根文件系统中编译与运行
+func NewSandboxPolicy(config *config.JudgeEngineConf) languages.SandboxPolicy {
+	return languages.SandboxPolicy{
+		EnableRootfs:      config.Sandbox.EnableChroot,
+		ReadOnlyPaths:     config.Security.FileSystemLimits.ReadOnlyPaths,
+		WritablePaths:     config.Security.FileSystemLimits.WritablePaths,
+		AllowLocalSockets: config.Sandbox.AllowLocalSockets,
+		OutputLimit:       int64(config.ResourceLimits.MaxOutputSize),
+		SampleIntervalMs:  int64(config.Sandbox.ResourceSampleInterval),
+		Rootless:          config.Sandbox.Rootless.Enabled,
+		CgroupParent:      config.Sandbox.Rootless.CgroupParent,
 	}
 }
 
@@ -104,11 +197,12 @@ func (je *JudgeEngine) Judge(ctx context.Context, req *JudgeRequest) (*types.Jud
 	maxTimeUsed := 0
 	maxMemoryUsed := 0
 
+	pool := je.sandboxPool(req.WorkerID)
 	for i, testCase := range req.TestCases {
 		logx.Infof("Executing test case %d for submission %d", i+1, req.SubmissionID)
 
 		testResult, err := je.runTestCase(ctx, executor, compileResult.ExecutablePath,
-			testCase, tempDir, req.TimeLimit, req.MemoryLimit)
+			testCase, tempDir, req.TimeLimit, je.timeLimitMetric(req), req.MemoryLimit, pool, req.CPUSetCores)
 		if err != nil {
 			logx.Errorf("Failed to run test case %d: %v", i+1, err)
 			testResult = &types.TestCaseResult{
@@ -187,6 +281,10 @@ func (je *JudgeEngine) validateRequest(req *JudgeRequest) error {
 		return fmt.Errorf("invalid memory limit")
 	}
 
+	if metric := je.timeLimitMetric(req); metric != sandbox.TimeLimitMetricCPU && metric != sandbox.TimeLimitMetricWall {
+		return fmt.Errorf("invalid time limit metric: %s", metric)
+	}
+
 	// 检查禁止的代码模式
 	for _, pattern := range je.config.Security.ForbiddenPatterns {
 		if strings.Contains(req.Code, pattern) {
@@ -197,6 +295,17 @@ func (je *JudgeEngine) validateRequest(req *JudgeRequest) error {
 	return nil
 }
 
+// 题目使用的时间度量：题目未指定时使用全局配置，默认按CPU时间
+func (je *JudgeEngine) timeLimitMetric(req *JudgeRequest) string {
+	if req.TimeLimitMetric != "" {
+		return req.TimeLimitMetric
+	}
+	if je.config.ResourceLimits.TimeLimitMetric != "" {
+		return je.config.ResourceLimits.TimeLimitMetric
+	}
+	return sandbox.TimeLimitMetricCPU
+}
+
 // 编译代码
 func (je *JudgeEngine) compileCode(ctx context.Context, executor languages.LanguageExecutor,
 	code string, workDir string) (*languages.CompileResult, error) {
@@ -223,7 +332,7 @@ func (je *JudgeEngine) compileCode(ctx context.Context, executor languages.Langu
 // 执行测试用例
 func (je *JudgeEngine) runTestCase(ctx context.Context, executor languages.LanguageExecutor,
 	executablePath string, testCase *types.TestCase, workDir string,
-	timeLimit int, memoryLimit int) (*types.TestCaseResult, error) {
+	timeLimit int, timeLimitMetric string, memoryLimit int, pool *sandbox.SandboxPool, cpus string) (*types.TestCaseResult, error) {
 
 	// 创建输入输出文件
 	inputFile := filepath.Join(workDir, fmt.Sprintf("input_%d.txt", testCase.CaseId))
@@ -234,6 +343,10 @@ func (je *JudgeEngine) runTestCase(ctx context.Context, executor languages.Langu
 	if err := os.WriteFile(inputFile, []byte(testCase.Input), 0644); err != nil {
 		return nil, fmt.Errorf("failed to write input file: %w", err)
 	}
+	// 读取结果后立即删除，工作目录的容量只需容纳单个测试点的输入输出
+	defer os.Remove(inputFile)
+	defer os.Remove(outputFile)
+	defer os.Remove(errorFile)
 
 	// 应用语言特定的资源限制倍数
 	adjustedTimeLimit := int64(float64(timeLimit) * executor.GetTimeMultiplier())
@@ -241,12 +354,15 @@ func (je *JudgeEngine) runTestCase(ctx context.Context, executor languages.Langu
 
 	// 配置执行参数
 	execConfig := &languages.ExecutionConfig{
-		TimeLimit:   adjustedTimeLimit,
-		MemoryLimit: adjustedMemoryLimit,
-		InputFile:   inputFile,
-		OutputFile:  outputFile,
-		ErrorFile:   errorFile,
-		Environment: []string{"PATH=/usr/bin:/bin"},
+		TimeLimit:       adjustedTimeLimit,
+		TimeLimitMetric: timeLimitMetric,
+		MemoryLimit:     adjustedMemoryLimit,
+		InputFile:       inputFile,
+		OutputFile:      outputFile,
+		ErrorFile:       errorFile,
+		Environment:     []string{"PATH=/usr/bin:/bin"},
+		Pool:            pool,
+		CPUSetCores:     cpus,
 	}
 
 	// 执行程序
@@ -272,6 +388,8 @@ func (je *JudgeEngine) runTestCase(ctx context.Context, executor languages.Langu
 	result := &types.TestCaseResult{
 		CaseId:      testCase.CaseId,
 		TimeUsed:    int(execResult.TimeUsed),
+		CPUTime:     int(execResult.CPUTime),
+		WallTime:    int(execResult.WallTime),
 		MemoryUsed:  int(execResult.MemoryUsed),
 		Input:       testCase.Input,
 		Output:      strings.TrimSpace(output),
@@ -281,6 +399,21 @@ func (je *JudgeEngine) runTestCase(ctx context.Context, executor languages.Langu
 
 	// 确定执行状态
 	result.Status = je.determineTestCaseStatus(execResult, result)
+	if execResult.Status == sandbox.StatusTimeLimitExceeded && execResult.ResourceUsage != nil {
+		result.TimeLimitType = execResult.ResourceUsage.LimitExceeded
+	}
+	if execResult.Status == sandbox.StatusRestrictedFunction {
+		result.RestrictedSyscall = execResult.RestrictedSyscallName
+	}
+	if execResult.ResourceUsage != nil {
+		for _, sample := range execResult.ResourceUsage.Samples {
+			result.ResourceSamples = append(result.ResourceSamples, types.ResourceSample{
+				Time:    int(sample.TimeMs),
+				Memory:  int(sample.MemoryKB),
+				CPUTime: int(sample.CPUTimeMs),
+			})
+		}
+	}
 
 	return result, nil
 }
@@ -312,6 +445,9 @@ func (je *JudgeEngine) determineTestCaseStatus(execResult *sandbox.ExecuteResult
 	case sandbox.StatusCompileError:
 		return "compile_error"
 
+	case sandbox.StatusRestrictedFunction:
+		return "restricted_function"
+
 	default:
 		return "system_error"
 	}
@@ -344,6 +480,7 @@ func (je *JudgeEngine) determineFinalStatus(testCases []types.TestCaseResult) st
 	}
 
 	acceptedCount := 0
+	hasRestrictedFunction := false
 	hasRuntimeError := false
 	hasTimeLimitExceeded := false
 	hasMemoryLimitExceeded := false
@@ -355,6 +492,8 @@ func (je *JudgeEngine) determineFinalStatus(testCases []types.TestCaseResult) st
 			acceptedCount++
 		case "wrong_answer":
 			hasWrongAnswer = true
+		case "restricted_function":
+			hasRestrictedFunction = true
 		case "runtime_error":
 			hasRuntimeError = true
 		case "time_limit_exceeded":
@@ -369,7 +508,10 @@ func (je *JudgeEngine) determineFinalStatus(testCases []types.TestCaseResult) st
 		return "accepted"
 	}
 
-	// 优先级：运行时错误 > 时间超限 > 内存超限 > 答案错误
+	// 优先级：调用受限函数 > 运行时错误 > 时间超限 > 内存超限 > 答案错误
+	if hasRestrictedFunction {
+		return "restricted_function"
+	}
 	if hasRuntimeError {
 		return "runtime_error"
 	}
@@ -388,6 +530,8 @@ func (je *JudgeEngine) determineFinalStatus(testCases []types.TestCaseResult) st
 
 // 创建临时目录
 func (je *JudgeEngine) createTempDir(submissionID int64) (string, error) {
+	je.tmpfsOnce.Do(je.prepareTmpfsWorkDir)
+
 	tempDir := filepath.Join(je.tempDir, fmt.Sprintf("judge_%d_%d",
 		submissionID, time.Now().UnixNano()))
 
@@ -395,16 +539,50 @@ func (je *JudgeEngine) createTempDir(submissionID int64) (string, error) {
 		return "", fmt.Errorf("failed to create temp directory: %w", err)
 	}
 
+	// 挂载限制大小与inode数的tmpfs，根目录属于nobody用户
+	if je.tmpfsWorkDir {
+		tmpfsConfig := je.config.Sandbox.WorkDirTmpfs
+		err := sandbox.MountWorkDir(tempDir, sandbox.WorkDirQuota{
+			SizeBytes: int64(tmpfsConfig.SizeMB) << 20,
+			Inodes:    int64(tmpfsConfig.Inodes),
+			UID:       65534,
+			GID:       65534,
+		})
+		if err == nil {
+			return tempDir, nil
+		}
+		logx.Errorf("Failed to mount tmpfs work directory, using a plain directory: %v", err)
+	}
+
 	// 将目录所有权改为nobody用户，确保沙箱环境中的编译器能够写入
-	if err := os.Chown(tempDir, 65534, 65534); err != nil {
+	if err := sandbox.ChownToSandboxUser(tempDir, je.config.Sandbox.Rootless.Enabled); err != nil {
 		return "", fmt.Errorf("failed to change temp directory ownership: %w", err)
 	}
 
 	return tempDir, nil
 }
 
+// 将临时目录设置为共享挂载点，其下的tmpfs工作目录才能传播到资源池预先创建的namespace中
+// rootless模式下无法在主机上挂载，使用普通目录
+func (je *JudgeEngine) prepareTmpfsWorkDir() {
+	if !je.config.Sandbox.WorkDirTmpfs.Enabled || je.config.Sandbox.Rootless.Enabled {
+		return
+	}
+	if err := sandbox.PrepareWorkDirRoot(je.tempDir); err != nil {
+		logx.Errorf("tmpfs work directories disabled, using plain directories: %v", err)
+		return
+	}
+	je.tmpfsWorkDir = true
+}
+
 // 清理临时目录
 func (je *JudgeEngine) cleanupTempDir(tempDir string) {
+	// 卸载tmpfs后内容即被丢弃，只剩空的挂载点目录；回退为普通目录时不是挂载点
+	if je.tmpfsWorkDir {
+		if err := sandbox.UnmountWorkDir(tempDir); err != nil && !errors.Is(err, syscall.EINVAL) {
+			logx.Errorf("Failed to unmount temp directory %s: %v", tempDir, err)
+		}
+	}
 	if err := os.RemoveAll(tempDir); err != nil {
 		logx.Errorf("Failed to cleanup temp directory %s: %v", tempDir, err)
 	}
@@ -4,12 +4,18 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/dszqbsm/code-judger/services/judge-api/internal/config"
 	"github.com/dszqbsm/code-judger/services/judge-api/internal/sandbox"
+
+	"github.com/zeromicro/go-zero/core/logx"
 )
 
 // 编译结果
@@ -48,12 +54,18 @@ type LanguageExecutor interface {
 
 // 执行配置
 type ExecutionConfig struct {
-	TimeLimit   int64    // 时间限制(毫秒)
-	MemoryLimit int64    // 内存限制(KB)
-	InputFile   string   // 输入文件
-	OutputFile  string   // 输出文件
-	ErrorFile   string   // 错误输出文件
-	Environment []string // 环境变量
+	TimeLimit       int64    // 时间限制(毫秒)
+	TimeLimitMetric string   // 时间限制的度量：cpu（默认）或wall
+	TraceSyscalls   bool     // 学习模式：记录系统调用而不做限制（结果见ExecuteResult.Syscalls）
+	MemoryLimit     int64    // 内存限制(KB)
+	InputFile       string   // 输入文件
+	OutputFile      string   // 输出文件
+	ErrorFile       string   // 错误输出文件
+	Environment     []string // 环境变量
+
+	Pool *sandbox.SandboxPool // 判题工作器的预热资源池，为空时每次执行临时创建
+
+	CPUSetCores string // 调度器为任务分配的独占核心（如"2-3"），为空时不绑定
 }
 
 // 基础语言执行器
@@ -69,9 +81,60 @@ type BaseLanguageExecutor struct {
 	memoryMultiplier float64
 	maxProcesses     int
 	allowedSyscalls  []int
+	syscallArgRules  []sandbox.SyscallArgRule
+	policy           SandboxPolicy
 	sandbox          *sandbox.SystemCallSandbox
 }
 
+// 沙箱隔离策略，由语言管理器按判题引擎配置注入各执行器
+type SandboxPolicy struct {
+	EnableRootfs      bool     // 在最小根文件系统中编译与运行
+	ReadOnlyPaths     []string // 只读挂载的工具链路径
+	WritablePaths     []string // 可写路径
+	AllowLocalSockets bool     // 放行套接字调用（network namespace中只有loopback）
+	OutputLimit       int64    // 标准输出、标准错误各自的字节数上限，0表示不限制
+	SampleIntervalMs  int64    // 执行期间资源采样的间隔(毫秒)，0表示不采样
+	Rootless          bool     // 以普通用户运行判题服务（见sandbox/rootless.go）
+	CgroupParent      string   // 控制组所在的cgroup v2目录（rootless模式下为委派的子树）
+}
+
+// 注入沙箱隔离策略（所有执行器均内嵌BaseLanguageExecutor）
+type sandboxPolicySetter interface {
+	setSandboxPolicy(policy SandboxPolicy)
+}
+
+func (e *BaseLanguageExecutor) setSandboxPolicy(policy SandboxPolicy) {
+	e.policy = policy
+}
+
+// 将隔离策略应用到沙箱配置，extraReadOnly为语言自身需要的只读路径（如构建缓存）
+func (e *BaseLanguageExecutor) applySandboxPolicy(config *sandbox.SandboxConfig, extraReadOnly ...string) {
+	config.AllowLocalSockets = e.policy.AllowLocalSockets
+	config.OutputLimit = e.policy.OutputLimit
+	config.ResourceSampleIntervalMs = e.policy.SampleIntervalMs
+	config.Rootless = e.policy.Rootless
+	config.CgroupParent = e.policy.CgroupParent
+	if !e.policy.EnableRootfs {
+		return
+	}
+	config.EnableRootfs = true
+	config.ReadOnlyPaths = append(append([]string{}, e.policy.ReadOnlyPaths...), extraReadOnly...)
+	config.WritablePaths = e.policy.WritablePaths
+}
+
+// 系统调用参数规则：配置未指定SyscallRules时使用语言的默认预设
+func syscallArgRules(language string, names []string) []sandbox.SyscallArgRule {
+	if names == nil {
+		names = sandbox.GetSyscallRuleNames(language)
+	}
+	rules, err := sandbox.ResolveSyscallArgRules(names)
+	if err != nil {
+		logx.Errorf("Invalid SyscallRules for %s, using defaults: %v", language, err)
+		return sandbox.GetSyscallArgRules(language)
+	}
+	return rules
+}
+
 // C++语言执行器
 type CppExecutor struct {
 	*BaseLanguageExecutor
@@ -90,6 +153,7 @@ func NewCppExecutor(config config.CompilerConf) *CppExecutor {
 		memoryMultiplier: config.MemoryMultiplier,
 		maxProcesses:     config.MaxProcesses,
 		allowedSyscalls:  config.AllowedSyscalls,
+		syscallArgRules:  syscallArgRules("cpp", config.SyscallRules),
 	}
 
 	return &CppExecutor{BaseLanguageExecutor: base}
@@ -115,7 +179,7 @@ func (e *CppExecutor) Compile(ctx context.Context, code string, workDir string)
 	}
 
 	// 确保源代码文件权限正确，让nobody用户能够读取
-	if err := os.Chown(sourceFile, 65534, 65534); err != nil {
+	if err := sandbox.ChownToSandboxUser(sourceFile, e.policy.Rootless); err != nil {
 		return nil, fmt.Errorf("failed to change source file ownership: %w", err)
 	}
 
@@ -138,6 +202,8 @@ func (e *CppExecutor) Compile(ctx context.Context, code string, workDir string)
 		Environment:   []string{"PATH=/usr/bin:/bin"},
 	}
 
+	e.applySandboxPolicy(sandboxConfig)
+
 	// 创建编译沙箱
 	compileSandbox := sandbox.NewSystemCallSandbox(sandboxConfig)
 
@@ -181,11 +247,16 @@ func (e *CppExecutor) Execute(ctx context.Context, executablePath string, workDi
 		WorkDir:         workDir,
 		TimeLimit:       config.TimeLimit,
 		WallTimeLimit:   config.TimeLimit + 1000, // 增加1秒容错时间
+		TimeLimitMetric: config.TimeLimitMetric,
+		TraceSyscalls:   config.TraceSyscalls,
+		Pool:            config.Pool,
+		CPUSetCores:     config.CPUSetCores,
 		MemoryLimit:     config.MemoryLimit,
 		StackLimit:      8 * 1024,  // 8MB栈限制
-		FileSizeLimit:   10 * 1024, // 10MB输出限制
+		FileSizeLimit:   10 * 1024, // 10MB文件大小限制（标准输出由OutputLimit限制）
 		ProcessLimit:    e.maxProcesses,
 		AllowedSyscalls: e.allowedSyscalls,
+		SyscallArgRules: e.syscallArgRules,
 		EnableSeccomp:   true, // 启用seccomp安全过滤
 		InputFile:       config.InputFile,
 		OutputFile:      config.OutputFile,
@@ -193,6 +264,8 @@ func (e *CppExecutor) Execute(ctx context.Context, executablePath string, workDi
 		Environment:     config.Environment,
 	}
 
+	e.applySandboxPolicy(sandboxConfig)
+
 	// 创建执行沙箱
 	executeSandbox := sandbox.NewSystemCallSandbox(sandboxConfig)
 
@@ -218,6 +291,7 @@ func NewCExecutor(config config.CompilerConf) *CExecutor {
 		memoryMultiplier: config.MemoryMultiplier,
 		maxProcesses:     config.MaxProcesses,
 		allowedSyscalls:  config.AllowedSyscalls,
+		syscallArgRules:  syscallArgRules("c", config.SyscallRules),
 	}
 
 	return &CExecutor{BaseLanguageExecutor: base}
@@ -243,7 +317,7 @@ func (e *CExecutor) Compile(ctx context.Context, code string, workDir string) (*
 	}
 
 	// 确保源代码文件权限正确，让nobody用户能够读取
-	if err := os.Chown(sourceFile, 65534, 65534); err != nil {
+	if err := sandbox.ChownToSandboxUser(sourceFile, e.policy.Rootless); err != nil {
 		return nil, fmt.Errorf("failed to change source file ownership: %w", err)
 	}
 
@@ -266,6 +340,7 @@ func (e *CExecutor) Compile(ctx context.Context, code string, workDir string) (*
 		Environment:   []string{"PATH=/usr/bin:/bin"},
 	}
 
+	e.applySandboxPolicy(sandboxConfig)
 	compileSandbox := sandbox.NewSystemCallSandbox(sandboxConfig)
 	cmdParts := strings.Fields(compileCmd)
 
@@ -300,11 +375,16 @@ func (e *CExecutor) Execute(ctx context.Context, executablePath string, workDir
 		WorkDir:         workDir,
 		TimeLimit:       config.TimeLimit,
 		WallTimeLimit:   config.TimeLimit + 1000,
+		TimeLimitMetric: config.TimeLimitMetric,
+		TraceSyscalls:   config.TraceSyscalls,
+		Pool:            config.Pool,
+		CPUSetCores:     config.CPUSetCores,
 		MemoryLimit:     config.MemoryLimit,
 		StackLimit:      8 * 1024,
 		FileSizeLimit:   10 * 1024,
 		ProcessLimit:    e.maxProcesses,
 		AllowedSyscalls: e.allowedSyscalls,
+		SyscallArgRules: e.syscallArgRules,
 		EnableSeccomp:   true, // 启用seccomp安全过滤
 		InputFile:       config.InputFile,
 		OutputFile:      config.OutputFile,
@@ -312,16 +392,100 @@ func (e *CExecutor) Execute(ctx context.Context, executablePath string, workDir
 		Environment:     config.Environment,
 	}
 
+	e.applySandboxPolicy(sandboxConfig)
 	executeSandbox := sandbox.NewSystemCallSandbox(sandboxConfig)
 	return executeSandbox.Execute(ctx, executablePath, []string{})
 }
 
+// Java相关常量
+const (
+	defaultJavaCacheDir  = "/var/cache/judge/java" // 默认的AppCDS归档目录
+	javaCDSArchiveFile   = "jdk.jsa"               // JDK类的共享归档
+	javaClassListFile    = "jdk.classlist"         // 训练运行加载的JDK类列表
+	javaCacheMarkerFile  = ".judge-warm"           // 归档生成完成标记（内容为JDK版本）
+	javaWarmupClass      = "JudgeWarmup"           // 训练程序类名，生成归档时从类列表中剔除
+	javaStartupSamples   = 5                       // 启动耗时校准的采样次数
+	javaCalibrationHeapM = 256                     // 校准时使用的堆大小(MB)
+	javaCalibrationLimit = 10000                   // 校准运行的时间限制(毫秒)
+)
+
+// 训练程序：覆盖判题中常见的输入输出、集合、字符串格式化与lambda，使对应的JDK类进入归档
+const javaWarmupSource = `import java.io.*;
+import java.math.BigInteger;
+import java.util.*;
+import java.util.stream.*;
+
+public class JudgeWarmup {
+    public static void main(String[] args) throws IOException {
+        BufferedReader reader = new BufferedReader(new InputStreamReader(System.in));
+        PrintWriter out = new PrintWriter(new BufferedWriter(new OutputStreamWriter(System.out)));
+
+        long sum = 0;
+        StringTokenizer tokenizer = new StringTokenizer("1 2 3");
+        while (tokenizer.hasMoreTokens()) {
+            sum += Long.parseLong(tokenizer.nextToken());
+        }
+        Scanner scanner = new Scanner("4 5 6.5 word");
+        sum += scanner.nextInt() + scanner.nextLong();
+        double value = scanner.nextDouble();
+        String word = scanner.next();
+
+        List<Integer> list = new ArrayList<>(Arrays.asList(5, 3, 1, 4, 2));
+        Collections.sort(list);
+        list.sort(Comparator.reverseOrder());
+        int[] array = list.stream().mapToInt(Integer::intValue).toArray();
+        Arrays.sort(array);
+        Map<String, Integer> map = new HashMap<>();
+        map.merge(word, 1, Integer::sum);
+        TreeMap<Integer, Long> tree = new TreeMap<>();
+        tree.put(array[0], sum);
+        Deque<Integer> deque = new ArrayDeque<>(list);
+        PriorityQueue<Long> heap = new PriorityQueue<>(Collections.reverseOrder());
+        heap.add(sum);
+        Set<Integer> set = new HashSet<>(list);
+        String joined = IntStream.of(array).mapToObj(String::valueOf).collect(Collectors.joining(" "));
+        BigInteger big = BigInteger.valueOf(sum).pow(3).mod(BigInteger.valueOf(1000000007L));
+
+        StringBuilder builder = new StringBuilder();
+        builder.append(joined).append(' ').append(big).append(' ').append(Math.max(deque.size(), set.size()));
+        out.printf("%d %.2f %s %s %d%n", sum, value, builder, map, tree.firstKey() + heap.peek());
+        out.println(reader.readLine());
+        out.flush();
+    }
+}
+`
+
+// 空程序，与提交代码使用同一条执行命令运行，用于测量JVM自身的启动耗时
+const javaEmptySource = `public class Main {
+    public static void main(String[] args) {
+    }
+}
+`
+
 // Java语言执行器
+// 原理：每个测试用例都会启动新的JVM，启动阶段加载和校验JDK类占用了大量CPU时间
+// 启动时为本机JDK生成AppCDS归档，运行时直接映射已解析的类；剩余的启动耗时通过空程序校准后从TimeUsed中扣除
+// 归档与校准在服务启动时于后台完成，完成之前的提交不使用AppCDS，也不扣除启动耗时
 type JavaExecutor struct {
 	*BaseLanguageExecutor
+	cacheDir string
+	runtime  atomic.Pointer[javaRuntime] // 预热完成后设置
+	warmOnce sync.Once
+}
+
+// 预热得到的Java运行时参数
+type javaRuntime struct {
+	cdsArchive      string // 可用的AppCDS归档路径，生成失败时为空
+	startupCPUTime  int64  // 校准得到的JVM启动CPU时间(毫秒)，与沙箱统计的口径相同（整棵进程树的user+sys）
+	startupWallTime int64  // 校准得到的JVM启动墙钟时间(毫秒)
 }
 
 func NewJavaExecutor(config config.CompilerConf) *JavaExecutor {
+	cacheDir := config.CacheDir
+	if cacheDir == "" {
+		cacheDir = defaultJavaCacheDir
+	}
+
 	base := &BaseLanguageExecutor{
 		name:             "java",
 		displayName:      "Java",
@@ -334,9 +498,13 @@ func NewJavaExecutor(config config.CompilerConf) *JavaExecutor {
 		memoryMultiplier: config.MemoryMultiplier,
 		maxProcesses:     config.MaxProcesses,
 		allowedSyscalls:  config.AllowedSyscalls,
+		syscallArgRules:  syscallArgRules("java", config.SyscallRules),
 	}
 
-	return &JavaExecutor{BaseLanguageExecutor: base}
+	return &JavaExecutor{
+		BaseLanguageExecutor: base,
+		cacheDir:             cacheDir,
+	}
 }
 
 func (e *JavaExecutor) GetName() string              { return e.name }
@@ -349,6 +517,205 @@ func (e *JavaExecutor) GetMemoryMultiplier() float64 { return e.memoryMultiplier
 func (e *JavaExecutor) GetMaxProcesses() int         { return e.maxProcesses }
 func (e *JavaExecutor) GetAllowedSyscalls() []int    { return e.allowedSyscalls }
 
+// 预热Java运行时：生成AppCDS归档并校准JVM启动耗时
+// 归档只需在JDK版本变化时重新生成，启动耗时与机器负载相关，每次服务启动都重新校准
+func (e *JavaExecutor) WarmupRuntime() {
+	e.warmOnce.Do(func() {
+		runtime := &javaRuntime{cdsArchive: e.generateCDSArchive()}
+		runtime.startupCPUTime, runtime.startupWallTime = e.calibrateStartupTime(runtime.cdsArchive)
+		e.runtime.Store(runtime)
+	})
+}
+
+// 执行命令中的java与javac可执行文件
+func (e *JavaExecutor) javaBinaries() (string, string) {
+	javaBinary, javacBinary := "java", "javac"
+	if parts := strings.Fields(e.executeCommand); len(parts) > 0 {
+		javaBinary = parts[0]
+	}
+	if parts := strings.Fields(e.compileCommand); len(parts) > 0 {
+		javacBinary = parts[0]
+	}
+	return javaBinary, javacBinary
+}
+
+// 生成JDK类的AppCDS归档，返回归档路径，失败时为空
+// 步骤：运行训练程序导出加载的类列表 -> 剔除训练程序自身的类 -> -Xshare:dump生成归档
+// 归档中不包含应用类，因此运行时的classpath与生成时不同也能使用
+func (e *JavaExecutor) generateCDSArchive() string {
+	javaBinary, javacBinary := e.javaBinaries()
+
+	// 探测JDK版本（java -version输出到标准错误）
+	versionOutput, err := exec.Command(javaBinary, "-version").CombinedOutput()
+	if err != nil {
+		logx.Errorf("Failed to detect JDK version, AppCDS disabled: %v", err)
+		return ""
+	}
+	jdkVersion := strings.TrimSpace(strings.SplitN(string(versionOutput), "\n", 2)[0])
+
+	archivePath := filepath.Join(e.cacheDir, javaCDSArchiveFile)
+	markerPath := filepath.Join(e.cacheDir, javaCacheMarkerFile)
+	if marker, err := os.ReadFile(markerPath); err == nil && strings.TrimSpace(string(marker)) == jdkVersion {
+		if _, err := os.Stat(archivePath); err == nil {
+			logx.Infof("Java AppCDS archive already generated: archive=%s, jdk=%s", archivePath, jdkVersion)
+			return archivePath
+		}
+	}
+
+	// 升级JDK后需要重新生成，先恢复可写权限
+	if err := setDirWritable(e.cacheDir, true); err != nil && !os.IsNotExist(err) {
+		logx.Errorf("Failed to make Java cache writable: %v", err)
+	}
+	warmupDir := filepath.Join(e.cacheDir, "warmup")
+	if err := os.MkdirAll(warmupDir, 0755); err != nil {
+		logx.Errorf("Failed to create Java warmup dir %s: %v", warmupDir, err)
+		return ""
+	}
+
+	startTime := time.Now()
+	sourceFile := filepath.Join(warmupDir, javaWarmupClass+".java")
+	if err := os.WriteFile(sourceFile, []byte(javaWarmupSource), 0644); err != nil {
+		logx.Errorf("Failed to write Java warmup source: %v", err)
+		return ""
+	}
+	if output, err := exec.Command(javacBinary, "-d", warmupDir, sourceFile).CombinedOutput(); err != nil {
+		logx.Errorf("Failed to compile Java warmup program: %v, output: %s", err, string(output))
+		return ""
+	}
+
+	// 训练运行，导出加载过的类
+	rawClassList := filepath.Join(warmupDir, "loaded.classlist")
+	trainCmd := exec.Command(javaBinary, "-Xshare:off", "-XX:DumpLoadedClassList="+rawClassList, "-cp", warmupDir, javaWarmupClass)
+	trainCmd.Stdin = strings.NewReader("warmup\n")
+	if output, err := trainCmd.CombinedOutput(); err != nil {
+		logx.Errorf("Failed to run Java warmup program: %v, output: %s", err, string(output))
+		return ""
+	}
+
+	rawList, err := os.ReadFile(rawClassList)
+	if err != nil {
+		logx.Errorf("Failed to read Java class list: %v", err)
+		return ""
+	}
+	var classList strings.Builder
+	for _, line := range strings.Split(string(rawList), "\n") {
+		if line == "" || strings.Contains(line, javaWarmupClass) {
+			continue
+		}
+		classList.WriteString(line)
+		classList.WriteByte('\n')
+	}
+	classListPath := filepath.Join(e.cacheDir, javaClassListFile)
+	if err := os.WriteFile(classListPath, []byte(classList.String()), 0644); err != nil {
+		logx.Errorf("Failed to write Java class list: %v", err)
+		return ""
+	}
+
+	// 在空目录中生成归档，避免当前目录作为classpath被记录
+	dumpCmd := exec.Command(javaBinary, "-Xshare:dump",
+		"-XX:SharedClassListFile="+classListPath,
+		"-XX:SharedArchiveFile="+archivePath)
+	dumpCmd.Dir = e.cacheDir
+	if output, err := dumpCmd.CombinedOutput(); err != nil {
+		logx.Errorf("Failed to dump Java AppCDS archive: %v, output: %s", err, string(output))
+		return ""
+	}
+
+	if err := os.RemoveAll(warmupDir); err != nil {
+		logx.Errorf("Failed to remove Java warmup dir: %v", err)
+	}
+	if err := os.WriteFile(markerPath, []byte(jdkVersion+"\n"), 0644); err != nil {
+		logx.Errorf("Failed to write Java cache marker: %v", err)
+	}
+	if err := setDirWritable(e.cacheDir, false); err != nil {
+		logx.Errorf("Failed to make Java cache read-only: %v", err)
+		return ""
+	}
+
+	logx.Infof("Java AppCDS archive generated: archive=%s, jdk=%s, took=%v", archivePath, jdkVersion, time.Since(startTime))
+	return archivePath
+}
+
+// AppCDS相关的JVM参数，归档不可用时JVM回退到默认行为
+func cdsOptions(cdsArchive string) []string {
+	if cdsArchive == "" {
+		return nil
+	}
+	return []string{"-Xshare:auto", "-XX:SharedArchiveFile=" + cdsArchive}
+}
+
+// 构建执行命令：JVM参数插在java之后，执行命令中的调优参数（SerialGC、TieredStopAtLevel等）保持不变
+func (e *JavaExecutor) buildCommand(cdsArchive string, memoryLimitMB int64) (string, []string, error) {
+	executeCmd := strings.ReplaceAll(e.executeCommand, "{memory_limit}", fmt.Sprintf("%d", memoryLimitMB))
+	cmdParts := strings.Fields(executeCmd)
+	if len(cmdParts) == 0 {
+		return "", nil, fmt.Errorf("empty execute command")
+	}
+	return cmdParts[0], append(cdsOptions(cdsArchive), cmdParts[1:]...), nil
+}
+
+// 校准JVM启动耗时，返回CPU时间与墙钟时间(毫秒)，失败时均为0（不扣除）
+// 用与提交代码完全相同的执行路径（沙箱、cgroup、seccomp、根文件系统）与执行命令运行空的Main，
+// 按沙箱统计的CPU时间与墙钟时间分别取多次采样中的最小值，保证扣除的时间不会超过任何一次真实运行的启动开销
+func (e *JavaExecutor) calibrateStartupTime(cdsArchive string) (int64, int64) {
+	_, javacBinary := e.javaBinaries()
+
+	calibrationDir, err := os.MkdirTemp("", "judge-java-calibration-")
+	if err != nil {
+		logx.Errorf("Failed to create Java calibration dir: %v", err)
+		return 0, 0
+	}
+	defer os.RemoveAll(calibrationDir)
+
+	sourceFile := filepath.Join(calibrationDir, "Main.java")
+	if err := os.WriteFile(sourceFile, []byte(javaEmptySource), 0644); err != nil {
+		logx.Errorf("Failed to write Java calibration source: %v", err)
+		return 0, 0
+	}
+	if output, err := exec.Command(javacBinary, "-d", calibrationDir, sourceFile).CombinedOutput(); err != nil {
+		logx.Errorf("Failed to compile Java calibration program: %v, output: %s", err, string(output))
+		return 0, 0
+	}
+	// 沙箱用户需要能够进入目录并读取Main.class
+	if err := os.Chmod(calibrationDir, 0755); err != nil {
+		logx.Errorf("Failed to prepare Java calibration dir: %v", err)
+		return 0, 0
+	}
+	if err := sandbox.ChownToSandboxUser(calibrationDir, e.policy.Rootless); err != nil {
+		logx.Errorf("Failed to prepare Java calibration dir: %v", err)
+		return 0, 0
+	}
+
+	runtime := &javaRuntime{cdsArchive: cdsArchive}
+	var cpuTime, wallTime int64 = -1, -1
+	for i := 0; i < javaStartupSamples; i++ {
+		result, err := e.execute(context.Background(), runtime, calibrationDir, &ExecutionConfig{
+			TimeLimit:   javaCalibrationLimit,
+			MemoryLimit: javaCalibrationHeapM * 1024,
+			OutputFile:  filepath.Join(calibrationDir, "output.txt"),
+			ErrorFile:   filepath.Join(calibrationDir, "error.txt"),
+			Environment: []string{"PATH=/usr/bin:/bin"},
+		})
+		if err != nil {
+			logx.Errorf("Java calibration run failed: %v", err)
+			return 0, 0
+		}
+		if result.Status != sandbox.StatusAccepted {
+			logx.Errorf("Java calibration run failed: status=%d, error: %s", result.Status, result.ErrorOutput)
+			return 0, 0
+		}
+		if cpuTime < 0 || result.CPUTime < cpuTime {
+			cpuTime = result.CPUTime
+		}
+		if wallTime < 0 || result.WallTime < wallTime {
+			wallTime = result.WallTime
+		}
+	}
+
+	logx.Infof("Java startup time calibrated: cpu=%dms, wall=%dms (cds=%v, samples=%d)", cpuTime, wallTime, cdsArchive != "", javaStartupSamples)
+	return cpuTime, wallTime
+}
+
 func (e *JavaExecutor) Compile(ctx context.Context, code string, workDir string) (*CompileResult, error) {
 	sourceFile := filepath.Join(workDir, "Main.java")
 
@@ -358,7 +725,7 @@ func (e *JavaExecutor) Compile(ctx context.Context, code string, workDir string)
 	}
 
 	// 确保源代码文件权限正确，让nobody用户能够读取
-	if err := os.Chown(sourceFile, 65534, 65534); err != nil {
+	if err := sandbox.ChownToSandboxUser(sourceFile, e.policy.Rootless); err != nil {
 		return nil, fmt.Errorf("failed to change source file ownership: %w", err)
 	}
 
@@ -366,19 +733,21 @@ func (e *JavaExecutor) Compile(ctx context.Context, code string, workDir string)
 	compileCmd := strings.ReplaceAll(e.compileCommand, "{source}", sourceFile)
 
 	sandboxConfig := &sandbox.SandboxConfig{
-		UID:           65534,
-		GID:           65534,
-		WorkDir:       workDir,
-		TimeLimit:     int64(e.compileTimeout.Milliseconds()),
-		WallTimeLimit: int64(e.compileTimeout.Milliseconds()) + 2000, // Java需要更多时间
-		MemoryLimit:   1024 * 1024,                                   // 1GB编译内存限制
-		StackLimit:    8 * 1024,
-		FileSizeLimit: 50 * 1024,
-		ProcessLimit:  e.maxProcesses,
-		ErrorFile:     filepath.Join(workDir, "compile_error.txt"),
-		Environment:   []string{"PATH=/usr/bin:/bin", "JAVA_HOME=/usr/lib/jvm/default-java"},
+		UID:                   65534,
+		GID:                   65534,
+		WorkDir:               workDir,
+		TimeLimit:             int64(e.compileTimeout.Milliseconds()),
+		WallTimeLimit:         int64(e.compileTimeout.Milliseconds()) + 2000, // Java需要更多时间
+		MemoryLimit:           1024 * 1024,                                   // 1GB编译内存限制
+		StackLimit:            8 * 1024,
+		FileSizeLimit:         50 * 1024,
+		ProcessLimit:          e.maxProcesses,
+		SkipAddressSpaceLimit: true, // JVM启动即预留压缩类空间与代码缓存的虚拟地址
+		ErrorFile:             filepath.Join(workDir, "compile_error.txt"),
+		Environment:           []string{"PATH=/usr/bin:/bin", "JAVA_HOME=/usr/lib/jvm/default-java"},
 	}
 
+	e.applySandboxPolicy(sandboxConfig, e.cacheDir)
 	compileSandbox := sandbox.NewSystemCallSandbox(sandboxConfig)
 	cmdParts := strings.Fields(compileCmd)
 
@@ -407,31 +776,75 @@ func (e *JavaExecutor) Compile(ctx context.Context, code string, workDir string)
 }
 
 func (e *JavaExecutor) Execute(ctx context.Context, executablePath string, workDir string, config *ExecutionConfig) (*sandbox.ExecuteResult, error) {
+	// 预热仍在后台进行时不等待：不使用AppCDS，也不扣除启动耗时
+	runtime := e.runtime.Load()
+	if runtime == nil {
+		runtime = &javaRuntime{}
+	}
+	return e.execute(ctx, runtime, workDir, config)
+}
+
+// 使用给定的运行时参数执行Main，启动耗时按时间限制的度量扣除
+func (e *JavaExecutor) execute(ctx context.Context, runtime *javaRuntime, workDir string, config *ExecutionConfig) (*sandbox.ExecuteResult, error) {
 	// Java执行命令，需要替换内存限制
-	executeCmd := strings.ReplaceAll(e.executeCommand, "{memory_limit}", fmt.Sprintf("%d", config.MemoryLimit/1024))
+	javaBinary, args, err := e.buildCommand(runtime.cdsArchive, config.MemoryLimit/1024)
+	if err != nil {
+		return nil, err
+	}
+
+	// JVM启动耗时不计入用户时间，限制相应放宽，结束后再从各项时间中扣除
+	startupTime := runtime.startupCPUTime
+	if config.TimeLimitMetric == sandbox.TimeLimitMetricWall {
+		startupTime = runtime.startupWallTime
+	}
 
 	sandboxConfig := &sandbox.SandboxConfig{
-		UID:             65534,
-		GID:             65534,
-		WorkDir:         workDir,
-		TimeLimit:       config.TimeLimit,
-		WallTimeLimit:   config.TimeLimit + 2000, // Java需要更多启动时间
-		MemoryLimit:     config.MemoryLimit,
-		StackLimit:      8 * 1024,
-		FileSizeLimit:   10 * 1024,
-		ProcessLimit:    e.maxProcesses,
-		AllowedSyscalls: e.allowedSyscalls,
-		EnableSeccomp:   false, // 临时禁用seccomp - Java需要更多系统调用
-		InputFile:       config.InputFile,
-		OutputFile:      config.OutputFile,
-		ErrorFile:       config.ErrorFile,
-		Environment:     append(config.Environment, "JAVA_HOME=/usr/lib/jvm/default-java"),
+		UID:                   65534,
+		GID:                   65534,
+		WorkDir:               workDir,
+		TimeLimit:             config.TimeLimit + startupTime,
+		WallTimeLimit:         config.TimeLimit + runtime.startupWallTime + 2000, // Java需要更多启动时间
+		TimeLimitMetric:       config.TimeLimitMetric,
+		TraceSyscalls:         config.TraceSyscalls,
+		Pool:                  config.Pool,
+		CPUSetCores:           config.CPUSetCores,
+		MemoryLimit:           config.MemoryLimit,
+		StackLimit:            8 * 1024,
+		FileSizeLimit:         10 * 1024,
+		ProcessLimit:          e.maxProcesses,
+		SkipAddressSpaceLimit: true, // 堆大小由-Xmx控制
+		AllowedSyscalls:       e.allowedSyscalls,
+		SyscallArgRules:       e.syscallArgRules,
+		EnableSeccomp:         false, // 临时禁用seccomp - Java需要更多系统调用
+		InputFile:             config.InputFile,
+		OutputFile:            config.OutputFile,
+		ErrorFile:             config.ErrorFile,
+		Environment:           append(config.Environment, "JAVA_HOME=/usr/lib/jvm/default-java"),
 	}
 
+	e.applySandboxPolicy(sandboxConfig, e.cacheDir)
 	executeSandbox := sandbox.NewSystemCallSandbox(sandboxConfig)
-	cmdParts := strings.Fields(executeCmd)
+	result, err := executeSandbox.Execute(ctx, javaBinary, args)
+	if err != nil {
+		return nil, err
+	}
 
-	return executeSandbox.Execute(ctx, cmdParts[0], cmdParts[1:])
+	result.StartupTime = startupTime
+	for _, deduction := range []struct {
+		used    *int64
+		startup int64
+	}{
+		{&result.TimeUsed, startupTime},
+		{&result.CPUTime, runtime.startupCPUTime},
+		{&result.WallTime, runtime.startupWallTime},
+	} {
+		*deduction.used -= deduction.startup
+		if *deduction.used < 0 {
+			*deduction.used = 0
+		}
+	}
+
+	return result, nil
 }
 
 // Python语言执行器
@@ -440,6 +853,11 @@ type PythonExecutor struct {
 }
 
 func NewPythonExecutor(config config.CompilerConf) *PythonExecutor {
+	allowedSyscalls := config.AllowedSyscalls
+	if len(allowedSyscalls) == 0 {
+		allowedSyscalls = sandbox.GetSyscallWhitelist("python")
+	}
+
 	base := &BaseLanguageExecutor{
 		name:             "python",
 		displayName:      "Python",
@@ -451,7 +869,8 @@ func NewPythonExecutor(config config.CompilerConf) *PythonExecutor {
 		timeMultiplier:   config.TimeMultiplier,
 		memoryMultiplier: config.MemoryMultiplier,
 		maxProcesses:     config.MaxProcesses,
-		allowedSyscalls:  config.AllowedSyscalls,
+		allowedSyscalls:  allowedSyscalls,
+		syscallArgRules:  syscallArgRules("python", config.SyscallRules),
 	}
 
 	return &PythonExecutor{BaseLanguageExecutor: base}
@@ -476,7 +895,7 @@ func (e *PythonExecutor) Compile(ctx context.Context, code string, workDir strin
 	}
 
 	// 确保源代码文件权限正确，让nobody用户能够读取
-	if err := os.Chown(sourceFile, 65534, 65534); err != nil {
+	if err := sandbox.ChownToSandboxUser(sourceFile, e.policy.Rootless); err != nil {
 		return nil, fmt.Errorf("failed to change source file ownership: %w", err)
 	}
 
@@ -498,30 +917,629 @@ func (e *PythonExecutor) Execute(ctx context.Context, executablePath string, wor
 		WorkDir:         workDir,
 		TimeLimit:       config.TimeLimit,
 		WallTimeLimit:   config.TimeLimit + 1000,
+		TimeLimitMetric: config.TimeLimitMetric,
+		TraceSyscalls:   config.TraceSyscalls,
+		Pool:            config.Pool,
+		CPUSetCores:     config.CPUSetCores,
 		MemoryLimit:     config.MemoryLimit,
 		StackLimit:      8 * 1024,
 		FileSizeLimit:   10 * 1024,
 		ProcessLimit:    e.maxProcesses,
 		AllowedSyscalls: e.allowedSyscalls,
-		EnableSeccomp:   false, // 临时禁用seccomp - Python需要更多系统调用
+		DeniedSyscalls:  sandbox.GetSyscallDenylist("python"),
+		SyscallArgRules: e.syscallArgRules,
+		EnableSeccomp:   true, // 白名单由解释器真实运行记录整理，见sandbox.GetSyscallWhitelist
 		InputFile:       config.InputFile,
 		OutputFile:      config.OutputFile,
 		ErrorFile:       config.ErrorFile,
 		Environment:     append(config.Environment, "PYTHONPATH=/usr/lib/python3.8"),
 	}
 
+	e.applySandboxPolicy(sandboxConfig)
 	executeSandbox := sandbox.NewSystemCallSandbox(sandboxConfig)
 	cmdParts := strings.Fields(executeCmd)
 
 	return executeSandbox.Execute(ctx, cmdParts[0], cmdParts[1:])
 }
 
+// Go语言相关常量
+const (
+	defaultGoCacheDir     = "/var/cache/judge/go-build" // 默认的预热构建缓存目录
+	defaultGoVersion      = "1.21"                      // 无法探测工具链版本时go.mod使用的语言版本
+	goCacheMarkerFile     = ".judge-warm"               // 缓存预热完成标记（内容为工具链版本）
+	goCompileProcessLimit = 64                          // go build会派生compile/link等子进程及其线程
+	goRuntimeMinThreads   = 16                          // Go运行时线程数下限（GOMAXPROCS=1时约5-8个线程）
+)
+
+// Go语言执行器
+// 原理：单文件main包在离线模块模式下编译（GOPROXY=off、-mod=readonly），
+// 标准库编译产物来自启动时预热并设为只读的GOCACHE，编译期间不会访问网络也不会写入共享缓存
+type GoExecutor struct {
+	*BaseLanguageExecutor
+	cacheDir  string
+	goVersion string // go.mod中的go指令版本
+	warmOnce  sync.Once
+}
+
+func NewGoExecutor(config config.CompilerConf) *GoExecutor {
+	allowedSyscalls := config.AllowedSyscalls
+	if len(allowedSyscalls) == 0 {
+		allowedSyscalls = sandbox.GetSyscallWhitelist("go")
+	}
+
+	cacheDir := config.CacheDir
+	if cacheDir == "" {
+		cacheDir = defaultGoCacheDir
+	}
+
+	base := &BaseLanguageExecutor{
+		name:             "go",
+		displayName:      "Go",
+		version:          config.Version,
+		fileExtension:    ".go",
+		compileCommand:   config.CompileCommand,
+		executeCommand:   config.ExecuteCommand,
+		compileTimeout:   time.Duration(config.CompileTimeout) * time.Millisecond,
+		timeMultiplier:   config.TimeMultiplier,
+		memoryMultiplier: config.MemoryMultiplier,
+		maxProcesses:     config.MaxProcesses,
+		allowedSyscalls:  allowedSyscalls,
+		syscallArgRules:  syscallArgRules("go", config.SyscallRules),
+	}
+
+	return &GoExecutor{
+		BaseLanguageExecutor: base,
+		cacheDir:             cacheDir,
+		goVersion:            defaultGoVersion,
+	}
+}
+
+func (e *GoExecutor) GetName() string              { return e.name }
+func (e *GoExecutor) GetDisplayName() string       { return e.displayName }
+func (e *GoExecutor) GetVersion() string           { return e.version }
+func (e *GoExecutor) GetFileExtension() string     { return e.fileExtension }
+func (e *GoExecutor) IsCompiled() bool             { return true }
+func (e *GoExecutor) GetTimeMultiplier() float64   { return e.timeMultiplier }
+func (e *GoExecutor) GetMemoryMultiplier() float64 { return e.memoryMultiplier }
+func (e *GoExecutor) GetMaxProcesses() int         { return e.maxProcesses }
+func (e *GoExecutor) GetAllowedSyscalls() []int    { return e.allowedSyscalls }
+
+// 构建环境变量：离线、只读模块、固定工具链
+// 显式覆盖GOFLAGS，避免继承宿主机上的-mod=mod导致编译期间修改go.mod或下载依赖
+func (e *GoExecutor) buildEnvironment() []string {
+	return []string{
+		"PATH=/usr/local/go/bin:/usr/bin:/bin",
+		"HOME=/tmp",
+		"GOCACHE=" + e.cacheDir,
+		"GOFLAGS=-mod=readonly",
+		"GOPROXY=off",
+		"GOSUMDB=off",
+		"GOWORK=off",
+		"GOTOOLCHAIN=local",
+		"GO111MODULE=on",
+		"CGO_ENABLED=0",
+	}
+}
+
+// 预热构建缓存
+// 以判题服务身份编译整个标准库写入GOCACHE，完成后将缓存设为只读，沙箱内的nobody用户只能命中不能写入
+// 编译参数必须与Compile一致（-trimpath），否则缓存键不同无法命中
+func (e *GoExecutor) WarmupBuildCache() {
+	e.warmOnce.Do(e.warmupBuildCache)
+}
+
+func (e *GoExecutor) warmupBuildCache() {
+	goBinary := "go"
+	if parts := strings.Fields(e.compileCommand); len(parts) > 0 {
+		goBinary = parts[0]
+	}
+
+	env := e.buildEnvironment()
+
+	// 探测工具链版本，用于生成go.mod的go指令
+	versionCmd := exec.Command(goBinary, "env", "GOVERSION")
+	versionCmd.Env = env
+	if output, err := versionCmd.Output(); err == nil {
+		if version := strings.TrimPrefix(strings.TrimSpace(string(output)), "go"); version != "" {
+			e.goVersion = version
+		}
+	} else {
+		logx.Errorf("Failed to detect Go toolchain version, using go %s in go.mod: %v", defaultGoVersion, err)
+	}
+
+	// 标记文件与工具链版本一致时说明缓存已预热
+	markerPath := filepath.Join(e.cacheDir, goCacheMarkerFile)
+	if marker, err := os.ReadFile(markerPath); err == nil && strings.TrimSpace(string(marker)) == e.goVersion {
+		logx.Infof("Go build cache already warm: dir=%s, version=%s", e.cacheDir, e.goVersion)
+		return
+	}
+
+	// 升级工具链后需要重新写入，先恢复可写权限
+	if err := e.setCacheWritable(true); err != nil && !os.IsNotExist(err) {
+		logx.Errorf("Failed to make Go build cache writable: %v", err)
+	}
+	if err := os.MkdirAll(e.cacheDir, 0755); err != nil {
+		logx.Errorf("Failed to create Go build cache dir %s: %v", e.cacheDir, err)
+		return
+	}
+
+	startTime := time.Now()
+	warmCmd := exec.Command(goBinary, "build", "-trimpath", "std")
+	warmCmd.Env = env
+	warmCmd.Dir = e.cacheDir
+	if output, err := warmCmd.CombinedOutput(); err != nil {
+		logx.Errorf("Failed to warm up Go build cache: %v, output: %s", err, string(output))
+		return
+	}
+
+	if err := os.WriteFile(markerPath, []byte(e.goVersion+"\n"), 0644); err != nil {
+		logx.Errorf("Failed to write Go build cache marker: %v", err)
+	}
+
+	if err := e.setCacheWritable(false); err != nil {
+		logx.Errorf("Failed to make Go build cache read-only: %v", err)
+		return
+	}
+
+	logx.Infof("Go build cache warmed up: dir=%s, version=%s, took=%v", e.cacheDir, e.goVersion, time.Since(startTime))
+}
+
+// 切换缓存目录的读写权限（目录0755/0555，文件0644/0444）
+func (e *GoExecutor) setCacheWritable(writable bool) error {
+	return setDirWritable(e.cacheDir, writable)
+}
+
+func (e *GoExecutor) Compile(ctx context.Context, code string, workDir string) (*CompileResult, error) {
+	// 首次编译前确保缓存已预热（通常在服务启动时已于后台完成）
+	e.WarmupBuildCache()
+
+	sourceFile := filepath.Join(workDir, "main.go")
+	modFile := filepath.Join(workDir, "go.mod")
+	executableFile := filepath.Join(workDir, "main")
+
+	// 写入源代码文件
+	if err := os.WriteFile(sourceFile, []byte(code), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write source file: %w", err)
+	}
+
+	// 写入go.mod，单文件main包以独立模块编译，不允许任何外部依赖
+	modContent := fmt.Sprintf("module main\n\ngo %s\n", e.goVersion)
+	if err := os.WriteFile(modFile, []byte(modContent), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write go.mod: %w", err)
+	}
+
+	// 确保源代码文件权限正确，让nobody用户能够读取
+	for _, file := range []string{sourceFile, modFile} {
+		if err := sandbox.ChownToSandboxUser(file, e.policy.Rootless); err != nil {
+			return nil, fmt.Errorf("failed to change source file ownership: %w", err)
+		}
+	}
+
+	// 替换编译命令中的占位符
+	compileCmd := strings.ReplaceAll(e.compileCommand, "{executable}", executableFile)
+	compileCmd = strings.ReplaceAll(compileCmd, "{source}", sourceFile)
+
+	sandboxConfig := &sandbox.SandboxConfig{
+		UID:           65534,
+		GID:           65534,
+		WorkDir:       workDir,
+		TimeLimit:     int64(e.compileTimeout.Milliseconds()),
+		WallTimeLimit: int64(e.compileTimeout.Milliseconds()) + 1000,
+		MemoryLimit:   1024 * 1024, // 1GB编译内存限制（go build并行编译）
+		StackLimit:    8 * 1024,
+		FileSizeLimit: 50 * 1024,
+		ProcessLimit:  goCompileProcessLimit,
+		ErrorFile:     filepath.Join(workDir, "compile_error.txt"),
+		Environment:   e.buildEnvironment(),
+	}
+
+	e.applySandboxPolicy(sandboxConfig, e.cacheDir)
+	compileSandbox := sandbox.NewSystemCallSandbox(sandboxConfig)
+	cmdParts := strings.Fields(compileCmd)
+	if len(cmdParts) == 0 {
+		return nil, fmt.Errorf("empty compile command")
+	}
+
+	startTime := time.Now()
+	result, err := compileSandbox.Execute(ctx, cmdParts[0], cmdParts[1:])
+	compileTime := time.Since(startTime)
+
+	var compileMessage string
+	if errorData, err := os.ReadFile(sandboxConfig.ErrorFile); err == nil {
+		compileMessage = string(errorData)
+	}
+
+	compileResult := &CompileResult{
+		Success:        result != nil && result.Status == sandbox.StatusAccepted,
+		ExecutablePath: executableFile,
+		CompileTime:    compileTime,
+		Message:        compileMessage,
+	}
+
+	if err != nil {
+		compileResult.Success = false
+		compileResult.Message = fmt.Sprintf("Compile error: %v", err)
+	}
+
+	return compileResult, nil
+}
+
+func (e *GoExecutor) Execute(ctx context.Context, executablePath string, workDir string, config *ExecutionConfig) (*sandbox.ExecuteResult, error) {
+	// Go运行时是多线程的，pids cgroup按线程计数
+	// 固定GOMAXPROCS=1使线程数稳定，并保证进程数限制不低于运行时所需的线程数
+	processLimit := e.maxProcesses
+	if processLimit < goRuntimeMinThreads {
+		processLimit = goRuntimeMinThreads
+	}
+
+	sandboxConfig := &sandbox.SandboxConfig{
+		UID:             65534,
+		GID:             65534,
+		WorkDir:         workDir,
+		TimeLimit:       config.TimeLimit,
+		WallTimeLimit:   config.TimeLimit + 1000,
+		TimeLimitMetric: config.TimeLimitMetric,
+		TraceSyscalls:   config.TraceSyscalls,
+		Pool:            config.Pool,
+		CPUSetCores:     config.CPUSetCores,
+		MemoryLimit:     config.MemoryLimit,
+		StackLimit:      8 * 1024,
+		FileSizeLimit:   10 * 1024,
+		ProcessLimit:    processLimit,
+		AllowedSyscalls: e.allowedSyscalls,
+		SyscallArgRules: e.syscallArgRules,
+		EnableSeccomp:   true, // 启用seccomp安全过滤
+		// Go运行时启动即预留约600MB虚拟地址（页摘要与堆arena），无法用RLIMIT_AS限制
+		// 内存由常驻内存监控与cgroup限制，GOMEMLIMIT让GC在接近限制时更积极地回收
+		SkipAddressSpaceLimit: true,
+		InputFile:             config.InputFile,
+		OutputFile:            config.OutputFile,
+		ErrorFile:             config.ErrorFile,
+		Environment:           append(config.Environment, "GOMAXPROCS=1", fmt.Sprintf("GOMEMLIMIT=%dKiB", config.MemoryLimit)),
+	}
+
+	e.applySandboxPolicy(sandboxConfig)
+	executeSandbox := sandbox.NewSystemCallSandbox(sandboxConfig)
+	return executeSandbox.Execute(ctx, executablePath, []string{})
+}
+
+// Node.js相关常量
+const (
+	nodeGuardFile         = "judge_guard.js" // 执行前预加载的模块守卫脚本
+	nodeHeapReserveMB     = 64               // 为新生代、代码区和运行时本身预留的内存(MB)
+	nodeMinHeapMB         = 32               // 老生代堆上限的最小值(MB)
+	nodeRuntimeMinThreads = 16               // Node运行时线程数下限（主线程、V8后台线程、libuv线程池等）
+)
+
+// 模块加载守卫：禁止子进程、工作线程与网络相关的内置模块
+// 这只是语言层面的防线，真正的隔离由seccomp白名单（无socket、clone只能创建线程）、init拦截再次execve与网络命名空间保证
+const nodeGuardScript = `'use strict';
+const Module = require('module');
+const blocked = new Set([
+  'child_process', 'cluster', 'worker_threads', 'inspector',
+  'net', 'tls', 'dgram', 'dns', 'http', 'https', 'http2',
+]);
+const load = Module._load;
+Module._load = function (request, parent, isMain) {
+  const name = typeof request === 'string' && request.startsWith('node:') ? request.slice(5) : request;
+  if (blocked.has(name)) {
+    throw new Error('module "' + request + '" is not allowed in judge sandbox');
+  }
+  return load.apply(this, arguments);
+};
+for (const key of ['binding', '_linkedBinding', 'dlopen']) {
+  Object.defineProperty(process, key, {
+    value: () => { throw new Error('process.' + key + ' is not allowed in judge sandbox'); },
+  });
+}
+`
+
+// 写入模块守卫脚本，由root持有且只读
+// 只在编译阶段（用户代码运行之前）写入，避免以root身份写入已被用户代码替换的路径
+func writeNodeGuard(workDir string) error {
+	guardFile := filepath.Join(workDir, nodeGuardFile)
+	if err := os.WriteFile(guardFile, []byte(nodeGuardScript), 0444); err != nil {
+		return fmt.Errorf("failed to write node guard script: %w", err)
+	}
+	return nil
+}
+
+// 检查守卫脚本未被替换：工作目录属于沙箱用户，前一个测试用例可能删除并重建该文件
+// rootless模式下沙箱用户就是文件所有者，所有者检查不再有效，因此同时比较内容；执行时守卫脚本还会以只读方式挂载到根文件系统中
+func verifyNodeGuard(workDir string) (string, error) {
+	guardFile := filepath.Join(workDir, nodeGuardFile)
+	info, err := os.Lstat(guardFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat node guard script: %w", err)
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !info.Mode().IsRegular() || !ok || stat.Uid != uint32(os.Getuid()) {
+		return "", fmt.Errorf("node guard script has been tampered with: %s", guardFile)
+	}
+	content, err := os.ReadFile(guardFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read node guard script: %w", err)
+	}
+	if string(content) != nodeGuardScript {
+		return "", fmt.Errorf("node guard script has been tampered with: %s", guardFile)
+	}
+	return guardFile, nil
+}
+
+// 根据内存限制推导V8老生代堆上限(MB)，使堆溢出先于内存超限在运行时内部暴露
+func nodeHeapSizeMB(memoryLimitKB int64) int64 {
+	heapMB := memoryLimitKB/1024 - nodeHeapReserveMB
+	if heapMB < nodeMinHeapMB {
+		heapMB = nodeMinHeapMB
+	}
+	return heapMB
+}
+
+// 在沙箱中运行Node脚本
+// 执行命令中的{source}替换为脚本路径，堆上限与守卫脚本参数插在解释器之后
+func executeNodeScript(ctx context.Context, e *BaseLanguageExecutor, scriptPath string, workDir string, config *ExecutionConfig) (*sandbox.ExecuteResult, error) {
+	guardFile, err := verifyNodeGuard(workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	executeCmd := strings.ReplaceAll(e.executeCommand, "{source}", scriptPath)
+	cmdParts := strings.Fields(executeCmd)
+	if len(cmdParts) == 0 {
+		return nil, fmt.Errorf("empty execute command")
+	}
+
+	args := []string{
+		fmt.Sprintf("--max-old-space-size=%d", nodeHeapSizeMB(config.MemoryLimit)),
+		"--v8-pool-size=1",
+		"--require", guardFile,
+	}
+	args = append(args, cmdParts[1:]...)
+
+	processLimit := e.maxProcesses
+	if processLimit < nodeRuntimeMinThreads {
+		processLimit = nodeRuntimeMinThreads
+	}
+
+	sandboxConfig := &sandbox.SandboxConfig{
+		UID:                   65534,
+		GID:                   65534,
+		WorkDir:               workDir,
+		TimeLimit:             config.TimeLimit,
+		WallTimeLimit:         config.TimeLimit + 1000,
+		TimeLimitMetric:       config.TimeLimitMetric,
+		TraceSyscalls:         config.TraceSyscalls,
+		Pool:                  config.Pool,
+		CPUSetCores:           config.CPUSetCores,
+		MemoryLimit:           config.MemoryLimit,
+		StackLimit:            8 * 1024,
+		FileSizeLimit:         10 * 1024,
+		ProcessLimit:          processLimit,
+		SkipAddressSpaceLimit: true, // V8启动即预留超过1GB虚拟地址空间
+		AllowedSyscalls:       e.allowedSyscalls,
+		SyscallArgRules:       e.syscallArgRules,
+		EnableSeccomp:         true, // 启用seccomp安全过滤
+		InputFile:             config.InputFile,
+		OutputFile:            config.OutputFile,
+		ErrorFile:             config.ErrorFile,
+		Environment:           append(config.Environment, "UV_THREADPOOL_SIZE=1", "NODE_DISABLE_COLORS=1"),
+	}
+
+	// 守卫脚本在可写的工作目录中，单独只读挂载，目标程序无法改写或删除（rootless模式下它是文件所有者）
+	e.applySandboxPolicy(sandboxConfig, guardFile)
+	executeSandbox := sandbox.NewSystemCallSandbox(sandboxConfig)
+	return executeSandbox.Execute(ctx, cmdParts[0], args)
+}
+
+// JavaScript语言执行器
+// 标准输入由沙箱重定向为文件，process.stdin即为可读流，readline与fs.readFileSync(0)均可使用
+type JavaScriptExecutor struct {
+	*BaseLanguageExecutor
+}
+
+func NewJavaScriptExecutor(config config.CompilerConf) *JavaScriptExecutor {
+	allowedSyscalls := config.AllowedSyscalls
+	if len(allowedSyscalls) == 0 {
+		allowedSyscalls = sandbox.GetSyscallWhitelist("javascript")
+	}
+
+	base := &BaseLanguageExecutor{
+		name:             "javascript",
+		displayName:      "JavaScript",
+		version:          config.Version,
+		fileExtension:    ".js",
+		compileCommand:   config.CompileCommand,
+		executeCommand:   config.ExecuteCommand,
+		compileTimeout:   time.Duration(config.CompileTimeout) * time.Millisecond,
+		timeMultiplier:   config.TimeMultiplier,
+		memoryMultiplier: config.MemoryMultiplier,
+		maxProcesses:     config.MaxProcesses,
+		allowedSyscalls:  allowedSyscalls,
+		syscallArgRules:  syscallArgRules("javascript", config.SyscallRules),
+	}
+
+	return &JavaScriptExecutor{BaseLanguageExecutor: base}
+}
+
+func (e *JavaScriptExecutor) GetName() string              { return e.name }
+func (e *JavaScriptExecutor) GetDisplayName() string       { return e.displayName }
+func (e *JavaScriptExecutor) GetVersion() string           { return e.version }
+func (e *JavaScriptExecutor) GetFileExtension() string     { return e.fileExtension }
+func (e *JavaScriptExecutor) IsCompiled() bool             { return false }
+func (e *JavaScriptExecutor) GetTimeMultiplier() float64   { return e.timeMultiplier }
+func (e *JavaScriptExecutor) GetMemoryMultiplier() float64 { return e.memoryMultiplier }
+func (e *JavaScriptExecutor) GetMaxProcesses() int         { return e.maxProcesses }
+func (e *JavaScriptExecutor) GetAllowedSyscalls() []int    { return e.allowedSyscalls }
+
+func (e *JavaScriptExecutor) Compile(ctx context.Context, code string, workDir string) (*CompileResult, error) {
+	sourceFile := filepath.Join(workDir, "main.js")
+
+	// 写入源代码文件
+	if err := os.WriteFile(sourceFile, []byte(code), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write source file: %w", err)
+	}
+
+	// 确保源代码文件权限正确，让nobody用户能够读取
+	if err := sandbox.ChownToSandboxUser(sourceFile, e.policy.Rootless); err != nil {
+		return nil, fmt.Errorf("failed to change source file ownership: %w", err)
+	}
+
+	if err := writeNodeGuard(workDir); err != nil {
+		return nil, err
+	}
+
+	// JavaScript为解释执行，不需要编译
+	return &CompileResult{
+		Success:        true,
+		ExecutablePath: sourceFile,
+		CompileTime:    0,
+		Message:        "JavaScript source prepared",
+	}, nil
+}
+
+func (e *JavaScriptExecutor) Execute(ctx context.Context, executablePath string, workDir string, config *ExecutionConfig) (*sandbox.ExecuteResult, error) {
+	return executeNodeScript(ctx, e.BaseLanguageExecutor, executablePath, workDir, config)
+}
+
+// TypeScript语言执行器
+// 编译阶段将main.ts转译为CommonJS的main.js，执行阶段与JavaScript相同
+type TypeScriptExecutor struct {
+	*BaseLanguageExecutor
+}
+
+func NewTypeScriptExecutor(config config.CompilerConf) *TypeScriptExecutor {
+	allowedSyscalls := config.AllowedSyscalls
+	if len(allowedSyscalls) == 0 {
+		allowedSyscalls = sandbox.GetSyscallWhitelist("typescript")
+	}
+
+	base := &BaseLanguageExecutor{
+		name:             "typescript",
+		displayName:      "TypeScript",
+		version:          config.Version,
+		fileExtension:    ".ts",
+		compileCommand:   config.CompileCommand,
+		executeCommand:   config.ExecuteCommand,
+		compileTimeout:   time.Duration(config.CompileTimeout) * time.Millisecond,
+		timeMultiplier:   config.TimeMultiplier,
+		memoryMultiplier: config.MemoryMultiplier,
+		maxProcesses:     config.MaxProcesses,
+		allowedSyscalls:  allowedSyscalls,
+		syscallArgRules:  syscallArgRules("typescript", config.SyscallRules),
+	}
+
+	return &TypeScriptExecutor{BaseLanguageExecutor: base}
+}
+
+func (e *TypeScriptExecutor) GetName() string              { return e.name }
+func (e *TypeScriptExecutor) GetDisplayName() string       { return e.displayName }
+func (e *TypeScriptExecutor) GetVersion() string           { return e.version }
+func (e *TypeScriptExecutor) GetFileExtension() string     { return e.fileExtension }
+func (e *TypeScriptExecutor) IsCompiled() bool             { return true }
+func (e *TypeScriptExecutor) GetTimeMultiplier() float64   { return e.timeMultiplier }
+func (e *TypeScriptExecutor) GetMemoryMultiplier() float64 { return e.memoryMultiplier }
+func (e *TypeScriptExecutor) GetMaxProcesses() int         { return e.maxProcesses }
+func (e *TypeScriptExecutor) GetAllowedSyscalls() []int    { return e.allowedSyscalls }
+
+func (e *TypeScriptExecutor) Compile(ctx context.Context, code string, workDir string) (*CompileResult, error) {
+	sourceFile := filepath.Join(workDir, "main.ts")
+	outputFile := filepath.Join(workDir, "main.js")
+
+	// 写入源代码文件
+	if err := os.WriteFile(sourceFile, []byte(code), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write source file: %w", err)
+	}
+
+	// 确保源代码文件权限正确，让nobody用户能够读取
+	if err := sandbox.ChownToSandboxUser(sourceFile, e.policy.Rootless); err != nil {
+		return nil, fmt.Errorf("failed to change source file ownership: %w", err)
+	}
+
+	if err := writeNodeGuard(workDir); err != nil {
+		return nil, err
+	}
+
+	// 替换编译命令中的占位符，转译结果输出到工作目录
+	compileCmd := strings.ReplaceAll(e.compileCommand, "{output_dir}", workDir)
+	compileCmd = strings.ReplaceAll(compileCmd, "{source}", sourceFile)
+
+	sandboxConfig := &sandbox.SandboxConfig{
+		UID:                   65534,
+		GID:                   65534,
+		WorkDir:               workDir,
+		TimeLimit:             int64(e.compileTimeout.Milliseconds()),
+		WallTimeLimit:         int64(e.compileTimeout.Milliseconds()) + 1000,
+		MemoryLimit:           1024 * 1024, // 1GB编译内存限制
+		StackLimit:            8 * 1024,
+		FileSizeLimit:         50 * 1024,
+		ProcessLimit:          nodeRuntimeMinThreads, // tsc本身运行在Node上
+		SkipAddressSpaceLimit: true,
+		OutputFile:            filepath.Join(workDir, "compile_output.txt"),
+		ErrorFile:             filepath.Join(workDir, "compile_error.txt"),
+		Environment:           []string{"PATH=/usr/local/bin:/usr/bin:/bin", "HOME=/tmp", "NODE_DISABLE_COLORS=1"},
+	}
+
+	e.applySandboxPolicy(sandboxConfig)
+	compileSandbox := sandbox.NewSystemCallSandbox(sandboxConfig)
+	cmdParts := strings.Fields(compileCmd)
+	if len(cmdParts) == 0 {
+		return nil, fmt.Errorf("empty compile command")
+	}
+
+	startTime := time.Now()
+	result, err := compileSandbox.Execute(ctx, cmdParts[0], cmdParts[1:])
+	compileTime := time.Since(startTime)
+
+	// tsc将类型错误输出到标准输出，这里一并收集
+	var compileMessage string
+	for _, file := range []string{sandboxConfig.OutputFile, sandboxConfig.ErrorFile} {
+		if data, err := os.ReadFile(file); err == nil {
+			compileMessage += string(data)
+		}
+	}
+
+	compileResult := &CompileResult{
+		Success:        result != nil && result.Status == sandbox.StatusAccepted,
+		ExecutablePath: outputFile,
+		CompileTime:    compileTime,
+		Message:        compileMessage,
+	}
+
+	if err != nil {
+		compileResult.Success = false
+		compileResult.Message = fmt.Sprintf("Compile error: %v", err)
+	}
+
+	return compileResult, nil
+}
+
+func (e *TypeScriptExecutor) Execute(ctx context.Context, executablePath string, workDir string, config *ExecutionConfig) (*sandbox.ExecuteResult, error) {
+	return executeNodeScript(ctx, e.BaseLanguageExecutor, executablePath, workDir, config)
+}
+
+// 递归切换目录的读写权限（目录0755/0555，文件0644/0444）
+func setDirWritable(dir string, writable bool) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		mode := os.FileMode(0444)
+		if info.IsDir() {
+			mode = 0555
+		}
+		if writable {
+			mode |= 0200
+		}
+		return os.Chmod(path, mode)
+	})
+}
+
 // 语言执行器管理器
 type LanguageManager struct {
 	executors map[string]LanguageExecutor
 }
 
-func NewLanguageManager(compilers map[string]config.CompilerConf) *LanguageManager {
+func NewLanguageManager(compilers map[string]config.CompilerConf, policy SandboxPolicy) *LanguageManager {
 	manager := &LanguageManager{
 		executors: make(map[string]LanguageExecutor),
 	}
@@ -537,8 +1555,25 @@ func NewLanguageManager(compilers map[string]config.CompilerConf) *LanguageManag
 			manager.executors[lang] = NewJavaExecutor(conf)
 		case "python":
 			manager.executors[lang] = NewPythonExecutor(conf)
+		case "go":
+			goExecutor := NewGoExecutor(conf)
+			// 后台预热构建缓存，避免首个Go提交承担标准库编译耗时
+			go goExecutor.WarmupBuildCache()
+			manager.executors[lang] = goExecutor
+		case "javascript":
+			manager.executors[lang] = NewJavaScriptExecutor(conf)
+		case "typescript":
+			manager.executors[lang] = NewTypeScriptExecutor(conf)
 			// TODO: 添加更多语言支持
 		}
+
+		if setter, ok := manager.executors[lang].(sandboxPolicySetter); ok {
+			setter.setSandboxPolicy(policy)
+		}
+		// 校准与提交代码使用相同的沙箱策略，在设置策略之后于后台生成AppCDS归档并校准启动耗时
+		if javaExecutor, ok := manager.executors[lang].(*JavaExecutor); ok {
+			go javaExecutor.WarmupRuntime()
+		}
 	}
 
 	return manager
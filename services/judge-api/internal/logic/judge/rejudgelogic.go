@@ -274,18 +274,21 @@ func (l *RejudgeLogic) createRejudgeTask(originalTask *scheduler.JudgeTask, prob
 
 	// 创建新的判题任务，使用更高的优先级
 	rejudgeTask := &scheduler.JudgeTask{
-		SubmissionID: originalTask.SubmissionID,
-		ProblemID:    originalTask.ProblemID,
-		UserID:       originalTask.UserID,
-		Language:     originalTask.Language,
-		Code:         originalTask.Code,
-		TimeLimit:    problemInfo.TimeLimit,   // 使用最新的限制
-		MemoryLimit:  problemInfo.MemoryLimit, // 使用最新的限制
-		TestCases:    testCases,               // 使用最新的测试用例
-		Priority:     scheduler.PriorityHigh,  // 重新判题使用高优先级
-		Status:       scheduler.TaskStatusPending,
-		CreatedAt:    time.Now(),
-		RetryCount:   0, // 重置重试次数
+		SubmissionID:    originalTask.SubmissionID,
+		ProblemID:       originalTask.ProblemID,
+		UserID:          originalTask.UserID,
+		Language:        originalTask.Language,
+		Code:            originalTask.Code,
+		TimeLimit:       problemInfo.TimeLimit,   // 使用最新的限制
+		MemoryLimit:     problemInfo.MemoryLimit, // 使用最新的限制
+		TimeLimitMetric: problemInfo.TimeLimitMetric,
+		TestCases:       testCases,              // 使用最新的测试用例
+		Priority:        scheduler.PriorityHigh, // 重新判题使用高优先级
+		ContestID:       originalTask.ContestID,
+		BatchID:         fmt.Sprintf("rejudge:problem:%d", originalTask.ProblemID), // 同一题目的重判共享一个公平排队的流
+		Status:          scheduler.TaskStatusPending,
+		CreatedAt:       time.Now(),
+		RetryCount:      0, // 重置重试次数
 	}
 
 	// 生成新的任务ID
@@ -85,15 +85,17 @@ func (l *SubmitJudgeLogic) SubmitJudge(req *types.SubmitJudgeReq) (resp *types.S
 
 	// 6. 创建判题任务（使用题目的时间和内存限制）
 	task := &scheduler.JudgeTask{
-		SubmissionID: req.SubmissionId,
-		ProblemID:    req.ProblemId,
-		UserID:       req.UserId,
-		Language:     req.Language,
-		Code:         req.Code,
-		TimeLimit:    problemInfo.TimeLimit,   // 从题目服务获取
-		MemoryLimit:  problemInfo.MemoryLimit, // 从题目服务获取
-		TestCases:    testCases,               // 从题目服务获取
-		Priority:     l.determinePriority(req.UserId),
+		SubmissionID:    req.SubmissionId,
+		ProblemID:       req.ProblemId,
+		UserID:          req.UserId,
+		Language:        req.Language,
+		Code:            req.Code,
+		TimeLimit:       problemInfo.TimeLimit,   // 从题目服务获取
+		MemoryLimit:     problemInfo.MemoryLimit, // 从题目服务获取
+		TimeLimitMetric: problemInfo.TimeLimitMetric,
+		TestCases:       testCases, // 从题目服务获取
+		Priority:        l.determinePriority(req.UserId),
+		ContestID:       req.ContestId,
 	}
 
 	// 7. 提交任务到调度器
@@ -302,6 +304,10 @@ func (l *SubmitJudgeLogic) validateBasicRequest(req *types.SubmitJudgeReq) error
 		return fmt.Errorf("无效的用户ID: %d", req.UserId)
 	}
 
+	if req.ContestId < 0 {
+		return fmt.Errorf("无效的比赛ID: %d", req.ContestId)
+	}
+
 	if req.Language == "" {
 		return fmt.Errorf("编程语言不能为空")
 	}
@@ -370,6 +376,10 @@ func (l *SubmitJudgeLogic) validateCodeSecurity(code, language string) error {
 		if err := l.validatePythonSecurity(code); err != nil {
 			return err
 		}
+	case "javascript", "typescript":
+		if err := l.validateJavaScriptSecurity(code); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -439,6 +449,25 @@ func (l *SubmitJudgeLogic) validatePythonSecurity(code string) error {
 	return nil
 }
 
+// validateJavaScriptSecurity JavaScript/TypeScript代码安全检查
+// 运行时另有模块加载守卫，这里只做提交阶段的快速拦截
+func (l *SubmitJudgeLogic) validateJavaScriptSecurity(code string) error {
+	dangerousPatterns := []string{
+		"child_process",
+		"worker_threads",
+		"process.binding",
+		"process.dlopen",
+	}
+
+	for _, pattern := range dangerousPatterns {
+		if strings.Contains(code, pattern) {
+			return fmt.Errorf("JavaScript代码包含危险操作: %s", pattern)
+		}
+	}
+
+	return nil
+}
+
 // convertTestCases 转换测试用例（值类型 -> 指针类型）
 func (l *SubmitJudgeLogic) convertTestCases(testCases []types.TestCase) []*types.TestCase {
 	result := make([]*types.TestCase, len(testCases))
@@ -31,13 +31,15 @@ func (l *GetJudgeQueueLogic) GetJudgeQueue(req *types.GetJudgeQueueReq) (resp *t
 	queueItems := make([]types.QueueItem, len(queueStatus.QueueItems))
 	for i, item := range queueStatus.QueueItems {
 		queueItems[i] = types.QueueItem{
-			SubmissionId:  item.SubmissionID,
-			UserId:        item.UserID,
-			ProblemId:     item.ProblemID,
-			Language:      item.Language,
-			Priority:      item.Priority,
-			QueueTime:     item.QueueTime,
-			EstimatedTime: item.EstimatedTime,
+			SubmissionId:      item.SubmissionID,
+			UserId:            item.UserID,
+			ProblemId:         item.ProblemID,
+			Language:          item.Language,
+			Priority:          item.Priority,
+			EffectivePriority: item.EffectivePriority,
+			QueueTime:         item.QueueTime,
+			WaitTime:          item.WaitTime,
+			EstimatedTime:     item.EstimatedTime,
 		}
 	}
 
@@ -26,6 +26,7 @@ type JudgeTaskMessage struct {
 	MemoryLimit  int               `json:"memory_limit"` // MB
 	TestCases    []*types.TestCase `json:"test_cases"`
 	Priority     int               `json:"priority"`
+	ContestID    int64             `json:"contest_id,omitempty"`
 	CreatedAt    time.Time         `json:"created_at"`
 }
 
@@ -78,6 +79,11 @@ func (c *KafkaConsumer) Start(ctx context.Context) error {
 
 	go c.consume()
 
+	// 重启前已确认的消息不会再次投递，恢复的任务在这里继续跟踪并回报结果
+	for _, task := range c.taskScheduler.RecoveredTasks() {
+		go c.monitorTaskStatus(task)
+	}
+
 	logx.Infof("Kafka consumer started, listening on topic: %s", c.config.Topic)
 	return nil
 }
@@ -197,15 +203,17 @@ func (c *KafkaConsumer) processMessage(message *kafka.Message) error {
 
 	// 创建完整的调度器任务
 	task := &scheduler.JudgeTask{
-		SubmissionID: taskMessage.SubmissionID,
-		ProblemID:    taskMessage.ProblemID,
-		UserID:       taskMessage.UserID,
-		Language:     taskMessage.Language,
-		Code:         taskMessage.Code,
-		TimeLimit:    problemDetails.TimeLimit,
-		MemoryLimit:  problemDetails.MemoryLimit,
-		TestCases:    problemDetails.TestCases,
-		Priority:     taskMessage.Priority,
+		SubmissionID:    taskMessage.SubmissionID,
+		ProblemID:       taskMessage.ProblemID,
+		UserID:          taskMessage.UserID,
+		Language:        taskMessage.Language,
+		Code:            taskMessage.Code,
+		TimeLimit:       problemDetails.TimeLimit,
+		MemoryLimit:     problemDetails.MemoryLimit,
+		TimeLimitMetric: problemDetails.TimeLimitMetric,
+		TestCases:       problemDetails.TestCases,
+		Priority:        taskMessage.Priority,
+		ContestID:       taskMessage.ContestID,
 	}
 
 	// 提交任务到调度器
@@ -270,19 +278,21 @@ func (c *KafkaConsumer) fetchProblemDetails(problemID int64) (*ProblemDetails, e
 	}
 
 	return &ProblemDetails{
-		ProblemID:   problemID,
-		TimeLimit:   problemInfo.TimeLimit,
-		MemoryLimit: problemInfo.MemoryLimit,
-		TestCases:   schedulerTestCases,
+		ProblemID:       problemID,
+		TimeLimit:       problemInfo.TimeLimit,
+		MemoryLimit:     problemInfo.MemoryLimit,
+		TimeLimitMetric: problemInfo.TimeLimitMetric,
+		TestCases:       schedulerTestCases,
 	}, nil
 }
 
 // ProblemDetails 题目详细信息
 type ProblemDetails struct {
-	ProblemID   int64             `json:"problem_id"`
-	TimeLimit   int               `json:"time_limit"`   // 毫秒
-	MemoryLimit int               `json:"memory_limit"` // MB
-	TestCases   []*types.TestCase `json:"test_cases"`
+	ProblemID       int64             `json:"problem_id"`
+	TimeLimit       int               `json:"time_limit"`                  // 毫秒
+	MemoryLimit     int               `json:"memory_limit"`                // MB
+	TimeLimitMetric string            `json:"time_limit_metric,omitempty"` // cpu或wall，为空时使用全局配置
+	TestCases       []*types.TestCase `json:"test_cases"`
 }
 
 // 监控任务状态
@@ -0,0 +1,138 @@
+package profiler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/languages"
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/sandbox"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// 系统调用学习模式
+// 在学习模式的沙箱中编译并运行一组样例程序，汇总观察到的系统调用，生成建议的白名单并与当前白名单比较
+// 升级编译器或运行时后重新运行即可得到需要调整的系统调用，不必反复提交程序试错
+
+// Options 学习模式参数
+type Options struct {
+	Language    string // 语言
+	CorpusDir   string // 样例程序目录：扩展名与语言一致的源文件，同名的.in文件作为标准输入
+	WorkDir     string // 编译与运行样例的临时目录
+	TimeLimit   int64  // 时间限制(毫秒)
+	MemoryLimit int64  // 内存限制(KB)
+}
+
+// Result 学习结果
+type Result struct {
+	Profile *sandbox.SyscallProfile     // 建议的系统调用配置
+	Diff    *sandbox.SyscallProfileDiff // 与当前白名单、黑名单的差异
+	Failed  []string                    // 没有正常结束的样例，其系统调用仍计入结果
+}
+
+// Run 运行样例程序集并生成系统调用配置
+func Run(ctx context.Context, executor languages.LanguageExecutor, opts Options) (*Result, error) {
+	samples, err := findSamples(opts.CorpusDir, executor.GetFileExtension())
+	if err != nil {
+		return nil, err
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no %s samples found in %s", executor.GetFileExtension(), opts.CorpusDir)
+	}
+
+	result := &Result{}
+	var traces []map[int]int
+	for _, sample := range samples {
+		syscalls, ok, err := runSample(ctx, executor, sample, opts)
+		if err != nil {
+			return nil, fmt.Errorf("sample %s: %w", filepath.Base(sample), err)
+		}
+		if !ok {
+			result.Failed = append(result.Failed, filepath.Base(sample))
+		}
+		logx.Infof("Profiled sample %s: %d distinct syscalls", filepath.Base(sample), len(syscalls))
+		traces = append(traces, syscalls)
+	}
+
+	result.Profile = sandbox.NewSyscallProfile(opts.Language, traces)
+	result.Diff = result.Profile.Diff(executor.GetAllowedSyscalls(), sandbox.GetSyscallDenylist(opts.Language))
+	return result, nil
+}
+
+// 按文件名排序的样例源文件
+func findSamples(dir, extension string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read corpus: %w", err)
+	}
+
+	var samples []string
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == extension {
+			samples = append(samples, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(samples)
+	return samples, nil
+}
+
+// 编译并以学习模式运行单个样例，返回观察到的系统调用与是否正常结束
+func runSample(ctx context.Context, executor languages.LanguageExecutor, sample string, opts Options) (map[int]int, bool, error) {
+	code, err := os.ReadFile(sample)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// 样例以nobody身份运行，需要能够进入工作目录
+	workDir, err := os.MkdirTemp(opts.WorkDir, "syscall-profile-")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create work dir: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+	if err := os.Chmod(workDir, 0777); err != nil {
+		return nil, false, err
+	}
+
+	// 解释型语言的Compile只写入源文件（JavaScript同时写入模块守卫脚本）
+	compileResult, err := executor.Compile(ctx, string(code), workDir)
+	if err != nil {
+		return nil, false, fmt.Errorf("compile failed: %w", err)
+	}
+	if !compileResult.Success {
+		return nil, false, fmt.Errorf("compile failed: %s", compileResult.Message)
+	}
+	executablePath := compileResult.ExecutablePath
+
+	input := []byte{}
+	if data, err := os.ReadFile(strings.TrimSuffix(sample, filepath.Ext(sample)) + ".in"); err == nil {
+		input = data
+	}
+	inputFile := filepath.Join(workDir, "input.txt")
+	if err := os.WriteFile(inputFile, input, 0644); err != nil {
+		return nil, false, err
+	}
+
+	result, err := executor.Execute(ctx, executablePath, workDir, &languages.ExecutionConfig{
+		TimeLimit:     int64(float64(opts.TimeLimit) * executor.GetTimeMultiplier()),
+		MemoryLimit:   int64(float64(opts.MemoryLimit) * executor.GetMemoryMultiplier()),
+		TraceSyscalls: true,
+		InputFile:     inputFile,
+		OutputFile:    filepath.Join(workDir, "output.txt"),
+		ErrorFile:     filepath.Join(workDir, "error.txt"),
+		Environment:   []string{"PATH=/usr/bin:/bin"},
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("execution failed: %w", err)
+	}
+
+	ok := result.Status == sandbox.StatusAccepted && result.ExitCode == 0
+	if !ok {
+		logx.Errorf("Sample %s did not exit cleanly: status=%d exit=%d signal=%d stderr=%s",
+			filepath.Base(sample), result.Status, result.ExitCode, result.Signal, result.ErrorOutput)
+	}
+	return result.Syscalls, ok, nil
+}
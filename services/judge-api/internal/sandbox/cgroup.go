@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/zeromicro/go-zero/core/logx"
@@ -23,6 +24,17 @@ const (
 	CgroupCPUSet = "cpuset" // CPU集合子系统
 	CgroupPIDs   = "pids"   // 进程ID子系统
 	CgroupBlkIO  = "blkio"  // 块设备I/O子系统
+
+	// cgroup v2统一层级下所有控制器共用一个目录
+	CgroupUnified = "unified"
+)
+
+// CgroupVersion cgroup层级版本
+type CgroupVersion int
+
+const (
+	CgroupV1 CgroupVersion = 1 // 各子系统独立挂载（/sys/fs/cgroup/{memory,cpu,...}）
+	CgroupV2 CgroupVersion = 2 // 统一层级（/sys/fs/cgroup/cgroup.controllers）
 )
 
 // cgroup根路径
@@ -34,9 +46,10 @@ const (
 // CgroupConfig cgroup配置结构体
 type CgroupConfig struct {
 	// 基础配置
-	GroupName string // 控制组名称
-	Language  string // 编程语言
-	TaskID    string // 任务ID
+	GroupName  string // 控制组名称
+	Language   string // 编程语言
+	TaskID     string // 任务ID
+	ParentPath string // 判题根组所在的cgroup v2目录（委派的子树），为空时为挂载点
 
 	// 内存限制配置
 	MemoryLimitBytes     int64 // 内存限制（字节）
@@ -53,16 +66,21 @@ type CgroupConfig struct {
 	PIDsMax int64 // 最大进程数
 
 	// I/O限制配置
-	BlkIOWeight   int64 // I/O权重
-	BlkIOReadBps  int64 // 读取带宽限制（字节/秒）
-	BlkIOWriteBps int64 // 写入带宽限制（字节/秒）
+	BlkIOWeight   int64  // I/O权重
+	BlkIOReadBps  int64  // 读取带宽限制（字节/秒）
+	BlkIOWriteBps int64  // 写入带宽限制（字节/秒）
+	BlkIODevice   string // 带宽限制作用的块设备号（major:minor），为空时不限制带宽
 }
 
 // CgroupManager cgroup管理器
 type CgroupManager struct {
 	config     *CgroupConfig
-	groupPaths map[string]string // 各子系统的组路径
+	root       string            // cgroup文件系统挂载点
+	version    CgroupVersion     // cgroup层级版本
+	groupPaths map[string]string // 各子系统的组路径（v2下只有unified一项）
 	created    bool              // 是否已创建
+	baseline   CgroupStats       // 复用前的累计计数（见Reset），GetStats返回与基线的差值
+	peakFile   *os.File          // v2下重置过的memory.peak，重置只对写入时使用的文件描述符生效
 }
 
 // CgroupStats cgroup统计信息
@@ -93,8 +111,15 @@ type CgroupStats struct {
 
 // NewCgroupManager 创建新的cgroup管理器
 func NewCgroupManager(config *CgroupConfig) *CgroupManager {
+	return newCgroupManagerAt(config, CgroupRootPath)
+}
+
+// 在指定挂载点上创建cgroup管理器，自动识别v1/v2层级
+func newCgroupManagerAt(config *CgroupConfig, root string) *CgroupManager {
 	manager := &CgroupManager{
 		config:     config,
+		root:       root,
+		version:    DetectCgroupVersion(root),
 		groupPaths: make(map[string]string),
 		created:    false,
 	}
@@ -102,17 +127,54 @@ func NewCgroupManager(config *CgroupConfig) *CgroupManager {
 	// 构建各子系统的组路径
 	manager.buildGroupPaths()
 
-	logx.Infof("Created cgroup manager for group: %s", config.GroupName)
+	logx.Infof("Created cgroup manager for group: %s (cgroup v%d)", config.GroupName, manager.version)
 	return manager
 }
 
+// DetectCgroupVersion 检测挂载点使用的cgroup层级版本
+// 只有根目录存在cgroup.controllers时才是纯v2；v1与混合模式（v2挂载在unified子目录且无控制器）按v1处理
+func DetectCgroupVersion(root string) CgroupVersion {
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err == nil {
+		return CgroupV2
+	}
+	return CgroupV1
+}
+
+// Version 返回当前使用的cgroup层级版本
+func (c *CgroupManager) Version() CgroupVersion {
+	return c.version
+}
+
+// 获取子系统对应的组路径，v2下所有子系统共用统一目录
+func (c *CgroupManager) subsystemPath(subsystem string) string {
+	if c.version == CgroupV2 {
+		return c.groupPaths[CgroupUnified]
+	}
+	return c.groupPaths[subsystem]
+}
+
+// v2下判题根组的上级目录
+func (c *CgroupManager) parentPath() string {
+	if c.config.ParentPath != "" {
+		return c.config.ParentPath
+	}
+	return c.root
+}
+
 // 构建各子系统的组路径
 func (c *CgroupManager) buildGroupPaths() {
+	if c.version == CgroupV2 {
+		// 路径格式: /sys/fs/cgroup/judge/{language}/{group_name}，委派子树时位于ParentPath下
+		c.groupPaths[CgroupUnified] = filepath.Join(c.parentPath(), JudgeRootGroup, c.config.Language, c.config.GroupName)
+		logx.Debugf("Built cgroup v2 path: %s", c.groupPaths[CgroupUnified])
+		return
+	}
+
 	subsystems := []string{CgroupMemory, CgroupCPU, CgroupCPUSet, CgroupPIDs, CgroupBlkIO}
 
 	for _, subsystem := range subsystems {
 		// 路径格式: /sys/fs/cgroup/{subsystem}/judge/{language}/{group_name}
-		path := filepath.Join(CgroupRootPath, subsystem, JudgeRootGroup, c.config.Language, c.config.GroupName)
+		path := filepath.Join(c.root, subsystem, JudgeRootGroup, c.config.Language, c.config.GroupName)
 		c.groupPaths[subsystem] = path
 	}
 
@@ -155,12 +217,16 @@ func (c *CgroupManager) Create() error {
 
 // 确保父级目录存在
 func (c *CgroupManager) ensureParentDirectories() error {
+	if c.version == CgroupV2 {
+		return c.ensureParentDirectoriesV2()
+	}
+
 	parentDirs := []string{
-		filepath.Join(CgroupRootPath, CgroupMemory, JudgeRootGroup),
-		filepath.Join(CgroupRootPath, CgroupCPU, JudgeRootGroup),
-		filepath.Join(CgroupRootPath, CgroupCPUSet, JudgeRootGroup),
-		filepath.Join(CgroupRootPath, CgroupPIDs, JudgeRootGroup),
-		filepath.Join(CgroupRootPath, CgroupBlkIO, JudgeRootGroup),
+		filepath.Join(c.root, CgroupMemory, JudgeRootGroup),
+		filepath.Join(c.root, CgroupCPU, JudgeRootGroup),
+		filepath.Join(c.root, CgroupCPUSet, JudgeRootGroup),
+		filepath.Join(c.root, CgroupPIDs, JudgeRootGroup),
+		filepath.Join(c.root, CgroupBlkIO, JudgeRootGroup),
 	}
 
 	for _, dir := range parentDirs {
@@ -175,6 +241,10 @@ func (c *CgroupManager) ensureParentDirectories() error {
 
 // 应用资源限制配置
 func (c *CgroupManager) applyLimits() error {
+	if c.version == CgroupV2 {
+		return c.applyLimitsV2()
+	}
+
 	var errors []error
 
 	// 应用内存限制
@@ -295,7 +365,7 @@ func (c *CgroupManager) applyPIDsLimits() error {
 		return nil
 	}
 
-	pidsPath := c.groupPaths[CgroupPIDs]
+	pidsPath := c.subsystemPath(CgroupPIDs)
 	maxFile := filepath.Join(pidsPath, "pids.max")
 
 	if err := c.writeFile(maxFile, strconv.FormatInt(c.config.PIDsMax, 10)); err != nil {
@@ -319,16 +389,30 @@ func (c *CgroupManager) applyBlkIOLimits() error {
 		logx.Debugf("Set BlkIO weight: %d", c.config.BlkIOWeight)
 	}
 
-	// 设置读取带宽限制（需要指定设备）
+	// 设置带宽限制（需要指定设备）
+	// 格式: "major:minor bytes_per_second"
+	// 示例: "8:0 1048576" 表示设备8:0限制为1MB/s
+	if c.config.BlkIODevice == "" {
+		if c.config.BlkIOReadBps > 0 || c.config.BlkIOWriteBps > 0 {
+			logx.Debugf("BlkIO bandwidth limit configured without device, skipped")
+		}
+		return nil
+	}
+
 	if c.config.BlkIOReadBps > 0 {
-		// 这里简化处理，实际应该获取具体的块设备号
-		// 格式: "major:minor bytes_per_second"
-		// 示例: "8:0 1048576" 表示设备8:0限制为1MB/s
-		logx.Debugf("BlkIO read BPS limit configured: %d (device-specific setup required)", c.config.BlkIOReadBps)
+		readFile := filepath.Join(blkioPath, "blkio.throttle.read_bps_device")
+		if err := c.writeFile(readFile, fmt.Sprintf("%s %d", c.config.BlkIODevice, c.config.BlkIOReadBps)); err != nil {
+			return fmt.Errorf("failed to set blkio read bps: %w", err)
+		}
+		logx.Debugf("Set BlkIO read BPS: %s %d", c.config.BlkIODevice, c.config.BlkIOReadBps)
 	}
 
 	if c.config.BlkIOWriteBps > 0 {
-		logx.Debugf("BlkIO write BPS limit configured: %d (device-specific setup required)", c.config.BlkIOWriteBps)
+		writeFile := filepath.Join(blkioPath, "blkio.throttle.write_bps_device")
+		if err := c.writeFile(writeFile, fmt.Sprintf("%s %d", c.config.BlkIODevice, c.config.BlkIOWriteBps)); err != nil {
+			return fmt.Errorf("failed to set blkio write bps: %w", err)
+		}
+		logx.Debugf("Set BlkIO write BPS: %s %d", c.config.BlkIODevice, c.config.BlkIOWriteBps)
 	}
 
 	return nil
@@ -355,6 +439,20 @@ func (c *CgroupManager) AddProcess(pid int) error {
 	return nil
 }
 
+// ProcsFiles 返回各子系统的cgroup.procs路径，供沙箱辅助进程在fork/execve之前自行加入
+// 未创建（如降级为setrlimit）时返回nil
+func (c *CgroupManager) ProcsFiles() []string {
+	if !c.created {
+		return nil
+	}
+
+	files := make([]string, 0, len(c.groupPaths))
+	for _, path := range c.groupPaths {
+		files = append(files, filepath.Join(path, "cgroup.procs"))
+	}
+	return files
+}
+
 // GetStats 获取cgroup统计信息
 func (c *CgroupManager) GetStats() (*CgroupStats, error) {
 	if !c.created {
@@ -363,6 +461,12 @@ func (c *CgroupManager) GetStats() (*CgroupStats, error) {
 
 	stats := &CgroupStats{}
 
+	if c.version == CgroupV2 {
+		c.getStatsV2(stats)
+		c.subtractBaseline(stats)
+		return stats, nil
+	}
+
 	// 获取内存统计
 	if err := c.getMemoryStats(stats); err != nil {
 		logx.Errorf("Failed to get memory stats: %v", err)
@@ -383,9 +487,141 @@ func (c *CgroupManager) GetStats() (*CgroupStats, error) {
 		logx.Errorf("Failed to get BlkIO stats: %v", err)
 	}
 
+	c.subtractBaseline(stats)
 	return stats, nil
 }
 
+// 累计计数减去复用前的基线，瞬时值与限制保持不变
+func (c *CgroupManager) subtractBaseline(stats *CgroupStats) {
+	stats.MemoryOOMCount -= c.baseline.MemoryOOMCount
+	stats.CPUUsageTotal -= c.baseline.CPUUsageTotal
+	stats.CPUUsageUser -= c.baseline.CPUUsageUser
+	stats.CPUUsageSystem -= c.baseline.CPUUsageSystem
+	stats.CPUThrottled -= c.baseline.CPUThrottled
+	stats.BlkIOReadBytes -= c.baseline.BlkIOReadBytes
+	stats.BlkIOWriteBytes -= c.baseline.BlkIOWriteBytes
+	stats.BlkIOReadOps -= c.baseline.BlkIOReadOps
+	stats.BlkIOWriteOps -= c.baseline.BlkIOWriteOps
+}
+
+// Reconfigure 以新的资源限制复用已创建的控制组，组名与路径保持不变
+// 先恢复为不限制，避免上一次执行设置、本次未设置的限制残留
+func (c *CgroupManager) Reconfigure(config *CgroupConfig) error {
+	if !c.created {
+		return fmt.Errorf("cgroup not created")
+	}
+
+	limits := *config
+	limits.GroupName, limits.Language, limits.TaskID = c.config.GroupName, c.config.Language, c.config.TaskID
+	limits.ParentPath = c.config.ParentPath
+	previous := c.config
+	c.config = &limits
+
+	if c.version == CgroupV2 {
+		c.clearLimitsV2(previous)
+	} else {
+		c.clearLimits(previous)
+	}
+	return c.applyLimits()
+}
+
+// 恢复v1各子系统为不限制；memsw不能小于内存限制，需要先放开
+func (c *CgroupManager) clearLimits(previous *CgroupConfig) {
+	memoryPath := c.groupPaths[CgroupMemory]
+	c.writeFile(filepath.Join(memoryPath, "memory.memsw.limit_in_bytes"), "-1")
+	c.writeFile(filepath.Join(memoryPath, "memory.limit_in_bytes"), "-1")
+	c.writeFile(filepath.Join(c.groupPaths[CgroupCPU], "cpu.cfs_quota_us"), "-1")
+	c.writeFile(filepath.Join(c.groupPaths[CgroupPIDs], "pids.max"), "max")
+
+	// v1的cpuset不能为空，恢复为父组的取值
+	if previous.CPUSetCPUs != "" {
+		cpusetPath := c.groupPaths[CgroupCPUSet]
+		if cpus, err := c.readFile(filepath.Join(filepath.Dir(cpusetPath), "cpuset.cpus")); err == nil {
+			c.writeFile(filepath.Join(cpusetPath, "cpuset.cpus"), cpus)
+		}
+	}
+	// 带宽限制写0即删除该设备的规则
+	if previous.BlkIODevice != "" {
+		blkioPath := c.groupPaths[CgroupBlkIO]
+		c.writeFile(filepath.Join(blkioPath, "blkio.throttle.read_bps_device"), previous.BlkIODevice+" 0")
+		c.writeFile(filepath.Join(blkioPath, "blkio.throttle.write_bps_device"), previous.BlkIODevice+" 0")
+	}
+}
+
+// Reset 清空控制组以便复用：终止残留进程，重置内存峰值，并把当前的累计计数（CPU时间、OOM、I/O等）记为基线
+// 内核不支持重置内存峰值时返回错误，调用方应删除该控制组并重新创建
+func (c *CgroupManager) Reset() error {
+	if !c.created {
+		return fmt.Errorf("cgroup not created")
+	}
+
+	if c.version == CgroupV2 {
+		c.killAllV2()
+	} else {
+		c.killAll()
+	}
+	if err := c.waitForProcessesExit(); err != nil {
+		return err
+	}
+
+	if c.version == CgroupV2 {
+		if err := c.resetMemoryPeakV2(); err != nil {
+			return fmt.Errorf("failed to reset memory.peak: %w", err)
+		}
+	} else if err := c.writeFile(filepath.Join(c.groupPaths[CgroupMemory], "memory.max_usage_in_bytes"), "0"); err != nil {
+		return fmt.Errorf("failed to reset memory.max_usage_in_bytes: %w", err)
+	}
+
+	c.baseline = CgroupStats{}
+	stats, err := c.GetStats()
+	if err != nil {
+		return err
+	}
+	c.baseline = *stats
+	return nil
+}
+
+// 向v1各子系统cgroup.procs中的进程发送SIGKILL
+func (c *CgroupManager) killAll() {
+	for _, path := range c.groupPaths {
+		content, err := c.readFile(filepath.Join(path, "cgroup.procs"))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(content, "\n") {
+			if pid, err := strconv.Atoi(strings.TrimSpace(line)); err == nil && pid > 0 {
+				syscall.Kill(pid, syscall.SIGKILL)
+			}
+		}
+	}
+}
+
+// SampleUsage 读取当前内存使用量（字节）与累计CPU时间（纳秒），供执行期间的资源采样使用
+// 只读取两个文件，开销远小于GetStats
+func (c *CgroupManager) SampleUsage() (int64, int64, error) {
+	var memory, cpu int64
+	var err error
+	if c.version == CgroupV2 {
+		groupPath := c.groupPaths[CgroupUnified]
+		if memory, err = c.readInt64File(filepath.Join(groupPath, "memory.current")); err != nil {
+			return 0, 0, err
+		}
+		cpuStat, err := c.readKeyValueFile(filepath.Join(groupPath, "cpu.stat"))
+		if err != nil {
+			return 0, 0, err
+		}
+		cpu = cpuStat["usage_usec"] * 1000
+	} else {
+		if memory, err = c.readInt64File(filepath.Join(c.groupPaths[CgroupMemory], "memory.usage_in_bytes")); err != nil {
+			return 0, 0, err
+		}
+		if cpu, err = c.readInt64File(filepath.Join(c.groupPaths[CgroupCPU], "cpuacct.usage")); err != nil {
+			return 0, 0, err
+		}
+	}
+	return memory, cpu - c.baseline.CPUUsageTotal, nil
+}
+
 // 获取内存统计信息
 func (c *CgroupManager) getMemoryStats(stats *CgroupStats) error {
 	memoryPath := c.groupPaths[CgroupMemory]
@@ -480,6 +716,11 @@ func (c *CgroupManager) Cleanup() error {
 
 	logx.Infof("Cleaning up cgroup: %s", c.config.GroupName)
 
+	// v2下先通过cgroup.kill终止组内残留进程
+	if c.version == CgroupV2 {
+		c.killAllV2()
+	}
+
 	// 等待所有进程退出
 	if err := c.waitForProcessesExit(); err != nil {
 		logx.Errorf("Some processes may still be running: %v", err)
@@ -488,7 +729,7 @@ func (c *CgroupManager) Cleanup() error {
 	// 删除各子系统的控制组目录
 	var errors []error
 	for subsystem, path := range c.groupPaths {
-		if err := os.RemoveAll(path); err != nil && !os.IsNotExist(err) {
+		if err := c.removeGroupDir(path); err != nil && !os.IsNotExist(err) {
 			errors = append(errors, fmt.Errorf("failed to remove %s cgroup: %w", subsystem, err))
 		} else {
 			logx.Debugf("Removed cgroup directory: %s", path)
@@ -496,6 +737,11 @@ func (c *CgroupManager) Cleanup() error {
 	}
 
 	c.created = false
+	if c.peakFile != nil {
+		c.peakFile.Close()
+		c.peakFile = nil
+	}
+	c.baseline = CgroupStats{}
 
 	if len(errors) > 0 {
 		return fmt.Errorf("cleanup errors: %v", errors)
@@ -667,8 +913,17 @@ func (c *CgroupManager) parseBlkIOStats(content string, stats map[string]int64,
 // 清理部分创建的资源
 func (c *CgroupManager) cleanupPartialCreation() {
 	for _, path := range c.groupPaths {
-		os.RemoveAll(path)
+		c.removeGroupDir(path)
+	}
+}
+
+// 删除控制组目录
+// cgroupfs中的控制文件不能单独删除，目录为空（无子组、无进程）时rmdir即可
+func (c *CgroupManager) removeGroupDir(path string) error {
+	if err := os.Remove(path); err == nil || os.IsNotExist(err) {
+		return err
 	}
+	return os.RemoveAll(path)
 }
 
 // IsProcessInGroup 检查进程是否在指定的cgroup中
@@ -678,7 +933,7 @@ func (c *CgroupManager) IsProcessInGroup(pid int) bool {
 	}
 
 	// 检查进程是否在memory cgroup中（代表性检查）
-	memoryPath := c.groupPaths[CgroupMemory]
+	memoryPath := c.subsystemPath(CgroupMemory)
 	procsFile := filepath.Join(memoryPath, "cgroup.procs")
 
 	content, err := c.readFile(procsFile)
@@ -0,0 +1,224 @@
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 构造一个模拟的cgroup v2挂载点：根目录存在cgroup.controllers即视为统一层级
+func fakeCgroupV2Root(t *testing.T, controllers string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "cgroup.controllers"), []byte(controllers+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// 内核会在新建的子组中自动生成cgroup.controllers，这里预先写好中间组的
+	for _, dir := range []string{
+		filepath.Join(root, JudgeRootGroup),
+		filepath.Join(root, JudgeRootGroup, "cpp"),
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "cgroup.controllers"), []byte(controllers+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func readCgroupFile(t *testing.T, path string) string {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(content))
+}
+
+func TestDetectCgroupVersion(t *testing.T) {
+	if version := DetectCgroupVersion(t.TempDir()); version != CgroupV1 {
+		t.Fatalf("expected v1 without cgroup.controllers, got v%d", version)
+	}
+	if version := DetectCgroupVersion(fakeCgroupV2Root(t, "memory")); version != CgroupV2 {
+		t.Fatalf("expected v2 with cgroup.controllers, got v%d", version)
+	}
+}
+
+func TestCgroupV2CreateWritesUnifiedLimits(t *testing.T) {
+	root := fakeCgroupV2Root(t, "cpuset cpu io memory pids")
+	manager := newCgroupManagerAt(&CgroupConfig{
+		GroupName:        "task",
+		Language:         "cpp",
+		MemoryLimitBytes: 256 << 20,
+		MemorySwapLimit:  256 << 20,
+		CPUQuotaUs:       50000,
+		CPUPeriodUs:      100000,
+		CPUSetCPUs:       "0-1",
+		PIDsMax:          8,
+		BlkIOWeight:      500,
+		BlkIOReadBps:     1 << 20,
+		BlkIODevice:      "8:0",
+	}, root)
+
+	if err := manager.Create(); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	for _, dir := range []string{root, filepath.Join(root, JudgeRootGroup), filepath.Join(root, JudgeRootGroup, "cpp")} {
+		if got := readCgroupFile(t, filepath.Join(dir, "cgroup.subtree_control")); got != "+cpu +cpuset +memory +pids +io" {
+			t.Fatalf("unexpected subtree_control in %s: %q", dir, got)
+		}
+	}
+
+	group := filepath.Join(root, JudgeRootGroup, "cpp", "task")
+	expected := map[string]string{
+		"memory.max":       "268435456",
+		"memory.swap.max":  "0",
+		"memory.oom.group": "1",
+		"cpu.max":          "50000 100000",
+		"cpuset.cpus":      "0-1",
+		"pids.max":         "8",
+		"io.weight":        "default 4950",
+		"io.max":           "8:0 rbps=1048576",
+	}
+	for file, want := range expected {
+		if got := readCgroupFile(t, filepath.Join(group, file)); got != want {
+			t.Fatalf("%s = %q, want %q", file, got, want)
+		}
+	}
+}
+
+func TestCgroupV2StatsMapping(t *testing.T) {
+	root := fakeCgroupV2Root(t, "cpu memory pids io")
+	manager := newCgroupManagerAt(&CgroupConfig{GroupName: "task", Language: "cpp", PIDsMax: 4}, root)
+	if err := manager.Create(); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	group := filepath.Join(root, JudgeRootGroup, "cpp", "task")
+	files := map[string]string{
+		"memory.current": "1048576\n",
+		"memory.peak":    "4194304\n",
+		"memory.max":     "max\n",
+		"memory.events":  "low 0\nhigh 0\nmax 3\noom 1\noom_kill 1\n",
+		"cpu.stat":       "usage_usec 1500\nuser_usec 1000\nsystem_usec 500\nnr_periods 10\nnr_throttled 2\nthrottled_usec 30\n",
+		"pids.current":   "3\n",
+		"io.stat":        "8:0 rbytes=100 wbytes=200 rios=1 wios=2 dbytes=0 dios=0\n8:16 rbytes=1 wbytes=2 rios=3 wios=4 dbytes=0 dios=0\n",
+	}
+	for file, content := range files {
+		if err := os.WriteFile(filepath.Join(group, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := manager.GetStats()
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+
+	want := CgroupStats{
+		MemoryUsage:     1048576,
+		MemoryMaxUsage:  4194304,
+		MemoryOOMCount:  1,
+		CPUUsageTotal:   1500000,
+		CPUUsageUser:    1000000,
+		CPUUsageSystem:  500000,
+		CPUThrottled:    2,
+		PIDsCurrent:     3,
+		PIDsMax:         4,
+		BlkIOReadBytes:  101,
+		BlkIOWriteBytes: 202,
+		BlkIOReadOps:    4,
+		BlkIOWriteOps:   6,
+	}
+	if *stats != want {
+		t.Fatalf("unexpected stats:\n got %+v\nwant %+v", *stats, want)
+	}
+}
+
+func TestCgroupV2ResetAndReconfigureForReuse(t *testing.T) {
+	root := fakeCgroupV2Root(t, "cpuset cpu io memory pids")
+	manager := newCgroupManagerAt(&CgroupConfig{
+		GroupName:        "pooled",
+		Language:         "cpp",
+		MemoryLimitBytes: 256 << 20,
+		MemorySwapLimit:  256 << 20,
+		CPUQuotaUs:       50000,
+		CPUPeriodUs:      100000,
+		CPUSetCPUs:       "0-1",
+		PIDsMax:          8,
+	}, root)
+	if err := manager.Create(); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	group := filepath.Join(root, JudgeRootGroup, "cpp", "pooled")
+	writeFiles := func(files map[string]string) {
+		t.Helper()
+		for file, content := range files {
+			if err := os.WriteFile(filepath.Join(group, file), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// 第一次执行留下的累计计数
+	writeFiles(map[string]string{
+		"memory.peak":   "4194304\n",
+		"memory.events": "oom 1\n",
+		"cpu.stat":      "usage_usec 1500\nuser_usec 1000\nsystem_usec 500\nnr_throttled 2\n",
+		"io.stat":       "8:0 rbytes=100 wbytes=200 rios=1 wios=2\n",
+	})
+	if err := manager.Reset(); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+
+	// 第二次执行：只限制内存，上一次的CPU配额、cpuset与进程数限制不能残留
+	if err := manager.Reconfigure(&CgroupConfig{
+		GroupName:        "ignored",
+		Language:         "java",
+		MemoryLimitBytes: 64 << 20,
+		MemorySwapLimit:  64 << 20,
+	}); err != nil {
+		t.Fatalf("reconfigure: %v", err)
+	}
+	if manager.config.GroupName != "pooled" || manager.config.Language != "cpp" {
+		t.Fatalf("reconfigure must keep the group path, got %s/%s", manager.config.Language, manager.config.GroupName)
+	}
+	expected := map[string]string{
+		"memory.max":  "67108864",
+		"cpu.max":     "max",
+		"cpuset.cpus": "",
+		"pids.max":    "max",
+	}
+	for file, want := range expected {
+		if got := readCgroupFile(t, filepath.Join(group, file)); got != want {
+			t.Fatalf("%s = %q, want %q", file, got, want)
+		}
+	}
+
+	writeFiles(map[string]string{
+		"memory.peak":   "8192\n",
+		"memory.events": "oom 1\n",
+		"cpu.stat":      "usage_usec 1800\nuser_usec 1200\nsystem_usec 600\nnr_throttled 2\n",
+		"io.stat":       "8:0 rbytes=150 wbytes=200 rios=2 wios=2\n",
+	})
+	stats, err := manager.GetStats()
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if stats.MemoryMaxUsage != 8192 || stats.MemoryOOMCount != 0 || stats.CPUThrottled != 0 {
+		t.Fatalf("unexpected memory/throttle stats after reset: %+v", *stats)
+	}
+	if stats.CPUUsageTotal != 300000 || stats.CPUUsageUser != 200000 || stats.CPUUsageSystem != 100000 {
+		t.Fatalf("cpu usage must exclude the previous run: %+v", *stats)
+	}
+	if stats.BlkIOReadBytes != 50 || stats.BlkIOReadOps != 1 || stats.BlkIOWriteBytes != 0 {
+		t.Fatalf("io stats must exclude the previous run: %+v", *stats)
+	}
+}
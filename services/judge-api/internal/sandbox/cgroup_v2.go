@@ -0,0 +1,405 @@
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// cgroup v2（统一层级）实现
+// 原理：所有控制器挂载在同一棵树上，每个控制组只有一个目录
+// 父组需要通过cgroup.subtree_control向子组开放控制器，且开放了控制器的非根组内不能有进程（no internal processes）
+// 因此judge/{language}只作为中间组，进程只加入最末端的任务组
+
+// 判题需要的v2控制器
+var cgroupV2Controllers = []string{"cpu", "cpuset", "memory", "pids", "io"}
+
+// v2中表示"不限制"的取值
+const cgroupV2Max = "max"
+
+// 创建中间组并逐级开放控制器：root -> judge -> judge/{language}
+func (c *CgroupManager) ensureParentDirectoriesV2() error {
+	judgeDir := filepath.Join(c.parentPath(), JudgeRootGroup)
+	languageDir := filepath.Join(judgeDir, c.config.Language)
+
+	if err := os.MkdirAll(languageDir, 0755); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("failed to create parent directory %s: %w", languageDir, err)
+	}
+
+	for _, dir := range []string{c.parentPath(), judgeDir, languageDir} {
+		if err := c.enableSubtreeControl(dir); err != nil {
+			return fmt.Errorf("failed to enable controllers in %s: %w", dir, err)
+		}
+	}
+
+	return nil
+}
+
+// 在指定组的cgroup.subtree_control中开放判题需要且可用的控制器
+func (c *CgroupManager) enableSubtreeControl(dir string) error {
+	content, err := c.readFile(filepath.Join(dir, "cgroup.controllers"))
+	if err != nil {
+		return fmt.Errorf("failed to read cgroup.controllers: %w", err)
+	}
+
+	available := make(map[string]bool)
+	for _, controller := range strings.Fields(content) {
+		available[controller] = true
+	}
+
+	var enable []string
+	for _, controller := range cgroupV2Controllers {
+		if available[controller] {
+			enable = append(enable, "+"+controller)
+		} else {
+			logx.Errorf("cgroup v2 controller %s not available in %s", controller, dir)
+		}
+	}
+	if len(enable) == 0 {
+		return nil
+	}
+
+	// 一次写入多个控制器，内核按顺序逐个开放
+	if err := c.writeFile(filepath.Join(dir, "cgroup.subtree_control"), strings.Join(enable, " ")); err != nil {
+		return err
+	}
+
+	logx.Debugf("Enabled cgroup v2 controllers in %s: %v", dir, enable)
+	return nil
+}
+
+// 应用v2资源限制配置
+func (c *CgroupManager) applyLimitsV2() error {
+	var errors []error
+
+	if err := c.applyMemoryLimitsV2(); err != nil {
+		errors = append(errors, fmt.Errorf("memory limits: %w", err))
+	}
+
+	if err := c.applyCPULimitsV2(); err != nil {
+		errors = append(errors, fmt.Errorf("CPU limits: %w", err))
+	}
+
+	if err := c.applyPIDsLimits(); err != nil {
+		errors = append(errors, fmt.Errorf("PIDs limits: %w", err))
+	}
+
+	if err := c.applyIOLimitsV2(); err != nil {
+		errors = append(errors, fmt.Errorf("IO limits: %w", err))
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("failed to apply some limits: %v", errors)
+	}
+
+	return nil
+}
+
+// 应用v2内存限制
+func (c *CgroupManager) applyMemoryLimitsV2() error {
+	groupPath := c.groupPaths[CgroupUnified]
+
+	if c.config.MemoryLimitBytes > 0 {
+		if err := c.writeFile(filepath.Join(groupPath, "memory.max"), strconv.FormatInt(c.config.MemoryLimitBytes, 10)); err != nil {
+			return fmt.Errorf("failed to set memory.max: %w", err)
+		}
+		logx.Debugf("Set memory.max: %d bytes", c.config.MemoryLimitBytes)
+	}
+
+	// v1的memsw限制的是内存+swap总量，v2的memory.swap.max只限制swap部分
+	if c.config.MemorySwapLimit > 0 {
+		swap := c.config.MemorySwapLimit - c.config.MemoryLimitBytes
+		if swap < 0 {
+			swap = 0
+		}
+		if err := c.writeFile(filepath.Join(groupPath, "memory.swap.max"), strconv.FormatInt(swap, 10)); err != nil {
+			// 未开启swap记账的内核没有该文件，记录警告但不失败
+			logx.Errorf("Failed to set memory.swap.max (may not be supported): %v", err)
+		} else {
+			logx.Debugf("Set memory.swap.max: %d bytes", swap)
+		}
+	}
+
+	// v2没有关闭OOM killer的接口；OOM时整组终止，避免留下半死的进程
+	if c.config.MemoryOOMKillDisable {
+		logx.Errorf("Disabling OOM kill is not supported on cgroup v2, ignored")
+	}
+	if err := c.writeFile(filepath.Join(groupPath, "memory.oom.group"), "1"); err != nil {
+		logx.Errorf("Failed to set memory.oom.group: %v", err)
+	}
+
+	return nil
+}
+
+// 应用v2 CPU限制
+func (c *CgroupManager) applyCPULimitsV2() error {
+	groupPath := c.groupPaths[CgroupUnified]
+
+	// cpu.max格式: "$MAX $PERIOD"，配额为max表示不限制
+	if c.config.CPUQuotaUs > 0 || c.config.CPUPeriodUs > 0 {
+		quota := cgroupV2Max
+		if c.config.CPUQuotaUs > 0 {
+			quota = strconv.FormatInt(c.config.CPUQuotaUs, 10)
+		}
+		period := c.config.CPUPeriodUs
+		if period <= 0 {
+			period = 100000
+		}
+
+		value := fmt.Sprintf("%s %d", quota, period)
+		if err := c.writeFile(filepath.Join(groupPath, "cpu.max"), value); err != nil {
+			return fmt.Errorf("failed to set cpu.max: %w", err)
+		}
+		logx.Debugf("Set cpu.max: %s", value)
+	}
+
+	// cpu.shares(2-262144)换算为cpu.weight(1-10000)
+	if c.config.CPUShares > 0 {
+		weight := convertCPUSharesToWeight(c.config.CPUShares)
+		if err := c.writeFile(filepath.Join(groupPath, "cpu.weight"), strconv.FormatInt(weight, 10)); err != nil {
+			return fmt.Errorf("failed to set cpu.weight: %w", err)
+		}
+		logx.Debugf("Set cpu.weight: %d", weight)
+	}
+
+	// cpuset文件名与v1相同
+	if c.config.CPUSetCPUs != "" {
+		if err := c.writeFile(filepath.Join(groupPath, "cpuset.cpus"), c.config.CPUSetCPUs); err != nil {
+			return fmt.Errorf("failed to set cpuset.cpus: %w", err)
+		}
+		if err := c.writeFile(filepath.Join(groupPath, "cpuset.mems"), "0"); err != nil {
+			logx.Errorf("Failed to set cpuset.mems: %v", err)
+		}
+		logx.Debugf("Set CPU set: %s", c.config.CPUSetCPUs)
+	}
+
+	return nil
+}
+
+// 应用v2 I/O限制
+func (c *CgroupManager) applyIOLimitsV2() error {
+	groupPath := c.groupPaths[CgroupUnified]
+
+	// blkio.weight(10-1000)换算为io.weight(1-10000)
+	if c.config.BlkIOWeight > 0 {
+		weight := convertBlkIOWeightToIOWeight(c.config.BlkIOWeight)
+		if err := c.writeFile(filepath.Join(groupPath, "io.weight"), fmt.Sprintf("default %d", weight)); err != nil {
+			// io.weight依赖BFQ调度器，不可用时只记录警告
+			logx.Errorf("Failed to set io.weight (may not be supported): %v", err)
+		} else {
+			logx.Debugf("Set io.weight: %d", weight)
+		}
+	}
+
+	// io.max格式: "major:minor rbps=X wbps=Y"
+	if c.config.BlkIODevice == "" {
+		if c.config.BlkIOReadBps > 0 || c.config.BlkIOWriteBps > 0 {
+			logx.Debugf("IO bandwidth limit configured without device, skipped")
+		}
+		return nil
+	}
+
+	var limits []string
+	if c.config.BlkIOReadBps > 0 {
+		limits = append(limits, fmt.Sprintf("rbps=%d", c.config.BlkIOReadBps))
+	}
+	if c.config.BlkIOWriteBps > 0 {
+		limits = append(limits, fmt.Sprintf("wbps=%d", c.config.BlkIOWriteBps))
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+
+	value := c.config.BlkIODevice + " " + strings.Join(limits, " ")
+	if err := c.writeFile(filepath.Join(groupPath, "io.max"), value); err != nil {
+		return fmt.Errorf("failed to set io.max: %w", err)
+	}
+	logx.Debugf("Set io.max: %s", value)
+
+	return nil
+}
+
+// 获取v2统计信息并映射到CgroupStats
+func (c *CgroupManager) getStatsV2(stats *CgroupStats) {
+	groupPath := c.groupPaths[CgroupUnified]
+
+	// 内存统计
+	if usage, err := c.readInt64File(filepath.Join(groupPath, "memory.current")); err == nil {
+		stats.MemoryUsage = usage
+	}
+	// memory.peak需要5.19+内核；复用的控制组通过重置时使用的文件描述符读取
+	if peak, err := c.readMemoryPeakV2(); err == nil {
+		stats.MemoryMaxUsage = peak
+	}
+	if limit, err := c.readLimitFileV2(filepath.Join(groupPath, "memory.max")); err == nil {
+		stats.MemoryLimit = limit
+	}
+	if events, err := c.readKeyValueFile(filepath.Join(groupPath, "memory.events")); err == nil {
+		stats.MemoryOOMCount = events["oom_kill"]
+	} else {
+		logx.Errorf("Failed to get memory events: %v", err)
+	}
+
+	// CPU统计：cpu.stat以微秒为单位，转换为与v1 cpuacct.usage一致的纳秒
+	if cpuStat, err := c.readKeyValueFile(filepath.Join(groupPath, "cpu.stat")); err == nil {
+		stats.CPUUsageTotal = cpuStat["usage_usec"] * 1000
+		stats.CPUUsageUser = cpuStat["user_usec"] * 1000
+		stats.CPUUsageSystem = cpuStat["system_usec"] * 1000
+		stats.CPUThrottled = cpuStat["nr_throttled"]
+	} else {
+		logx.Errorf("Failed to get CPU stats: %v", err)
+	}
+
+	// 进程统计
+	if current, err := c.readInt64File(filepath.Join(groupPath, "pids.current")); err == nil {
+		stats.PIDsCurrent = current
+	}
+	if max, err := c.readLimitFileV2(filepath.Join(groupPath, "pids.max")); err == nil {
+		stats.PIDsMax = max
+	}
+
+	// I/O统计：io.stat每行一个设备，如 "8:0 rbytes=1 wbytes=2 rios=3 wios=4 dbytes=0 dios=0"
+	if content, err := c.readFile(filepath.Join(groupPath, "io.stat")); err == nil {
+		for _, line := range strings.Split(content, "\n") {
+			for _, field := range strings.Fields(line) {
+				key, value, ok := strings.Cut(field, "=")
+				if !ok {
+					continue
+				}
+				n, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					continue
+				}
+				switch key {
+				case "rbytes":
+					stats.BlkIOReadBytes += n
+				case "wbytes":
+					stats.BlkIOWriteBytes += n
+				case "rios":
+					stats.BlkIOReadOps += n
+				case "wios":
+					stats.BlkIOWriteOps += n
+				}
+			}
+		}
+	}
+}
+
+// 读取内存峰值
+func (c *CgroupManager) readMemoryPeakV2() (int64, error) {
+	if c.peakFile == nil {
+		return c.readInt64File(filepath.Join(c.groupPaths[CgroupUnified], "memory.peak"))
+	}
+	buf := make([]byte, 32)
+	n, err := c.peakFile.ReadAt(buf, 0)
+	if n == 0 && err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(buf[:n])), 10, 64)
+}
+
+// 重置内存峰值：6.12+内核中向memory.peak写入任意内容，之后通过同一文件描述符读到的是重置后的峰值
+func (c *CgroupManager) resetMemoryPeakV2() error {
+	if c.peakFile == nil {
+		file, err := os.OpenFile(filepath.Join(c.groupPaths[CgroupUnified], "memory.peak"), os.O_RDWR, 0)
+		if err != nil {
+			return err
+		}
+		c.peakFile = file
+	}
+	_, err := c.peakFile.WriteAt([]byte("reset\n"), 0)
+	return err
+}
+
+// 恢复为不限制；cpuset.cpus写入空值表示使用父组的CPU
+func (c *CgroupManager) clearLimitsV2(previous *CgroupConfig) {
+	groupPath := c.groupPaths[CgroupUnified]
+	c.writeFile(filepath.Join(groupPath, "memory.max"), cgroupV2Max)
+	c.writeFile(filepath.Join(groupPath, "memory.swap.max"), cgroupV2Max)
+	c.writeFile(filepath.Join(groupPath, "cpu.max"), cgroupV2Max)
+	c.writeFile(filepath.Join(groupPath, "pids.max"), cgroupV2Max)
+	if previous.CPUSetCPUs != "" {
+		c.writeFile(filepath.Join(groupPath, "cpuset.cpus"), "")
+	}
+	if previous.BlkIODevice != "" {
+		c.writeFile(filepath.Join(groupPath, "io.max"), previous.BlkIODevice+" rbps=max wbps=max")
+	}
+}
+
+// 终止组内所有进程
+// 优先使用cgroup.kill（5.14+内核），否则逐个向cgroup.procs中的进程发送SIGKILL
+func (c *CgroupManager) killAllV2() {
+	groupPath := c.groupPaths[CgroupUnified]
+
+	if err := c.writeFile(filepath.Join(groupPath, "cgroup.kill"), "1"); err == nil {
+		logx.Debugf("Killed all processes in cgroup via cgroup.kill: %s", groupPath)
+		return
+	}
+
+	content, err := c.readFile(filepath.Join(groupPath, "cgroup.procs"))
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(content, "\n") {
+		if pid, err := strconv.Atoi(strings.TrimSpace(line)); err == nil && pid > 0 {
+			syscall.Kill(pid, syscall.SIGKILL)
+		}
+	}
+}
+
+// 读取v2限制文件，"max"表示不限制，返回0
+func (c *CgroupManager) readLimitFileV2(path string) (int64, error) {
+	content, err := c.readFile(path)
+	if err != nil {
+		return 0, err
+	}
+	if content == cgroupV2Max {
+		return 0, nil
+	}
+	return strconv.ParseInt(content, 10, 64)
+}
+
+// 读取"key value"格式的统计文件（memory.events、cpu.stat等）
+func (c *CgroupManager) readKeyValueFile(path string) (map[string]int64, error) {
+	content, err := c.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]int64)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if value, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+			values[fields[0]] = value
+		}
+	}
+	return values, nil
+}
+
+// cpu.shares换算为cpu.weight，与runc/systemd的换算公式一致
+func convertCPUSharesToWeight(shares int64) int64 {
+	if shares < 2 {
+		shares = 2
+	} else if shares > 262144 {
+		shares = 262144
+	}
+	return 1 + ((shares-2)*9999)/262142
+}
+
+// blkio.weight换算为io.weight，与runc的换算公式一致
+func convertBlkIOWeightToIOWeight(weight int64) int64 {
+	if weight < 10 {
+		weight = 10
+	} else if weight > 1000 {
+		weight = 1000
+	}
+	return 1 + (weight-10)*9999/990
+}
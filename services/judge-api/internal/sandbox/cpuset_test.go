@@ -0,0 +1,44 @@
+package sandbox
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAndFormatCPUList(t *testing.T) {
+	cpus, err := ParseCPUList("4, 0-2,2,7")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if !reflect.DeepEqual(cpus, []int{0, 1, 2, 4, 7}) {
+		t.Fatalf("unexpected cpus %v", cpus)
+	}
+	if got := FormatCPUList([]int{7, 0, 1, 2, 4, 5}); got != "0-2,4-5,7" {
+		t.Fatalf("unexpected format %q", got)
+	}
+	for _, invalid := range []string{"a", "3-1", "-1", "0-2048"} {
+		if _, err := ParseCPUList(invalid); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+// 执行阶段绑定到分配的核心，目标程序的子进程同样受限
+func TestExecuteCPUSetCoresSetsAffinity(t *testing.T) {
+	available, err := AvailableCPUs()
+	if err != nil {
+		t.Fatalf("failed to get available cpus: %v", err)
+	}
+	cpu := FormatCPUList(available[len(available)-1:])
+
+	result, output := runShellInSandboxWith(t, `grep Cpus_allowed_list /proc/self/status`, func(config *SandboxConfig) {
+		config.CPUSetCores = cpu
+	})
+	if result.ExitCode != 0 {
+		t.Fatalf("unexpected exit code %d, stderr %q", result.ExitCode, result.ErrorOutput)
+	}
+	if fields := strings.Fields(output); len(fields) != 2 || fields[1] != cpu {
+		t.Fatalf("expected affinity %s, got %q", cpu, output)
+	}
+}
@@ -0,0 +1,324 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// 沙箱辅助进程
+// 原理：seccomp过滤器只能由进程自己安装，并在execve后继续生效；根文件系统也只能在新的mount namespace内搭建
+// 判题服务以辅助模式重新执行自身（/proc/self/exe），先以init角色成为PID namespace的1号进程（见reaper.go），
+// init再启动执行阶段：从继承的管道读取配置，依次完成加入cgroup、搭建根文件系统（或chroot）、降权、设置rlimit、安装过滤器，最后execve目标程序
+// 相比每次生成并用gcc编译C初始化程序，不依赖gcc/libseccomp，也不会在选手工作目录中留下构建产物
+
+// 辅助模式标记，通过环境变量传递给重新执行的判题服务
+const sandboxHelperEnv = "JUDGE_SANDBOX_HELPER"
+
+// 辅助进程在execve目标程序之前失败时的退出码
+const SandboxHelperFailureCode = 125
+
+// 辅助进程从该文件描述符读取配置（exec.Cmd.ExtraFiles[0]）
+const sandboxHelperConfigFd = 3
+
+// 辅助进程的Go运行时配置：单个P、关闭GC，减少启动时创建的线程，避免触及pids限制
+var sandboxHelperRuntimeEnv = []string{"GOMAXPROCS=1", "GOGC=off"}
+
+// SandboxHelperConfig 传递给辅助进程的配置
+type SandboxHelperConfig struct {
+	Executable string   `json:"executable"` // 目标程序（已解析为路径）
+	Args       []string `json:"args"`       // 完整argv，包括argv[0]
+	Env        []string `json:"env"`        // 目标程序的环境变量
+	Dir        string   `json:"dir"`        // 工作目录（chroot之后的路径）
+
+	SetupLoopback bool          `json:"setup_loopback"`   // 启用新network namespace中的loopback接口
+	Rootfs        *RootfsConfig `json:"rootfs,omitempty"` // 最小根文件系统，为空则不搭建
+	Chroot        string        `json:"chroot"`           // chroot根目录，为空则不切换
+	SetCredential bool          `json:"set_credential"`   // 是否切换到UID/GID
+	UID           int           `json:"uid"`
+	GID           int           `json:"gid"`
+	Rootless      bool          `json:"rootless"` // 不切换UID/GID，rlimit不超过当前硬限制，execve前放弃全部capability
+
+	Rlimits         []SandboxHelperRlimit `json:"rlimits"`            // 在execve前设置的资源限制
+	CPUs            []int                 `json:"cpus,omitempty"`     // 绑定的CPU核心（见cpuset.go），为空时不限制
+	CgroupProcs     []string              `json:"cgroup_procs"`       // 执行阶段启动后首先加入的cgroup.procs文件
+	WallTimeLimitMs int64                 `json:"wall_time_limit_ms"` // init进程执行的墙钟时间限制
+
+	EnableSeccomp   bool             `json:"enable_seccomp"`
+	AllowedSyscalls []int            `json:"allowed_syscalls"`
+	DeniedSyscalls  []int            `json:"denied_syscalls"`
+	SyscallArgRules []SyscallArgRule `json:"syscall_arg_rules"`
+	DefaultAction   uint32           `json:"default_action"`
+	DenyAction      uint32           `json:"deny_action"` // 违规动作，为0时使用过滤器默认的KILL_PROCESS
+}
+
+// SandboxHelperRlimit 单项资源限制
+type SandboxHelperRlimit struct {
+	Resource int    `json:"resource"`
+	Cur      uint64 `json:"cur"`
+	Max      uint64 `json:"max"`
+}
+
+// IsSandboxHelper 判断当前进程是否以沙箱辅助模式启动
+// 需要在main函数最开始（解析命令行参数之前）检查
+func IsSandboxHelper() bool {
+	return os.Getenv(sandboxHelperEnv) != ""
+}
+
+// RunSandboxHelper 辅助模式入口：init角色回收进程树，执行阶段完成隔离设置并执行目标程序，不会返回
+func RunSandboxHelper() {
+	// 过滤器与no_new_privs按线程生效，必须与execve在同一个线程上完成
+	runtime.LockOSThread()
+	// 日志会写入目标程序的标准输出
+	logx.Disable()
+
+	var err error
+	switch os.Getenv(sandboxHelperEnv) {
+	case sandboxHelperRoleInit:
+		err = runSandboxInit(false)
+	case sandboxHelperRoleZygote:
+		err = runSandboxInit(true)
+	default:
+		err = runSandboxHelper()
+	}
+	fmt.Fprintf(os.Stderr, "sandbox helper: %v\n", err)
+	os.Exit(SandboxHelperFailureCode)
+}
+
+func runSandboxHelper() error {
+	configFile := os.NewFile(sandboxHelperConfigFd, "sandbox-config")
+	var config SandboxHelperConfig
+	if err := json.NewDecoder(configFile).Decode(&config); err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	// 配置管道不能泄漏给目标程序
+	configFile.Close()
+
+	// 在fork或execve之前加入cgroup，目标程序及其子进程从第一条指令起就受cgroup限制与统计
+	for _, procsFile := range config.CgroupProcs {
+		if err := os.WriteFile(procsFile, []byte("0"), 0644); err != nil {
+			return fmt.Errorf("failed to join cgroup: %w", err)
+		}
+	}
+
+	// 网络与挂载配置需要root权限，必须在降权之前完成
+	if config.SetupLoopback {
+		if err := setupLoopback(); err != nil {
+			return err
+		}
+	}
+	if config.Rootfs != nil {
+		if err := setupRootfs(config.Rootfs); err != nil {
+			return err
+		}
+	} else if config.Chroot != "" {
+		if err := syscall.Chroot(config.Chroot); err != nil {
+			return fmt.Errorf("failed to chroot: %w", err)
+		}
+	}
+	if config.Dir != "" {
+		if err := syscall.Chdir(config.Dir); err != nil {
+			return fmt.Errorf("failed to chdir: %w", err)
+		}
+	}
+	if !strings.Contains(config.Executable, "/") {
+		path, err := lookPathInEnv(config.Executable, config.Env)
+		if err != nil {
+			return err
+		}
+		config.Executable = path
+	}
+
+	// 先降组再降用户，降用户后将失去修改组的权限
+	if config.SetCredential {
+		if err := syscall.Setgroups([]int{}); err != nil {
+			return fmt.Errorf("failed to setgroups: %w", err)
+		}
+		if err := syscall.Setgid(config.GID); err != nil {
+			return fmt.Errorf("failed to setgid: %w", err)
+		}
+		if err := syscall.Setuid(config.UID); err != nil {
+			return fmt.Errorf("failed to setuid: %w", err)
+		}
+	}
+
+	if len(config.CPUs) > 0 {
+		if err := setCPUAffinity(config.CPUs); err != nil {
+			return err
+		}
+	}
+
+	// 先于rlimit放弃capability：读取cap_last_cap需要分配内存，受限的地址空间内Go运行时可能无法扩展堆
+	// rootless模式下rlimit已按当前硬限制截断，设置时不需要CAP_SYS_RESOURCE
+	if config.Rootless {
+		if err := dropCapabilities(); err != nil {
+			return err
+		}
+	}
+
+	// rlimit在辅助进程中设置：判题服务自身不受影响，Go运行时也无需在受限的地址空间内启动
+	for _, limit := range config.Rlimits {
+		if config.Rootless {
+			limit = clampRlimit(limit)
+		}
+		if err := syscall.Setrlimit(limit.Resource, &syscall.Rlimit{Cur: limit.Cur, Max: limit.Max}); err != nil {
+			return fmt.Errorf("failed to set rlimit %s: %w", rlimitName(limit.Resource), err)
+		}
+	}
+
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, PR_SET_NO_NEW_PRIVS, 1, 0); errno != 0 {
+		return fmt.Errorf("failed to set no_new_privs: %w", errno)
+	}
+
+	if config.EnableSeccomp {
+		filter := NewSeccompFilter(config.AllowedSyscalls, config.DefaultAction)
+		filter.SetDeniedSyscalls(config.DeniedSyscalls)
+		filter.SetArgRules(config.SyscallArgRules)
+		if config.DenyAction != 0 {
+			filter.SetDenyAction(config.DenyAction)
+		}
+		if err := filter.Install(); err != nil {
+			return fmt.Errorf("failed to install seccomp filter: %w", err)
+		}
+	}
+
+	// execve本身必须在白名单中（各语言白名单均包含59）
+	err := syscall.Exec(config.Executable, config.Args, config.Env)
+	return fmt.Errorf("failed to exec %s: %w", config.Executable, err)
+}
+
+// 在目标环境变量的PATH中查找程序（chroot之后调用，查找的是沙箱内的路径）
+func lookPathInEnv(executable string, env []string) (string, error) {
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, "PATH=") {
+			continue
+		}
+		for _, dir := range filepath.SplitList(strings.TrimPrefix(kv, "PATH=")) {
+			path := filepath.Join(dir, executable)
+			if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() && info.Mode()&0111 != 0 {
+				return path, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("executable %s not found in PATH", executable)
+}
+
+// 构建辅助进程配置
+func (s *SystemCallSandbox) buildSandboxHelperConfig(executable string, args []string) (*SandboxHelperConfig, error) {
+	// 与exec.Command一致：不含路径分隔符的程序名按判题服务的PATH解析
+	// chroot场景下主机路径无意义，交给辅助进程在chroot后按目标环境变量解析
+	// 新根中的只读路径与主机路径一致，可以直接按主机路径解析
+	path := executable
+	if !strings.Contains(executable, "/") && s.config.Chroot == "" {
+		resolved, err := exec.LookPath(executable)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve executable: %w", err)
+		}
+		path = resolved
+	}
+
+	allowedSyscalls := s.config.AllowedSyscalls
+	if s.config.EnableSeccomp && len(allowedSyscalls) == 0 {
+		logx.Error("No allowed syscalls configured, using strict mode")
+		allowedSyscalls = GetSyscallWhitelist("")
+	}
+	deniedSyscalls := s.config.DeniedSyscalls
+	if s.config.AllowLocalSockets {
+		// network namespace中只有loopback，放行套接字调用也无法访问外部网络
+		allowedSyscalls, deniedSyscalls = allowLocalSocketSyscalls(allowedSyscalls, deniedSyscalls)
+	}
+
+	var cpus []int
+	if s.config.CPUSetCores != "" {
+		parsed, err := ParseCPUList(s.config.CPUSetCores)
+		if err != nil {
+			return nil, err
+		}
+		cpus = parsed
+	}
+
+	var rootfs *RootfsConfig
+	if s.config.EnableRootfs {
+		rootfs = &RootfsConfig{
+			MountPoint:    s.rootfsDir,
+			ReadOnlyPaths: s.config.ReadOnlyPaths,
+			WritablePaths: s.config.WritablePaths,
+			WorkDir:       s.config.WorkDir,
+		}
+	}
+
+	return &SandboxHelperConfig{
+		Executable:      path,
+		Args:            append([]string{executable}, args...),
+		Env:             s.config.Environment,
+		Dir:             s.config.WorkDir,
+		SetupLoopback:   true,
+		Rootfs:          rootfs,
+		Chroot:          s.config.Chroot,
+		SetCredential:   !s.config.Rootless,
+		UID:             s.config.UID,
+		GID:             s.config.GID,
+		Rootless:        s.config.Rootless,
+		Rlimits:         s.buildRlimits(),
+		CPUs:            cpus,
+		CgroupProcs:     s.cgroupProcsFiles(),
+		WallTimeLimitMs: s.wallTimeLimit(),
+		EnableSeccomp:   s.config.EnableSeccomp,
+		AllowedSyscalls: allowedSyscalls,
+		DeniedSyscalls:  deniedSyscalls,
+		SyscallArgRules: s.config.SyscallArgRules,
+		// 违规时发送SIGSYS而不是直接终止：init跟踪目标程序，从siginfo中读取被拦截的系统调用后终止整棵进程树
+		DefaultAction: SECCOMP_RET_TRAP,
+		DenyAction:    SECCOMP_RET_TRAP,
+	}, nil
+}
+
+// 创建以执行阶段角色重新执行判题服务的命令
+func newSandboxHelperCommand(ctx context.Context, config *SandboxHelperConfig) (*exec.Cmd, error) {
+	return newSandboxHelperCommandAs(ctx, sandboxHelperRoleExec, config)
+}
+
+// 创建以辅助模式重新执行判题服务的命令
+// 配置在启动前写入管道：JSON远小于管道缓冲区，写入不会阻塞
+func newSandboxHelperCommandAs(ctx context.Context, role string, config *SandboxHelperConfig) (*exec.Cmd, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate judge executable: %w", err)
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sandbox helper config: %w", err)
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create config pipe: %w", err)
+	}
+	defer writer.Close()
+
+	if _, err := writer.Write(data); err != nil {
+		reader.Close()
+		return nil, fmt.Errorf("failed to write sandbox helper config: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, self)
+	cmd.Env = append([]string{sandboxHelperEnv + "=" + role}, sandboxHelperRuntimeEnv...)
+	cmd.ExtraFiles = []*os.File{reader}
+	return cmd, nil
+}
+
+// 关闭父进程持有的配置管道读端（子进程已继承）
+func closeSandboxHelperFiles(cmd *exec.Cmd) {
+	for _, file := range cmd.ExtraFiles {
+		file.Close()
+	}
+}
@@ -0,0 +1,83 @@
+package sandbox
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// 网络隔离
+// 原理：每次编译与运行都通过CLONE_NEWNET进入新的network namespace，其中只有一个未启用的loopback接口
+// 辅助进程在降权前启用loopback，沙箱内没有任何通往主机或外部网络的接口和路由，
+// 即使seccomp关闭（如Python）或放行了套接字调用，对外connect也只会得到ENETUNREACH
+
+// 套接字相关系统调用，AllowLocalSockets开启时放行
+var localSocketSyscalls = []int{
+	41,  // socket
+	42,  // connect
+	43,  // accept
+	44,  // sendto
+	45,  // recvfrom
+	46,  // sendmsg
+	47,  // recvmsg
+	48,  // shutdown
+	49,  // bind
+	50,  // listen
+	51,  // getsockname
+	52,  // getpeername
+	53,  // socketpair
+	54,  // setsockopt
+	55,  // getsockopt
+	288, // accept4
+}
+
+// struct ifreq中用于读写接口标志的部分（总长度40字节）
+type ifreqFlags struct {
+	Name  [syscall.IFNAMSIZ]byte
+	Flags uint16
+	_     [22]byte
+}
+
+// 启用当前network namespace中的loopback接口（需要CAP_NET_ADMIN）
+func setupLoopback() error {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("failed to create control socket: %w", err)
+	}
+	defer syscall.Close(fd)
+
+	var req ifreqFlags
+	copy(req.Name[:], "lo")
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.SIOCGIFFLAGS, uintptr(unsafe.Pointer(&req))); errno != 0 {
+		return fmt.Errorf("failed to get loopback flags: %w", errno)
+	}
+	req.Flags |= syscall.IFF_UP
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.SIOCSIFFLAGS, uintptr(unsafe.Pointer(&req))); errno != 0 {
+		return fmt.Errorf("failed to bring up loopback: %w", errno)
+	}
+	return nil
+}
+
+// 将套接字调用加入白名单并从黑名单中移除，返回新的列表（不修改传入的切片）
+func allowLocalSocketSyscalls(allowed, denied []int) ([]int, []int) {
+	socketSet := make(map[int]bool, len(localSocketSyscalls))
+	for _, nr := range localSocketSyscalls {
+		socketSet[nr] = true
+	}
+
+	newAllowed := make([]int, 0, len(allowed)+len(localSocketSyscalls))
+	for _, nr := range allowed {
+		if !socketSet[nr] {
+			newAllowed = append(newAllowed, nr)
+		}
+	}
+	newAllowed = append(newAllowed, localSocketSyscalls...)
+
+	newDenied := make([]int, 0, len(denied))
+	for _, nr := range denied {
+		if !socketSet[nr] {
+			newDenied = append(newDenied, nr)
+		}
+	}
+	return newAllowed, newDenied
+}
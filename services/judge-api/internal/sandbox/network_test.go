@@ -0,0 +1,154 @@
+package sandbox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 探测网络环境：接口列表、对外连接与loopback上的本地连接
+const networkProbeProgram = `package main
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+func main() {
+	ifaces, _ := net.Interfaces()
+	for _, iface := range ifaces {
+		fmt.Println("iface", iface.Name, iface.Flags&net.FlagUp != 0)
+	}
+
+	if conn, err := net.DialTimeout("tcp", "1.1.1.1:80", time.Second); err != nil {
+		fmt.Println("outbound blocked")
+	} else {
+		conn.Close()
+		fmt.Println("outbound connected")
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fmt.Println("loopback", err)
+		return
+	}
+	defer ln.Close()
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			conn.Write([]byte("pong"))
+			conn.Close()
+		}
+	}()
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		fmt.Println("loopback", err)
+		return
+	}
+	buf := make([]byte, 4)
+	n, _ := conn.Read(buf)
+	fmt.Println("loopback", string(buf[:n]))
+}
+`
+
+func TestExecuteIsolatesNetwork(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("sandbox execution requires root")
+	}
+
+	binary := buildGoProgram(t, networkProbeProgram)
+
+	tests := []struct {
+		name          string
+		enableSeccomp bool
+	}{
+		// Python等关闭seccomp的语言只依赖network namespace隔离
+		{name: "seccomp disabled", enableSeccomp: false},
+		{name: "seccomp with local sockets", enableSeccomp: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// nobody用户需要能够进入工作目录并执行程序
+			workDir, err := os.MkdirTemp("", "network-execute-")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(workDir)
+			if err := os.Chmod(workDir, 0777); err != nil {
+				t.Fatal(err)
+			}
+			program := filepath.Join(workDir, "main")
+			data, err := os.ReadFile(binary)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(program, data, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			config := &SandboxConfig{
+				UID:                   65534,
+				GID:                   65534,
+				WorkDir:               workDir,
+				TimeLimit:             2000,
+				WallTimeLimit:         5000,
+				MemoryLimit:           65536,
+				StackLimit:            8192,
+				FileSizeLimit:         1024,
+				ProcessLimit:          16,
+				SkipAddressSpaceLimit: true,
+				EnableSeccomp:         tt.enableSeccomp,
+				AllowLocalSockets:     tt.enableSeccomp,
+				AllowedSyscalls:       GetSyscallWhitelist("go"),
+				DeniedSyscalls:        GetSyscallDenylist("go"),
+				OutputFile:            filepath.Join(workDir, "output.txt"),
+				ErrorFile:             filepath.Join(workDir, "error.txt"),
+				Environment:           []string{"PATH=/usr/bin:/bin", "GOMAXPROCS=1"},
+			}
+
+			result, err := NewSystemCallSandbox(config).Execute(context.Background(), program, nil)
+			if err != nil {
+				t.Fatalf("execute: %v", err)
+			}
+			if result.Status != StatusAccepted || result.ExitCode != 0 {
+				t.Fatalf("unexpected result: status=%d exit=%d signal=%d stderr=%q", result.Status, result.ExitCode, result.Signal, result.ErrorOutput)
+			}
+
+			output, err := os.ReadFile(config.OutputFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := "iface lo true\noutbound blocked\nloopback pong"
+			if got := strings.TrimSpace(string(output)); got != want {
+				t.Fatalf("unexpected network view:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestAllowLocalSocketSyscalls(t *testing.T) {
+	allowed, denied := allowLocalSocketSyscalls([]int{0, 1, 41}, GetSyscallDenylist("python"))
+
+	count := 0
+	for _, nr := range allowed {
+		if nr == 41 {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Fatalf("socket should appear exactly once in the whitelist, got %d", count)
+	}
+	for _, nr := range denied {
+		for _, socketNr := range localSocketSyscalls {
+			if nr == socketNr {
+				t.Fatalf("syscall %d should be removed from the denylist", nr)
+			}
+		}
+	}
+	if len(denied) != len(GetSyscallDenylist("python"))-7 {
+		t.Fatalf("only socket syscalls should be removed from the denylist, got %v", denied)
+	}
+}
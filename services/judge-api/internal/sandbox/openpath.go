@@ -0,0 +1,318 @@
+package sandbox
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// 工作目录之外只读的路径检查
+// 原理：BPF只能读取寄存器中的参数，看不到open/openat的路径。open_read_only规则让写方式的打开（写访问、O_CREAT、O_TRUNC）
+// 返回SECCOMP_RET_TRACE，跟踪目标程序的init在PTRACE_EVENT_SECCOMP停止时从目标程序的内存读取路径，
+// 按目标程序的视角解析（从/proc/PID/root、cwd或目录fd开始，符号链接与..都不会越过它的根目录），
+// 写入位置在工作目录之内才放行，否则跳过系统调用并返回EACCES；只读打开不产生停止，没有额外开销
+// 检查与内核执行系统调用之间，目标程序的其他线程可以改写内存中的路径，工作目录之外的写入最终仍由根文件系统的只读挂载阻止（见rootfs.go）
+
+// 路径检查用到的常量（syscall包未定义）
+const (
+	O_PATH   = 0x200000 // 只用于定位文件的描述符，不检查读写权限
+	AT_FDCWD = -100     // openat的dirfd：相对于当前工作目录
+
+	pathMax     = 4096 // PATH_MAX，包括结尾的NUL
+	symlinksMax = 40   // 解析一个路径最多跟随的符号链接数，与内核的MAXSYMLINKS一致
+)
+
+// 检查写方式打开的路径，不在工作目录之内时跳过系统调用并返回EACCES
+func (t *sandboxTracer) checkOpenPath(pid int) {
+	var regs syscall.PtraceRegs
+	if err := syscall.PtraceGetRegs(pid, &regs); err != nil {
+		// ESRCH：进程已被杀死
+		return
+	}
+	if t.openAllowed(pid, &regs) {
+		return
+	}
+	// 系统调用号为-1时内核跳过系统调用，rax即为返回值
+	errno := -int64(EACCES)
+	regs.Orig_rax = ^uint64(0)
+	regs.Rax = uint64(errno)
+	syscall.PtraceSetRegs(pid, &regs)
+}
+
+// 判断被拦截的打开是否允许执行
+func (t *sandboxTracer) openAllowed(pid int, regs *syscall.PtraceRegs) bool {
+	if t.workDir == "" {
+		return false
+	}
+
+	dirfd, pathAddr := AT_FDCWD, regs.Rdi
+	switch regs.Orig_rax {
+	case SYS_OPEN:
+	case SYS_OPENAT:
+		dirfd, pathAddr = int(int32(regs.Rdi)), regs.Rsi
+	default:
+		return false
+	}
+
+	path, err := readTraceeString(pid, uintptr(pathAddr))
+	if err != nil {
+		return false
+	}
+	return traceePathInDir(pid, dirfd, path, t.workDir)
+}
+
+// 从目标程序的内存中读取以NUL结尾的字符串
+func readTraceeString(pid int, addr uintptr) (string, error) {
+	mem, err := os.Open(fmt.Sprintf("/proc/%d/mem", pid))
+	if err != nil {
+		return "", err
+	}
+	defer mem.Close()
+
+	pageSize := uintptr(os.Getpagesize())
+	var path []byte
+	chunk := make([]byte, pageSize)
+	for len(path) < pathMax {
+		// 按页读取，字符串之后的页可能没有映射
+		n := int(pageSize - addr%pageSize)
+		read, err := mem.ReadAt(chunk[:n], int64(addr))
+		if err != nil {
+			return "", err
+		}
+		if end := bytes.IndexByte(chunk[:read], 0); end >= 0 {
+			return string(append(path, chunk[:end]...)), nil
+		}
+		path = append(path, chunk[:read]...)
+		addr += uintptr(read)
+	}
+	return "", syscall.ENAMETOOLONG
+}
+
+// 按目标程序的视角判断以写方式打开的路径是否位于目录dir之内
+func traceePathInDir(pid, dirfd int, path, dir string) bool {
+	root, err := openPathFd(AT_FDCWD, fmt.Sprintf("/proc/%d/root", pid), syscall.O_DIRECTORY)
+	if err != nil {
+		return false
+	}
+	defer syscall.Close(root)
+
+	start := root
+	if !strings.HasPrefix(path, "/") {
+		startPath := fmt.Sprintf("/proc/%d/cwd", pid)
+		if dirfd != AT_FDCWD {
+			startPath = fmt.Sprintf("/proc/%d/fd/%d", pid, dirfd)
+		}
+		start, err = openPathFd(AT_FDCWD, startPath, syscall.O_DIRECTORY)
+		if err != nil {
+			return false
+		}
+		defer syscall.Close(start)
+	}
+	return pathInDir(root, start, path, dir)
+}
+
+// 以root为根目录、从start开始解析path，判断写入的位置是否为dir（同样以root为根解析）或位于其中
+// 新建文件的位置是所在的目录；已存在的目录（如O_TMPFILE）是它本身；最后一级是符号链接时按不允许处理
+func pathInDir(root, start int, path, dir string) bool {
+	target, err := resolveWriteTarget(root, start, path)
+	if err != nil {
+		return false
+	}
+	defer syscall.Close(target)
+
+	dirFd, err := resolveDir(root, root, dir)
+	if err != nil {
+		return false
+	}
+	defer syscall.Close(dirFd)
+
+	var dirStat syscall.Stat_t
+	if err := syscall.Fstat(dirFd, &dirStat); err != nil {
+		return false
+	}
+	return dirWithin(root, target, &dirStat)
+}
+
+// 解析写入位置所在的目录
+func resolveWriteTarget(root, start int, path string) (int, error) {
+	trimmed := strings.TrimRight(path, "/")
+	slash := strings.LastIndex(trimmed, "/")
+	name := trimmed[slash+1:]
+	if name == "" || name == "." || name == ".." {
+		return resolveDir(root, start, path)
+	}
+
+	parent, err := resolveDir(root, start, trimmed[:slash+1])
+	if err != nil {
+		return -1, err
+	}
+	fd, err := openPathFd(parent, name, syscall.O_NOFOLLOW)
+	if err == syscall.ENOENT {
+		return parent, nil
+	}
+	if err != nil {
+		syscall.Close(parent)
+		return -1, err
+	}
+
+	var stat syscall.Stat_t
+	if err := syscall.Fstat(fd, &stat); err != nil {
+		syscall.Close(fd)
+		syscall.Close(parent)
+		return -1, err
+	}
+	switch stat.Mode & syscall.S_IFMT {
+	case syscall.S_IFLNK:
+		// 不跟随符号链接写入，避免工作目录中的链接指向外部
+		syscall.Close(fd)
+		syscall.Close(parent)
+		return -1, syscall.ELOOP
+	case syscall.S_IFDIR:
+		syscall.Close(parent)
+		return fd, nil
+	}
+	syscall.Close(fd)
+	return parent, nil
+}
+
+// 以root为根目录、从start开始逐级解析目录路径，返回目录的O_PATH描述符
+// 与内核一致地跟随符号链接（绝对路径的链接从root开始），..在root处停止
+func resolveDir(root, start int, path string) (int, error) {
+	if strings.HasPrefix(path, "/") {
+		start = root
+	}
+	current, err := syscall.Dup(start)
+	if err != nil {
+		return -1, err
+	}
+	// 出错时关闭当前目录
+	fail := func(err error) (int, error) {
+		syscall.Close(current)
+		return -1, err
+	}
+
+	components := strings.Split(path, "/")
+	links := 0
+	for len(components) > 0 {
+		name := components[0]
+		components = components[1:]
+		if name == "" || name == "." {
+			continue
+		}
+		if name == ".." {
+			if sameFile(current, root) {
+				continue
+			}
+			parent, err := openPathFd(current, "..", syscall.O_DIRECTORY)
+			if err != nil {
+				return fail(err)
+			}
+			syscall.Close(current)
+			current = parent
+			continue
+		}
+
+		next, err := openPathFd(current, name, syscall.O_NOFOLLOW)
+		if err != nil {
+			return fail(err)
+		}
+		var stat syscall.Stat_t
+		if err := syscall.Fstat(next, &stat); err != nil {
+			syscall.Close(next)
+			return fail(err)
+		}
+		switch stat.Mode & syscall.S_IFMT {
+		case syscall.S_IFDIR:
+			syscall.Close(current)
+			current = next
+		case syscall.S_IFLNK:
+			links++
+			link, err := readlinkFd(next)
+			syscall.Close(next)
+			if err != nil {
+				return fail(err)
+			}
+			if links > symlinksMax {
+				return fail(syscall.ELOOP)
+			}
+			if strings.HasPrefix(link, "/") {
+				syscall.Close(current)
+				if current, err = syscall.Dup(root); err != nil {
+					return -1, err
+				}
+			}
+			components = append(strings.Split(link, "/"), components...)
+		default:
+			syscall.Close(next)
+			return fail(syscall.ENOTDIR)
+		}
+	}
+	return current, nil
+}
+
+// 判断目录target是否为dir本身或位于其中：沿..向上查找，到达root或文件系统的顶层时停止
+func dirWithin(root, target int, dir *syscall.Stat_t) bool {
+	current, err := syscall.Dup(target)
+	if err != nil {
+		return false
+	}
+	defer func() { syscall.Close(current) }()
+
+	for depth := 0; depth < pathMax; depth++ {
+		var stat syscall.Stat_t
+		if err := syscall.Fstat(current, &stat); err != nil {
+			return false
+		}
+		if stat.Dev == dir.Dev && stat.Ino == dir.Ino {
+			return true
+		}
+		if sameFile(current, root) {
+			return false
+		}
+		parent, err := openPathFd(current, "..", syscall.O_DIRECTORY)
+		if err != nil {
+			return false
+		}
+		if sameFile(parent, current) {
+			syscall.Close(parent)
+			return false
+		}
+		syscall.Close(current)
+		current = parent
+	}
+	return false
+}
+
+// 以O_PATH打开文件
+func openPathFd(dirfd int, path string, flags int) (int, error) {
+	for {
+		fd, err := syscall.Openat(dirfd, path, O_PATH|syscall.O_CLOEXEC|flags, 0)
+		if err != syscall.EINTR {
+			return fd, err
+		}
+	}
+}
+
+// 读取O_PATH描述符指向的符号链接
+func readlinkFd(fd int) (string, error) {
+	empty := []byte{0}
+	buf := make([]byte, pathMax)
+	n, _, errno := syscall.Syscall6(syscall.SYS_READLINKAT, uintptr(fd), uintptr(unsafe.Pointer(&empty[0])),
+		uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)), 0, 0)
+	if errno != 0 {
+		return "", errno
+	}
+	return string(buf[:n]), nil
+}
+
+// 判断两个描述符是否指向同一个文件
+func sameFile(a, b int) bool {
+	var statA, statB syscall.Stat_t
+	if syscall.Fstat(a, &statA) != nil || syscall.Fstat(b, &statB) != nil {
+		return false
+	}
+	return statA.Dev == statB.Dev && statA.Ino == statB.Ino
+}
@@ -0,0 +1,69 @@
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// 以临时目录作为目标程序的根目录解析路径：..不越过根目录，绝对路径的符号链接从根目录开始
+func TestPathInDir(t *testing.T) {
+	rootDir := t.TempDir()
+	for _, dir := range []string{"work/sub", "other"} {
+		if err := os.MkdirAll(filepath.Join(rootDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"work/out":     "/other",     // 指向根目录中的other，而不是主机的/other
+		"work/inside":  "/work/sub",  // 指向工作目录之内
+		"work/file":    "/other/x",   // 最后一级是符号链接
+		"other/escape": "../../work", // ..在根目录处停止，指向工作目录
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, filepath.Join(rootDir, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(rootDir, "work/existing.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := openPathFd(AT_FDCWD, rootDir, syscall.O_DIRECTORY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(root)
+	cwd, err := openPathFd(root, "work/sub", syscall.O_DIRECTORY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(cwd)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/work/new.txt", true},
+		{"/work/existing.txt", true},
+		{"new.txt", true},
+		{"../new.txt", true},
+		{"../../new.txt", false},
+		{"/../../work/new.txt", true},
+		{"/work", true},
+		{"/work/sub/", true},
+		{"/other/new.txt", false},
+		{"/work/out/new.txt", false},
+		{"/work/inside/new.txt", true},
+		{"/work/file", false},
+		{"/other/escape/new.txt", true},
+		{"/missing/new.txt", false},
+		{"/work/existing.txt/new.txt", false},
+	}
+	for _, tt := range tests {
+		if got := pathInDir(root, cwd, tt.path, "/work"); got != tt.want {
+			t.Errorf("pathInDir(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// 输出限制
+// 原理：标准输出与标准错误连接到判题服务持有的管道，由判题服务读取、计数后写入输出文件
+// 任一流的字节数超过OutputLimit时立即杀死init（内核随之杀死整个进程树），判定为输出超限；
+// 不再依赖RLIMIT_FSIZE（对管道无效，且SIGXFSZ容易与其他运行错误混淆）
+// 标准错误只保留前errorOutputPrefixSize字节用于展示，其余内容计数后丢弃
+
+// 标准错误保留用于展示的最大字节数
+const errorOutputPrefixSize = 64 * 1024
+
+// 被监控的输出流
+type outputStream struct {
+	reader  *os.File // 判题服务读取的管道读端
+	writer  *os.File // 交给目标程序的管道写端，启动后父进程持有的副本需要关闭
+	file    *os.File // 输出文件
+	keep    int64    // 写入文件的最大字节数，0表示不限制
+	written int64    // 目标程序写出的总字节数
+	broken  bool     // 写入文件失败后只计数不再写入
+}
+
+// 标准输出与标准错误的监控
+type outputCapture struct {
+	limit    int64 // 每个流的字节数上限，0表示不限制
+	stdout   *outputStream
+	stderr   *outputStream
+	exceeded atomic.Bool
+	kill     sync.Once
+	wg       sync.WaitGroup
+}
+
+// 为输出文件创建管道，path为空时返回nil（输出被丢弃）
+func newOutputStream(path string, keep int64) (*outputStream, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to create output pipe: %w", err)
+	}
+	return &outputStream{reader: reader, writer: writer, file: file, keep: keep}, nil
+}
+
+// 创建输出监控
+func newOutputCapture(outputFile, errorFile string, limit int64) (*outputCapture, error) {
+	capture := &outputCapture{limit: limit}
+
+	var err error
+	if capture.stdout, err = newOutputStream(outputFile, limit); err != nil {
+		return nil, err
+	}
+
+	errorKeep := int64(errorOutputPrefixSize)
+	if limit > 0 && limit < errorKeep {
+		errorKeep = limit
+	}
+	if capture.stderr, err = newOutputStream(errorFile, errorKeep); err != nil {
+		capture.close()
+		return nil, err
+	}
+	return capture, nil
+}
+
+// 开始读取输出，超限时调用onExceeded终止进程树
+func (c *outputCapture) start(onExceeded func()) {
+	for _, stream := range []*outputStream{c.stdout, c.stderr} {
+		if stream == nil {
+			continue
+		}
+		// 写端已由子进程继承，关闭父进程的副本后，进程树全部退出时读端才能读到EOF
+		stream.writer.Close()
+		stream.writer = nil
+
+		c.wg.Add(1)
+		go func(stream *outputStream) {
+			defer c.wg.Done()
+			c.drain(stream, onExceeded)
+		}(stream)
+	}
+}
+
+// 持续读取管道直到EOF，超限后继续读取并丢弃，避免写端阻塞
+func (c *outputCapture) drain(stream *outputStream, onExceeded func()) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := stream.reader.Read(buf)
+		if n > 0 {
+			if !stream.broken && (stream.keep <= 0 || stream.written < stream.keep) {
+				chunk := buf[:n]
+				if stream.keep > 0 && stream.written+int64(n) > stream.keep {
+					chunk = chunk[:stream.keep-stream.written]
+				}
+				if _, err := stream.file.Write(chunk); err != nil {
+					logx.Errorf("Failed to write %s: %v", stream.file.Name(), err)
+					stream.broken = true
+				}
+			}
+			stream.written += int64(n)
+
+			if c.limit > 0 && stream.written > c.limit {
+				c.exceeded.Store(true)
+				c.kill.Do(onExceeded)
+			}
+		}
+		if err != nil {
+			// EOF：进程树已全部退出
+			return
+		}
+	}
+}
+
+// 等待所有输出读取完毕（进程树退出后管道写端全部关闭）
+func (c *outputCapture) wait() {
+	c.wg.Wait()
+}
+
+// 标准输出的总字节数
+func (c *outputCapture) outputSize() int64 {
+	if c.stdout == nil {
+		return 0
+	}
+	return c.stdout.written
+}
+
+// 是否有输出流超过限制
+func (c *outputCapture) limitExceeded() bool {
+	return c.exceeded.Load()
+}
+
+// 关闭管道与输出文件
+func (c *outputCapture) close() {
+	for _, stream := range []*outputStream{c.stdout, c.stderr} {
+		if stream == nil {
+			continue
+		}
+		if stream.writer != nil {
+			stream.writer.Close()
+		}
+		stream.reader.Close()
+		stream.file.Close()
+	}
+}
@@ -0,0 +1,100 @@
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestExecuteEnforcesOutputLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		script     string
+		wantStatus int
+		wantOutput int64 // 输出文件的大小
+		wantError  int64 // 错误文件的大小上限
+	}{
+		{name: "within limit", script: "printf 'hello\n'", wantStatus: StatusAccepted, wantOutput: 6},
+		// 标准输出持续写入，超过限制后立即终止而不是等到超时
+		{name: "stdout flood", script: "yes", wantStatus: StatusOutputLimitExceeded, wantOutput: 1 << 20},
+		// 标准错误同样受限，且只保留用于展示的前缀
+		{name: "stderr flood", script: "yes >&2", wantStatus: StatusOutputLimitExceeded, wantError: errorOutputPrefixSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outputFile, errorFile string
+			start := time.Now()
+			result, _ := runShellInSandboxWith(t, tt.script, func(config *SandboxConfig) {
+				config.OutputLimit = 1 << 20
+				outputFile, errorFile = config.OutputFile, config.ErrorFile
+			})
+
+			if result.Status != tt.wantStatus {
+				t.Fatalf("unexpected result: status=%d limit=%s signal=%d", result.Status, result.ResourceUsage.LimitExceeded, result.Signal)
+			}
+			if tt.wantStatus == StatusOutputLimitExceeded && result.ResourceUsage.LimitExceeded != "output" {
+				t.Fatalf("output overflow should be reported as output limit, got %s", result.ResourceUsage.LimitExceeded)
+			}
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Fatalf("output flood was not stopped promptly: %v", elapsed)
+			}
+
+			output, err := os.Stat(outputFile)
+			if err != nil || output.Size() != tt.wantOutput {
+				t.Fatalf("output file should hold %d bytes, got %v %v", tt.wantOutput, output, err)
+			}
+			if tt.wantStatus == StatusAccepted && result.OutputSize != tt.wantOutput {
+				t.Fatalf("unexpected output size %d", result.OutputSize)
+			}
+			errorOutput, err := os.Stat(errorFile)
+			if err != nil || errorOutput.Size() > tt.wantError || int64(len(result.ErrorOutput)) != errorOutput.Size() {
+				t.Fatalf("error output should be bounded by %d bytes, got %v %v", tt.wantError, errorOutput, err)
+			}
+		})
+	}
+}
+
+func TestOutputCaptureKeepsPrefix(t *testing.T) {
+	dir := t.TempDir()
+	capture, err := newOutputCapture(filepath.Join(dir, "output.txt"), filepath.Join(dir, "error.txt"), 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer capture.close()
+
+	// start会关闭父进程持有的写端，这里复制一份模拟目标程序继承的描述符
+	var writers []*os.File
+	for _, stream := range []*outputStream{capture.stdout, capture.stderr} {
+		fd, err := syscall.Dup(int(stream.writer.Fd()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		writers = append(writers, os.NewFile(uintptr(fd), "writer"))
+	}
+
+	kills := 0
+	capture.start(func() { kills++ })
+	writers[0].Write([]byte("0123456789"))
+	writers[0].Write([]byte("abc"))
+	writers[1].Write([]byte("err"))
+	for _, writer := range writers {
+		writer.Close()
+	}
+	capture.wait()
+
+	if !capture.limitExceeded() || kills != 1 {
+		t.Fatalf("overflow should trigger the kill callback once, got %d", kills)
+	}
+	if capture.outputSize() != 13 {
+		t.Fatalf("all written bytes should be counted, got %d", capture.outputSize())
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "output.txt")); string(content) != "01234567" {
+		t.Fatalf("only the prefix within the limit should be kept, got %q", content)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "error.txt")); string(content) != "err" {
+		t.Fatalf("unexpected error output %q", content)
+	}
+}
@@ -0,0 +1,420 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// 预热的沙箱资源池
+// 原理：每次执行都要重新执行判题服务作为init（启动Go运行时）、创建PID/网络/挂载namespace并创建cgroup，
+// 对毫秒级的测试点而言，这部分开销远大于目标程序本身的运行时间
+// 资源池在后台预先启动init（zygote角色）：它已是新PID namespace的1号进程，阻塞在套接字上等待执行配置；
+// 执行时判题服务通过SCM_RIGHTS把配置与标准输入、标准输出、标准错误一并交给它，此后的流程与新启动的init完全相同
+// init退出后PID namespace随之销毁，因此每个zygote只使用一次，取出后由后台协程立即补充
+// 控制组可以复用：执行结束后终止残留进程、重置内存峰值并记录累计计数的基线（见CgroupManager.Reset），再放回池中
+
+// 执行配置消息的最大长度（SOCK_SEQPACKET保留消息边界，超长会被截断）
+const sandboxZygoteMaxMessage = 256 * 1024
+
+// zygote创建的namespace，与未开启额外隔离时的buildCloneFlags一致
+const sandboxZygoteCloneFlags = syscall.CLONE_NEWPID | syscall.CLONE_NEWNET | syscall.CLONE_NEWNS
+
+// 资源池控制组所在的分组（JudgeRootGroup/pool/<组名>）
+const sandboxPoolCgroupGroup = "pool"
+
+// SandboxPoolConfig 资源池配置
+type SandboxPoolConfig struct {
+	Name    string // 资源池名称，用于控制组命名（如worker_0）
+	Zygotes int    // 预先启动的init进程数
+	Cgroups int    // 预先创建的控制组数，仅在沙箱启用cgroups时使用
+
+	Rootless     bool   // init创建在新的user namespace中（见rootless.go），只交给同为rootless的沙箱
+	CgroupParent string // 控制组所在的cgroup v2目录，与沙箱的CgroupParent一致
+}
+
+// SandboxPoolStats 资源池命中统计
+type SandboxPoolStats struct {
+	ZygoteHits   int64 // 使用预先启动的init的执行次数
+	ZygoteMisses int64 // 池为空或交付失败、临时启动init的执行次数
+	CgroupHits   int64 // 使用池中控制组的执行次数
+	CgroupMisses int64 // 池为空、临时创建控制组的执行次数
+}
+
+// SandboxPool 预热的沙箱资源池，每个判题工作器持有一个
+type SandboxPool struct {
+	config  SandboxPoolConfig
+	zygotes chan *sandboxZygote
+	cgroups chan *CgroupManager
+	refill  chan struct{} // 通知后台协程补充
+	done    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+
+	cgroupsWanted atomic.Bool // 有沙箱启用cgroups后才预先创建控制组
+	cgroupSeq     int         // 控制组序号，仅由后台协程访问
+	cgroupsBroken bool        // 控制组创建失败后不再尝试，仅由后台协程访问
+	zygoteHits    atomic.Int64
+	zygoteMisses  atomic.Int64
+	cgroupHits    atomic.Int64
+	cgroupMisses  atomic.Int64
+}
+
+// 预先启动的init进程
+type sandboxZygote struct {
+	cmd    *exec.Cmd
+	conn   *os.File // 交付执行配置的套接字（判题服务一端）
+	report *os.File // init上报结果的管道读端
+}
+
+// NewSandboxPool 创建资源池并在后台开始预热
+func NewSandboxPool(config SandboxPoolConfig) *SandboxPool {
+	if config.Zygotes < 0 {
+		config.Zygotes = 0
+	}
+	if config.Cgroups < 0 {
+		config.Cgroups = 0
+	}
+
+	pool := &SandboxPool{
+		config:  config,
+		zygotes: make(chan *sandboxZygote, config.Zygotes),
+		cgroups: make(chan *CgroupManager, config.Cgroups),
+		refill:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	pool.wg.Add(1)
+	go pool.run()
+
+	logx.Infof("Created sandbox pool %s: zygotes=%d, cgroups=%d", config.Name, config.Zygotes, config.Cgroups)
+	return pool
+}
+
+// Stats 返回资源池的命中统计
+func (p *SandboxPool) Stats() SandboxPoolStats {
+	return SandboxPoolStats{
+		ZygoteHits:   p.zygoteHits.Load(),
+		ZygoteMisses: p.zygoteMisses.Load(),
+		CgroupHits:   p.cgroupHits.Load(),
+		CgroupMisses: p.cgroupMisses.Load(),
+	}
+}
+
+// Close 停止预热，终止空闲的init并删除池中的控制组
+// 调用前应确保没有正在进行的执行
+func (p *SandboxPool) Close() {
+	p.once.Do(func() {
+		close(p.done)
+		p.wg.Wait()
+
+		for {
+			select {
+			case zygote := <-p.zygotes:
+				zygote.discard()
+			case manager := <-p.cgroups:
+				if err := manager.Cleanup(); err != nil {
+					logx.Errorf("Failed to cleanup pooled cgroup %s: %v", manager.config.GroupName, err)
+				}
+			default:
+				stats := p.Stats()
+				logx.Infof("Closed sandbox pool %s: %+v", p.config.Name, stats)
+				return
+			}
+		}
+	})
+}
+
+// 后台补充协程：池未满时启动init、创建控制组，之后等待下一次取用
+func (p *SandboxPool) run() {
+	defer p.wg.Done()
+
+	for {
+		p.fillZygotes()
+		p.fillCgroups()
+
+		select {
+		case <-p.refill:
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// 通知后台协程补充，不阻塞
+func (p *SandboxPool) signalRefill() {
+	select {
+	case p.refill <- struct{}{}:
+	default:
+	}
+}
+
+func (p *SandboxPool) fillZygotes() {
+	for len(p.zygotes) < cap(p.zygotes) {
+		select {
+		case <-p.done:
+			return
+		default:
+		}
+
+		zygote, err := startSandboxZygote(p.config.Rootless)
+		if err != nil {
+			// 下一次取用时再重试
+			logx.Errorf("Failed to start pooled sandbox init: %v", err)
+			return
+		}
+		p.zygotes <- zygote
+	}
+}
+
+func (p *SandboxPool) fillCgroups() {
+	if !p.cgroupsWanted.Load() {
+		return
+	}
+	for !p.cgroupsBroken && len(p.cgroups) < cap(p.cgroups) {
+		select {
+		case <-p.done:
+			return
+		default:
+		}
+
+		p.cgroupSeq++
+		manager := NewCgroupManager(&CgroupConfig{
+			GroupName:  fmt.Sprintf("%s_%d", p.config.Name, p.cgroupSeq),
+			Language:   sandboxPoolCgroupGroup,
+			ParentPath: p.config.CgroupParent,
+		})
+		if err := manager.Create(); err != nil {
+			// 通常是缺少权限或控制器未启用，沙箱回退为每次临时创建
+			logx.Errorf("Failed to create pooled cgroup, disabling cgroup pooling: %v", err)
+			p.cgroupsBroken = true
+			return
+		}
+		p.cgroups <- manager
+	}
+}
+
+// 取出一个预先启动的init，池为空时返回nil
+func (p *SandboxPool) takeZygote() *sandboxZygote {
+	defer p.signalRefill()
+
+	select {
+	case zygote := <-p.zygotes:
+		p.zygoteHits.Add(1)
+		return zygote
+	default:
+		p.zygoteMisses.Add(1)
+		return nil
+	}
+}
+
+// 交付失败的init不计入命中
+func (p *SandboxPool) zygoteFailed() {
+	p.zygoteHits.Add(-1)
+	p.zygoteMisses.Add(1)
+}
+
+// 取出一个控制组并按本次执行的配置设置限制，池为空或设置失败时返回nil
+func (p *SandboxPool) takeCgroup(config *CgroupConfig) *CgroupManager {
+	if !p.cgroupsWanted.Swap(true) {
+		p.signalRefill()
+	}
+
+	select {
+	case manager := <-p.cgroups:
+		if err := manager.Reconfigure(config); err != nil {
+			logx.Errorf("Failed to reconfigure pooled cgroup %s: %v", manager.config.GroupName, err)
+			p.discardCgroup(manager)
+			p.cgroupMisses.Add(1)
+			return nil
+		}
+		p.cgroupHits.Add(1)
+		return manager
+	default:
+		p.cgroupMisses.Add(1)
+		return nil
+	}
+}
+
+// 执行结束后在后台重置控制组并放回池中，不阻塞判题
+func (p *SandboxPool) releaseCgroup(manager *CgroupManager) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		if err := manager.Reset(); err != nil {
+			logx.Errorf("Failed to reset pooled cgroup %s: %v", manager.config.GroupName, err)
+			p.discardCgroup(manager)
+			return
+		}
+
+		select {
+		case <-p.done:
+			manager.Cleanup()
+			return
+		default:
+		}
+		select {
+		case p.cgroups <- manager:
+		default:
+			manager.Cleanup()
+		}
+	}()
+}
+
+// 删除无法复用的控制组，由后台协程重新创建
+func (p *SandboxPool) discardCgroup(manager *CgroupManager) {
+	if err := manager.Cleanup(); err != nil {
+		logx.Errorf("Failed to cleanup pooled cgroup %s: %v", manager.config.GroupName, err)
+	}
+	p.signalRefill()
+}
+
+// 以zygote角色启动init：fd3为接收执行配置的套接字，fd4为上报管道写端（与新启动的init一致）
+func startSandboxZygote(rootless bool) (*sandboxZygote, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate judge executable: %w", err)
+	}
+
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_SEQPACKET|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zygote socket: %w", err)
+	}
+	conn := os.NewFile(uintptr(fds[0]), "sandbox-zygote")
+	peer := os.NewFile(uintptr(fds[1]), "sandbox-zygote-peer")
+	defer peer.Close()
+
+	reportReader, reportWriter, err := os.Pipe()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create report pipe: %w", err)
+	}
+	defer reportWriter.Close()
+
+	cmd := exec.Command(self)
+	cmd.Env = append([]string{sandboxHelperEnv + "=" + sandboxHelperRoleZygote}, sandboxHelperRuntimeEnv...)
+	cmd.ExtraFiles = []*os.File{peer, reportWriter}
+	// 判题服务意外退出时zygote随之被杀死
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: sandboxZygoteCloneFlags,
+		Pdeathsig:  syscall.SIGKILL,
+	}
+	if rootless {
+		setRootlessIDMappings(cmd.SysProcAttr)
+	}
+	if err := cmd.Start(); err != nil {
+		conn.Close()
+		reportReader.Close()
+		return nil, fmt.Errorf("failed to start zygote: %w", err)
+	}
+
+	return &sandboxZygote{cmd: cmd, conn: conn, report: reportReader}, nil
+}
+
+// 交付本次执行：配置与标准输入、标准输出、标准错误通过一条消息发送，nil的流连接到/dev/null
+// 成功后init开始执行，与新启动的init一样由调用方监控与回收
+func (z *sandboxZygote) deliver(config *SandboxHelperConfig, stdin, stdout, stderr *os.File) error {
+	defer z.conn.Close()
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sandbox helper config: %w", err)
+	}
+	if len(data) > sandboxZygoteMaxMessage {
+		return fmt.Errorf("sandbox helper config too large: %d bytes", len(data))
+	}
+
+	stdio := []*os.File{stdin, stdout, stderr}
+	for i, file := range stdio {
+		if file != nil {
+			continue
+		}
+		devNull, err := os.Open(os.DevNull)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", os.DevNull, err)
+		}
+		defer devNull.Close()
+		stdio[i] = devNull
+	}
+
+	rights := syscall.UnixRights(int(stdio[0].Fd()), int(stdio[1].Fd()), int(stdio[2].Fd()))
+	// init已退出（如被意外杀死）时返回EPIPE，调用方改为临时启动
+	if err := syscall.Sendmsg(int(z.conn.Fd()), data, rights, nil, syscall.MSG_NOSIGNAL); err != nil {
+		return fmt.Errorf("failed to send config to zygote: %w", err)
+	}
+	return nil
+}
+
+// 终止并回收未使用的init
+func (z *sandboxZygote) discard() {
+	z.conn.Close()
+	z.report.Close()
+	z.cmd.Process.Kill()
+	z.cmd.Wait()
+}
+
+// zygote角色：阻塞等待判题服务交付执行配置与标准输入输出，资源池关闭（套接字EOF）时直接退出
+func receiveSandboxZygoteRun(config *SandboxHelperConfig) error {
+	buf := make([]byte, sandboxZygoteMaxMessage)
+	oob := make([]byte, syscall.CmsgSpace(3*4))
+
+	var n, oobn, flags int
+	var err error
+	for {
+		n, oobn, flags, _, err = syscall.Recvmsg(sandboxHelperConfigFd, buf, oob, syscall.MSG_CMSG_CLOEXEC)
+		if err != syscall.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to receive config: %w", err)
+	}
+	if n == 0 {
+		os.Exit(0)
+	}
+	if flags&(syscall.MSG_TRUNC|syscall.MSG_CTRUNC) != 0 {
+		return fmt.Errorf("config message truncated")
+	}
+	// 套接字不能泄漏给执行阶段与目标程序
+	syscall.Close(sandboxHelperConfigFd)
+
+	messages, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(messages) != 1 {
+		return fmt.Errorf("failed to parse stdio rights: %v", err)
+	}
+	fds, err := syscall.ParseUnixRights(&messages[0])
+	if err != nil || len(fds) != 3 {
+		return fmt.Errorf("failed to parse stdio rights: %v", err)
+	}
+	// 依次成为标准输入、标准输出、标准错误
+	for target, fd := range fds {
+		if err := syscall.Dup3(fd, target, 0); err != nil {
+			return fmt.Errorf("failed to install stdio: %w", err)
+		}
+		syscall.Close(fd)
+	}
+
+	if err := json.Unmarshal(buf[:n], config); err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	return nil
+}
+
+// 取出资源池中预先启动的init；需要包装脚本或额外namespace（User/UTS/IPC/Cgroup）时只能临时启动
+// rootless模式的user namespace由资源池一并创建，两者的模式必须一致
+func (s *SystemCallSandbox) takePooledInit(needsNamespaceWrapper bool) *sandboxZygote {
+	if s.config.Pool == nil || needsNamespaceWrapper || (s.config.EnableUserNS && !s.config.Rootless) {
+		return nil
+	}
+	if s.config.Rootless != s.config.Pool.config.Rootless {
+		return nil
+	}
+	return s.config.Pool.takeZygote()
+}
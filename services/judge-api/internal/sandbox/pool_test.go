@@ -0,0 +1,80 @@
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 等待资源池预先启动的init就绪
+func waitForPooledInit(t *testing.T, pool *SandboxPool) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for len(pool.zygotes) < cap(pool.zygotes) {
+		if time.Now().After(deadline) {
+			t.Fatal("pooled sandbox init not ready")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestExecuteUsesPooledInit(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("sandbox execution requires root")
+	}
+
+	pool := NewSandboxPool(SandboxPoolConfig{Name: "test", Zygotes: 1})
+	defer pool.Close()
+
+	for run := 0; run < 2; run++ {
+		waitForPooledInit(t, pool)
+
+		result, output := runShellInSandboxWith(t, `read line; echo "$line"; echo oops >&2; exit 3`, func(config *SandboxConfig) {
+			config.Pool = pool
+			config.InputFile = filepath.Join(config.WorkDir, "input.txt")
+			if err := os.WriteFile(config.InputFile, []byte("hello\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		})
+
+		if output != "hello" {
+			t.Fatalf("run %d: unexpected output %q", run, output)
+		}
+		if result.ExitCode != 3 {
+			t.Fatalf("run %d: expected exit code 3, got %d", run, result.ExitCode)
+		}
+		if strings.TrimSpace(result.ErrorOutput) != "oops" {
+			t.Fatalf("run %d: unexpected stderr %q", run, result.ErrorOutput)
+		}
+	}
+
+	if stats := pool.Stats(); stats.ZygoteHits != 2 || stats.ZygoteMisses != 0 {
+		t.Fatalf("expected both runs to use pooled init, got %+v", stats)
+	}
+}
+
+func TestSandboxPoolCloseStopsIdleInit(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("sandbox execution requires root")
+	}
+
+	pool := NewSandboxPool(SandboxPoolConfig{Name: "test", Zygotes: 2})
+	waitForPooledInit(t, pool)
+
+	var pids []int
+	for _, zygote := range []*sandboxZygote{<-pool.zygotes, <-pool.zygotes} {
+		pids = append(pids, zygote.cmd.Process.Pid)
+		pool.zygotes <- zygote
+	}
+	pool.Close()
+
+	for _, pid := range pids {
+		if _, err := os.Stat(filepath.Join("/proc", strconv.Itoa(pid))); err == nil {
+			t.Fatalf("pooled init %d still running after close", pid)
+		}
+	}
+}
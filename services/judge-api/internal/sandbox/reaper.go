@@ -0,0 +1,343 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// PID namespace中的init进程
+// 原理：辅助进程以init角色成为新PID namespace中的1号进程，再启动执行阶段（降权、rlimit、seccomp后execve目标程序）
+// 选手程序fork出的子孙进程无论是否被父进程回收、是否脱离父进程（守护进程化），最终都会被init回收
+// 主进程退出或墙钟时间耗尽时，init向namespace内所有进程发送SIGKILL并全部回收后退出；
+// init退出后内核也会杀死namespace中残留的进程，整个进程树不会逃出沙箱
+// 由于所有进程都由init回收，判题服务wait4得到的rusage包含整棵进程树的CPU时间
+//
+// 启用seccomp时init同时跟踪执行阶段及其全部线程与子进程：过滤器的违规动作为SECCOMP_RET_TRAP，
+// 违规的线程收到SIGSYS时先进入信号停止，init从siginfo中读取被拦截的系统调用号，随即终止整棵进程树并上报
+// 不使用SECCOMP_RET_KILL_PROCESS：5.16起其SIGSYS不可被ptrace拦截，审计日志在容器中通常也无法读取
+// execve只允许执行阶段启动目标程序的一次：BPF无法区分这次与目标程序之后的execve（参数是用户内存中的路径），
+// 由init在PTRACE_EVENT_EXEC时计数，目标程序再次execve（system("sh")、Node的child_process等）按违规处理
+// open_read_only规则的写方式打开以SECCOMP_RET_TRACE交给init，按路径只放行工作目录之内的（见openpath.go）
+
+// 辅助进程角色（sandboxHelperEnv的取值）
+const (
+	sandboxHelperRoleInit   = "init"   // PID namespace中的1号进程，负责回收与清理
+	sandboxHelperRoleZygote = "zygote" // 资源池预先启动的init，等待判题服务交付配置与标准输入输出（见pool.go）
+	sandboxHelperRoleExec   = "exec"   // 执行阶段，完成隔离设置后execve目标程序
+)
+
+// init进程向判题服务上报结果的文件描述符（exec.Cmd.ExtraFiles[1]）
+const sandboxInitReportFd = 4
+
+// 判题服务等待init自行处理墙钟超时的宽限时间，超过后直接杀死init
+const sandboxInitKillGrace = time.Second
+
+// init跟踪目标程序的全部线程与子进程；init退出时被跟踪的进程随之被杀死
+const sandboxInitTraceOptions = ptraceOptionTraceSeccomp | ptraceOptionExitKill |
+	syscall.PTRACE_O_TRACECLONE | syscall.PTRACE_O_TRACEFORK | syscall.PTRACE_O_TRACEVFORK | syscall.PTRACE_O_TRACEEXEC
+
+// SIGSYS的si_code：由seccomp过滤器的SECCOMP_RET_TRAP产生
+const siginfoCodeSysSeccomp = 1
+
+// 内核siginfo_t中SIGSYS的字段（x86_64布局，共128字节）
+type seccompSiginfo struct {
+	Signo    int32
+	Errno    int32
+	Code     int32
+	_        int32
+	CallAddr uint64
+	Syscall  int32  // 被拦截的系统调用号
+	Arch     uint32 // AUDIT_ARCH_*
+	_        [96]byte
+}
+
+// SandboxInitReport init进程上报的主进程状态与进程树统计
+type SandboxInitReport struct {
+	ExitCode         int   `json:"exit_code"`          // 主进程退出码
+	Signal           int   `json:"signal"`             // 终止主进程的信号，正常退出为0
+	WallTimeExceeded bool  `json:"wall_time_exceeded"` // 是否因墙钟时间耗尽被终止
+	Processes        int   `json:"processes"`          // init回收的进程总数（含主进程）
+	OrphansKilled    int   `json:"orphans_killed"`     // 主进程结束时仍存活、被init终止的进程数
+	InitCPUTimeUs    int64 `json:"init_cpu_time_us"`   // init自身的CPU时间（user+sys，微秒），需从总时间中扣除
+	WallTimeUs       int64 `json:"wall_time_us"`       // 从执行阶段启动到主进程结束的墙钟时间（微秒）
+	SeccompViolation bool  `json:"seccomp_violation"`  // 是否因调用被禁止的系统调用而被终止
+	Syscall          int   `json:"syscall"`            // 被seccomp拦截的系统调用号（第一次违规）
+}
+
+// init角色入口，zygote为true时从资源池的套接字接收配置与标准输入输出
+func runSandboxInit(zygote bool) error {
+	// 只有作为新PID namespace的1号进程时kill(-1)才局限于沙箱内
+	if os.Getpid() != 1 {
+		return fmt.Errorf("sandbox init must run as pid 1 of a new pid namespace")
+	}
+
+	var config SandboxHelperConfig
+	if zygote {
+		if err := receiveSandboxZygoteRun(&config); err != nil {
+			return err
+		}
+	} else {
+		configFile := os.NewFile(sandboxHelperConfigFd, "sandbox-config")
+		if err := json.NewDecoder(configFile).Decode(&config); err != nil {
+			return fmt.Errorf("failed to read config: %w", err)
+		}
+		configFile.Close()
+	}
+
+	// 上报管道不能泄漏给执行阶段与目标程序
+	reportFile := os.NewFile(sandboxInitReportFd, "sandbox-report")
+	syscall.CloseOnExec(sandboxInitReportFd)
+
+	// namespace内的进程只能向init发送其注册了处理函数的信号，这里统一忽略
+	signal.Ignore(syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGPIPE, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	cmd, err := newSandboxHelperCommand(context.Background(), &config)
+	if err != nil {
+		return err
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	// ptrace请求只能由启动被跟踪进程的线程发出，RunSandboxHelper已锁定当前线程
+	traced := config.EnableSeccomp
+	if traced {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Ptrace: true}
+	}
+	// 检查路径需要本PID namespace的/proc；根文件系统中的/proc由执行阶段挂载，pivot_root后init同样使用
+	if traced && config.Rootfs == nil && tracesSyscallArgs(config.SyscallArgRules) {
+		if err := mountSandboxInitProc(); err != nil {
+			return err
+		}
+	}
+	if err := cmd.Start(); err != nil {
+		closeSandboxHelperFiles(cmd)
+		return fmt.Errorf("failed to start exec stage: %w", err)
+	}
+	closeSandboxHelperFiles(cmd)
+
+	report := reapSandboxProcesses(cmd.Process.Pid, time.Duration(config.WallTimeLimitMs)*time.Millisecond, traced, config.Dir)
+
+	var self syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &self); err == nil {
+		report.InitCPUTimeUs = self.Utime.Sec*1000000 + self.Utime.Usec + self.Stime.Sec*1000000 + self.Stime.Usec
+	}
+
+	if err := json.NewEncoder(reportFile).Encode(report); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	reportFile.Close()
+	os.Exit(0)
+	return nil
+}
+
+// 回收namespace内的所有进程，主进程结束或墙钟超时后终止其余进程
+// traced为true时执行阶段以PTRACE_TRACEME启动，同时处理被跟踪进程的停止；workDir是目标程序视角的工作目录
+func reapSandboxProcesses(mainPid int, wallTimeLimit time.Duration, traced bool, workDir string) *SandboxInitReport {
+	report := &SandboxInitReport{}
+	start := time.Now()
+
+	var wallExceeded atomic.Bool
+	if wallTimeLimit > 0 {
+		timer := time.AfterFunc(wallTimeLimit, func() {
+			wallExceeded.Store(true)
+			syscall.Kill(-1, syscall.SIGKILL)
+		})
+		defer timer.Stop()
+	}
+
+	waitOptions := 0
+	var tracer *sandboxTracer
+	if traced {
+		waitOptions = syscall.WALL
+		tracer = newSandboxTracer(mainPid, report, workDir)
+	}
+
+	mainDone := false
+	for {
+		var status syscall.WaitStatus
+		pid, err := syscall.Wait4(-1, &status, waitOptions, nil)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			// ECHILD：namespace内已没有其他进程
+			break
+		}
+		if status.Stopped() {
+			tracer.handleStop(pid, status)
+			continue
+		}
+		if tracer != nil && !tracer.countExit(pid) {
+			continue
+		}
+		report.Processes++
+
+		if pid == mainPid {
+			mainDone = true
+			report.WallTimeUs = time.Since(start).Microseconds()
+			if status.Signaled() {
+				report.Signal = int(status.Signal())
+			} else {
+				report.ExitCode = status.ExitStatus()
+			}
+			// 主进程结束后残留的进程（如守护进程化的子进程）一律终止
+			syscall.Kill(-1, syscall.SIGKILL)
+			continue
+		}
+		if mainDone {
+			report.OrphansKilled++
+		}
+	}
+
+	report.WallTimeExceeded = wallExceeded.Load()
+	return report
+}
+
+// init对目标程序的跟踪状态
+type sandboxTracer struct {
+	report     *SandboxInitReport
+	workDir    string // 目标程序视角的工作目录，open_read_only只允许在其中写方式打开
+	optionsSet bool
+	attached   map[int]bool // 已完成初始停止的线程与进程，未出现过的停止是新线程或子进程的初始停止
+	execs      int          // 设置跟踪选项后的execve次数，第一次是执行阶段启动目标程序
+	threads    map[int]bool // PTRACE_EVENT_CLONE创建的线程，退出时不计入进程数
+	exited     map[int]bool // 已计数的进程：跟踪者与父进程不同时，同一进程的退出可能被通知两次
+}
+
+func newSandboxTracer(mainPid int, report *SandboxInitReport, workDir string) *sandboxTracer {
+	return &sandboxTracer{
+		report:   report,
+		workDir:  workDir,
+		attached: map[int]bool{mainPid: true},
+		threads:  make(map[int]bool),
+		exited:   make(map[int]bool),
+	}
+}
+
+// 处理被跟踪进程的停止并使其继续执行
+func (t *sandboxTracer) handleStop(pid int, status syscall.WaitStatus) {
+	signal := 0
+	stopSignal := status.StopSignal()
+	switch {
+	case !t.attached[pid]:
+		// 自动跟踪的新线程与子进程以SIGSTOP开始
+		t.attached[pid] = true
+	case !t.optionsSet:
+		// 执行阶段的第一次停止（PTRACE_TRACEME后execve判题服务自身），之后的线程与子进程自动被跟踪
+		t.optionsSet = true
+		if err := syscall.PtraceSetOptions(pid, sandboxInitTraceOptions); err != nil {
+			// 无法跟踪子进程就无法拦截违规，直接终止
+			syscall.Kill(-1, syscall.SIGKILL)
+			return
+		}
+	case stopSignal == syscall.SIGTRAP && status.TrapCause() > 0:
+		// clone/fork/exec/seccomp事件
+		switch status.TrapCause() {
+		case ptraceEventSeccomp:
+			t.checkOpenPath(pid)
+		case syscall.PTRACE_EVENT_CLONE:
+			if tid, err := syscall.PtraceGetEventMsg(pid); err == nil {
+				t.threads[int(tid)] = true
+			}
+		case syscall.PTRACE_EVENT_EXEC:
+			// 新程序尚未执行任何指令
+			t.execs++
+			if t.execs > 1 {
+				t.violation(syscall.SYS_EXECVE)
+				return
+			}
+		}
+	default:
+		var info seccompSiginfo
+		if _, _, errno := syscall.RawSyscall6(syscall.SYS_PTRACE, syscall.PTRACE_GETSIGINFO,
+			uintptr(pid), 0, uintptr(unsafe.Pointer(&info)), 0, 0); errno != 0 {
+			// 没有待处理的信号：组停止（SIGSTOP/SIGTSTP等），直接继续
+			break
+		}
+		if stopSignal == syscall.SIGSYS && info.Code == siginfoCodeSysSeccomp {
+			// 目标程序可能注册了SIGSYS处理函数，不能把信号交还给它
+			t.violation(int(info.Syscall))
+			return
+		}
+		signal = int(stopSignal)
+	}
+	// ESRCH：进程已被杀死，下一次wait4会得到退出状态
+	syscall.PtraceCont(pid, signal)
+}
+
+// 记录第一次违规的系统调用并终止整棵进程树
+func (t *sandboxTracer) violation(syscallNum int) {
+	if !t.report.SeccompViolation {
+		t.report.SeccompViolation = true
+		t.report.Syscall = syscallNum
+	}
+	syscall.Kill(-1, syscall.SIGKILL)
+}
+
+// 记录进程退出，返回是否计入进程数
+func (t *sandboxTracer) countExit(pid int) bool {
+	if t.threads[pid] {
+		delete(t.threads, pid)
+		return false
+	}
+	if t.exited[pid] {
+		return false
+	}
+	t.exited[pid] = true
+	return true
+}
+
+// 参数规则中是否有需要init检查的（Trace）
+func tracesSyscallArgs(rules []SyscallArgRule) bool {
+	for _, rule := range rules {
+		if rule.Trace {
+			return true
+		}
+	}
+	return false
+}
+
+// 在init的mount namespace中挂载本PID namespace的/proc
+// 先改为私有挂载，避免新挂载传播回判题服务的namespace；执行阶段与目标程序看到的/proc随之只包含沙箱内的进程
+func mountSandboxInitProc() error {
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("failed to make mounts private: %w", err)
+	}
+	if err := syscall.Mount("proc", "/proc", "proc", syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, ""); err != nil {
+		return fmt.Errorf("failed to mount /proc: %w", err)
+	}
+	return nil
+}
+
+// 创建以init角色重新执行判题服务的命令，返回init上报结果的读端
+func newSandboxInitCommand(ctx context.Context, config *SandboxHelperConfig) (*exec.Cmd, *os.File, error) {
+	cmd, err := newSandboxHelperCommandAs(ctx, sandboxHelperRoleInit, config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reportReader, reportWriter, err := os.Pipe()
+	if err != nil {
+		closeSandboxHelperFiles(cmd)
+		return nil, nil, fmt.Errorf("failed to create report pipe: %w", err)
+	}
+
+	cmd.ExtraFiles = append(cmd.ExtraFiles, reportWriter)
+	return cmd, reportReader, nil
+}
+
+// 读取init上报的结果，init被直接杀死（未上报）时返回nil
+func readSandboxInitReport(reader io.Reader) *SandboxInitReport {
+	var report SandboxInitReport
+	if err := json.NewDecoder(reader).Decode(&report); err != nil {
+		return nil
+	}
+	return &report
+}
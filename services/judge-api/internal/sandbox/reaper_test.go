@@ -0,0 +1,206 @@
+package sandbox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 在沙箱中用/bin/sh执行脚本
+func runShellInSandbox(t *testing.T, script string, wallTimeLimit int64) (*ExecuteResult, string) {
+	t.Helper()
+	return runShellInSandboxWith(t, script, func(config *SandboxConfig) {
+		config.WallTimeLimit = wallTimeLimit
+	})
+}
+
+// 在沙箱中用/bin/sh执行脚本，configure可调整默认配置
+func runShellInSandboxWith(t *testing.T, script string, configure func(*SandboxConfig)) (*ExecuteResult, string) {
+	t.Helper()
+
+	if os.Geteuid() != 0 {
+		t.Skip("sandbox execution requires root")
+	}
+
+	// nobody用户需要能够进入工作目录
+	workDir, err := os.MkdirTemp("", "reaper-execute-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(workDir) })
+	if err := os.Chmod(workDir, 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	config := &SandboxConfig{
+		UID:           65534,
+		GID:           65534,
+		WorkDir:       workDir,
+		TimeLimit:     5000,
+		WallTimeLimit: 10000,
+		MemoryLimit:   65536,
+		StackLimit:    8192,
+		FileSizeLimit: 1024,
+		ProcessLimit:  64,
+		OutputFile:    filepath.Join(workDir, "output.txt"),
+		ErrorFile:     filepath.Join(workDir, "error.txt"),
+		Environment:   []string{"PATH=/usr/bin:/bin"},
+	}
+	configure(config)
+
+	result, err := NewSystemCallSandbox(config).Execute(context.Background(), "/bin/sh", []string{"-c", script})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	output, _ := os.ReadFile(config.OutputFile)
+	return result, strings.TrimSpace(string(output))
+}
+
+// 主机上是否还有以nobody身份运行、命令行包含marker的进程
+func leakedProcesses(t *testing.T, marker string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var leaked []string
+	for _, entry := range entries {
+		cmdline, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "cmdline"))
+		if err != nil {
+			continue
+		}
+		if strings.Contains(strings.ReplaceAll(string(cmdline), "\x00", " "), marker) {
+			leaked = append(leaked, entry.Name())
+		}
+	}
+	return leaked
+}
+
+func TestExecuteKillsDaemonizedChildren(t *testing.T) {
+	start := time.Now()
+	// 子进程脱离父进程后在后台长时间运行
+	result, output := runShellInSandbox(t, "(sleep 37 &); echo detached", 10000)
+
+	if result.Status != StatusAccepted || result.ExitCode != 0 || output != "detached" {
+		t.Fatalf("unexpected result: status=%d exit=%d output=%q", result.Status, result.ExitCode, output)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("execute waited for the daemonized child: %v", elapsed)
+	}
+	if result.ResourceUsage.ProcessStats.OrphansKilled < 1 {
+		t.Fatalf("daemonized child should be reported, got %+v", result.ResourceUsage.ProcessStats)
+	}
+	if leaked := leakedProcesses(t, "sleep 37"); len(leaked) > 0 {
+		t.Fatalf("daemonized child escaped the sandbox: %v", leaked)
+	}
+}
+
+func TestExecuteContainsProcessFlood(t *testing.T) {
+	start := time.Now()
+	// 不断创建进程且主进程不退出，只能由墙钟时间限制终止；全部创建后输出spawned
+	script := `i=0; while [ $i -lt 40 ]; do sleep 41 & i=$((i+1)); done; echo spawned; sleep 41`
+	result, output := runShellInSandbox(t, script, 2000)
+
+	// 无论机器多慢，进程树都以墙钟超时结束且没有进程逃出沙箱
+	if result.Status != StatusTimeLimitExceeded || result.ResourceUsage.LimitExceeded != "wall" {
+		t.Fatalf("unexpected result: status=%d limit=%s output=%q", result.Status, result.ResourceUsage.LimitExceeded, output)
+	}
+	if elapsed := time.Since(start); elapsed > 2000*time.Millisecond+sandboxInitKillGrace+2*time.Second {
+		t.Fatalf("process flood was not stopped in time: %v", elapsed)
+	}
+	if leaked := leakedProcesses(t, "sleep 41"); len(leaked) > 0 {
+		t.Fatalf("processes escaped the sandbox: %v", leaked)
+	}
+
+	// 进程数只有在全部进程已创建（输出了spawned）且init在宽限时间内上报时才能检查；
+	// 负载很高时init自身启动较慢，可能在上报之前被判题服务直接杀死
+	stats := result.ResourceUsage.ProcessStats
+	if output != "spawned" || stats.Processes == 0 {
+		t.Logf("flood not observed in full (output=%q, stats=%+v), skipping the process count", output, stats)
+		return
+	}
+	if stats.Processes < 41 {
+		t.Fatalf("all processes should be reaped by init, got %+v", stats)
+	}
+}
+
+func TestExecuteAccountsCPUTimeOfWholeTree(t *testing.T) {
+	// 忙循环在脱离父进程的孙进程中运行，主进程自身几乎不占用CPU
+	script := `(i=0; while [ $i -lt 200000 ]; do i=$((i+1)); done; echo busy > busy.txt) &
+while [ ! -f busy.txt ]; do sleep 0.05; done; echo done`
+	result, output := runShellInSandbox(t, script, 10000)
+
+	if result.Status != StatusAccepted || output != "done" {
+		t.Fatalf("unexpected result: status=%d output=%q", result.Status, output)
+	}
+	if result.TimeUsed < 100 {
+		t.Fatalf("CPU time of child processes should be accounted, got %dms", result.TimeUsed)
+	}
+}
+
+func TestExecuteSeparatesCPUAndWallTime(t *testing.T) {
+	// 时间限制与墙钟限制之间留出足够的余量，负载很高时也不会把CPU超时误判为墙钟超时；
+	// 检查只关注超时的分类与TimeUsed采用的度量，不依赖精确到毫秒的耗时
+	tests := []struct {
+		name          string
+		script        string
+		metric        string
+		timeLimit     int64
+		wallTimeLimit int64
+		wantStatus    int
+		wantLimit     string
+		check         func(*ExecuteResult) bool
+	}{
+		{
+			// 阻塞在sleep上的程序几乎不占用CPU，只能由墙钟时间终止
+			name: "idle program", script: "sleep 30", metric: TimeLimitMetricCPU, timeLimit: 1000, wallTimeLimit: 1500,
+			wantStatus: StatusTimeLimitExceeded, wantLimit: "wall",
+			check: func(r *ExecuteResult) bool {
+				return r.CPUTime < r.WallTime && r.WallTime >= 1500 && r.TimeUsed == r.CPUTime
+			},
+		},
+		{
+			// RLIMIT_CPU以秒为单位，CPU限制500ms的程序最迟约2秒CPU时间被终止，墙钟限制远大于此
+			name: "busy program", script: "while :; do :; done", metric: TimeLimitMetricCPU, timeLimit: 500, wallTimeLimit: 30000,
+			wantStatus: StatusTimeLimitExceeded, wantLimit: "cpu",
+			check: func(r *ExecuteResult) bool { return r.CPUTime > 500 && r.TimeUsed == r.CPUTime },
+		},
+		{
+			name: "sleep within cpu limit", script: "sleep 0.3", metric: TimeLimitMetricCPU, timeLimit: 1000, wallTimeLimit: 10000,
+			wantStatus: StatusAccepted, wantLimit: "none",
+			check: func(r *ExecuteResult) bool { return r.TimeUsed == r.CPUTime && r.WallTime >= 300 },
+		},
+		{
+			name: "sleep within wall limit", script: "sleep 0.3", metric: TimeLimitMetricWall, timeLimit: 5000, wallTimeLimit: 10000,
+			wantStatus: StatusAccepted, wantLimit: "none",
+			check: func(r *ExecuteResult) bool { return r.TimeUsed == r.WallTime && r.WallTime >= 300 },
+		},
+		{
+			// 按墙钟计时时TimeLimit本身就是墙钟限制，先于WallTimeLimit生效
+			name: "sleep beyond wall limit", script: "sleep 30", metric: TimeLimitMetricWall, timeLimit: 300, wallTimeLimit: 20000,
+			wantStatus: StatusTimeLimitExceeded, wantLimit: "wall",
+			check: func(r *ExecuteResult) bool {
+				return r.TimeUsed == r.WallTime && r.WallTime >= 300 && r.WallTime < 20000
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := runShellInSandboxWith(t, tt.script, func(config *SandboxConfig) {
+				config.TimeLimit = tt.timeLimit
+				config.WallTimeLimit = tt.wallTimeLimit
+				config.TimeLimitMetric = tt.metric
+			})
+
+			if result.Status != tt.wantStatus || result.ResourceUsage.LimitExceeded != tt.wantLimit || !tt.check(result) {
+				t.Fatalf("unexpected result: status=%d limit=%s time=%dms cpu=%dms wall=%dms",
+					result.Status, result.ResourceUsage.LimitExceeded, result.TimeUsed, result.CPUTime, result.WallTime)
+			}
+		})
+	}
+}
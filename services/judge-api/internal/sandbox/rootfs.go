@@ -0,0 +1,264 @@
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// 最小只读根文件系统
+// 原理：辅助进程位于新的mount namespace中，在tmpfs上按配置搭建新的根目录，再pivot_root切换过去
+// 只有配置的工具链路径以只读方式绑定挂载进来，工作目录读写挂载，/proc与/dev按需最小化填充
+// 切换后卸载旧根，用户程序无法再访问主机上的其他文件（即使nobody可读）
+
+// 新根目录tmpfs大小：只存放挂载点与符号链接
+const rootfsTmpfsOptions = "mode=0755,size=1m"
+
+// 可写目录（如/tmp）在沙箱内是独立的tmpfs，不暴露主机上的内容
+const writableTmpfsOptions = "mode=1777,size=64m"
+
+// 旧根在新根中的临时位置，pivot_root后立即卸载
+const rootfsOldRoot = ".oldroot"
+
+// 沙箱内/dev只提供的设备文件
+var rootfsDevices = []string{"/dev/null", "/dev/zero", "/dev/random", "/dev/urandom"}
+
+// RootfsConfig 根文件系统配置
+type RootfsConfig struct {
+	MountPoint    string   `json:"mount_point"`    // 新根的挂载点（主机上的空目录）
+	ReadOnlyPaths []string `json:"readonly_paths"` // 只读绑定挂载的主机路径（工具链、动态库等）
+	WritablePaths []string `json:"writable_paths"` // 可写路径：目录挂载为独立tmpfs，设备等文件读写绑定挂载
+	WorkDir       string   `json:"work_dir"`       // 读写绑定挂载的工作目录
+}
+
+// 挂载类型
+type rootfsMountKind int
+
+const (
+	rootfsBindReadOnly rootfsMountKind = iota // 只读绑定挂载
+	rootfsBindWritable                        // 读写绑定挂载
+	rootfsTmpfs                               // 独立tmpfs
+	rootfsSymlink                             // 符号链接（如merged-usr系统上的/bin -> usr/bin）
+	rootfsProc                                // procfs
+)
+
+// 新根中的一项挂载
+type rootfsMount struct {
+	kind   rootfsMountKind
+	source string // 主机路径；符号链接时为链接内容
+	target string // 新根中的绝对路径
+	isDir  bool   // 绑定挂载的源是否为目录
+}
+
+// 根据配置规划新根中的挂载项，父路径排在子路径之前
+func planRootfsMounts(config *RootfsConfig) ([]rootfsMount, error) {
+	var mounts []rootfsMount
+	planned := make(map[string]bool)
+
+	add := func(m rootfsMount) {
+		if planned[m.target] {
+			return
+		}
+		// 已被只读绑定挂载（递归）覆盖的子路径无需重复挂载，也无法在只读挂载中创建挂载点
+		for _, existing := range mounts {
+			if existing.kind == rootfsBindReadOnly && existing.isDir && strings.HasPrefix(m.target, existing.target+"/") {
+				return
+			}
+		}
+		planned[m.target] = true
+		mounts = append(mounts, m)
+	}
+
+	addHostPath := func(path string, writable bool) error {
+		path = filepath.Clean(path)
+		if !filepath.IsAbs(path) || path == "/" {
+			return fmt.Errorf("invalid rootfs path: %s", path)
+		}
+
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			// 工具链路径在不同主机上可能不存在，跳过即可
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return fmt.Errorf("failed to read symlink %s: %w", path, err)
+			}
+			add(rootfsMount{kind: rootfsSymlink, source: link, target: path})
+		case writable && info.IsDir():
+			add(rootfsMount{kind: rootfsTmpfs, target: path, isDir: true})
+		case writable:
+			add(rootfsMount{kind: rootfsBindWritable, source: path, target: path})
+		default:
+			add(rootfsMount{kind: rootfsBindReadOnly, source: path, target: path, isDir: info.IsDir()})
+		}
+		return nil
+	}
+
+	// /proc与/dev总是提供，/dev只包含少量无害的设备
+	add(rootfsMount{kind: rootfsProc, target: "/proc", isDir: true})
+	add(rootfsMount{kind: rootfsTmpfs, target: "/dev", isDir: true})
+	for _, device := range rootfsDevices {
+		if err := addHostPath(device, true); err != nil {
+			return nil, err
+		}
+	}
+	add(rootfsMount{kind: rootfsSymlink, source: "/proc/self/fd", target: "/dev/fd"})
+	add(rootfsMount{kind: rootfsSymlink, source: "/proc/self/fd/0", target: "/dev/stdin"})
+	add(rootfsMount{kind: rootfsSymlink, source: "/proc/self/fd/1", target: "/dev/stdout"})
+	add(rootfsMount{kind: rootfsSymlink, source: "/proc/self/fd/2", target: "/dev/stderr"})
+
+	for _, path := range config.ReadOnlyPaths {
+		if err := addHostPath(path, false); err != nil {
+			return nil, err
+		}
+	}
+	for _, path := range config.WritablePaths {
+		if err := addHostPath(path, true); err != nil {
+			return nil, err
+		}
+	}
+
+	// 工作目录读写挂载在原路径上，执行器传入的绝对路径在沙箱内保持有效
+	if config.WorkDir != "" {
+		workDir := filepath.Clean(config.WorkDir)
+		delete(planned, workDir)
+		add(rootfsMount{kind: rootfsBindWritable, source: workDir, target: workDir, isDir: true})
+	}
+
+	// 按路径深度排序，保证先挂载/tmp再在其中挂载工作目录
+	sort.SliceStable(mounts, func(i, j int) bool {
+		return strings.Count(mounts[i].target, "/") < strings.Count(mounts[j].target, "/")
+	})
+	return mounts, nil
+}
+
+// 在辅助进程中搭建新根并pivot_root（需要root权限，且已处于新的mount namespace）
+func setupRootfs(config *RootfsConfig) error {
+	mounts, err := planRootfsMounts(config)
+	if err != nil {
+		return err
+	}
+
+	// 挂载事件不能传播回主机的mount namespace
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("failed to make mounts private: %w", err)
+	}
+
+	root := config.MountPoint
+	if err := syscall.Mount("tmpfs", root, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, rootfsTmpfsOptions); err != nil {
+		return fmt.Errorf("failed to mount root tmpfs: %w", err)
+	}
+
+	for _, m := range mounts {
+		if err := applyRootfsMount(root, m); err != nil {
+			return err
+		}
+	}
+
+	return pivotRoot(root)
+}
+
+// 在新根中创建一项挂载
+func applyRootfsMount(root string, m rootfsMount) error {
+	target := filepath.Join(root, m.target)
+
+	switch m.kind {
+	case rootfsSymlink:
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(m.target), err)
+		}
+		if err := os.Symlink(m.source, target); err != nil {
+			return fmt.Errorf("failed to create symlink %s: %w", m.target, err)
+		}
+		return nil
+
+	case rootfsTmpfs:
+		if err := os.MkdirAll(target, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", m.target, err)
+		}
+		options := writableTmpfsOptions
+		if m.target == "/dev" {
+			options = rootfsTmpfsOptions
+		}
+		if err := syscall.Mount("tmpfs", target, "tmpfs", syscall.MS_NOSUID, options); err != nil {
+			return fmt.Errorf("failed to mount tmpfs on %s: %w", m.target, err)
+		}
+		return nil
+
+	case rootfsProc:
+		if err := os.MkdirAll(target, 0555); err != nil {
+			return fmt.Errorf("failed to create /proc: %w", err)
+		}
+		// 辅助进程位于新的PID namespace中，/proc只能看到沙箱内的进程
+		if err := syscall.Mount("proc", target, "proc", syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, ""); err != nil {
+			return fmt.Errorf("failed to mount /proc: %w", err)
+		}
+		return nil
+	}
+
+	// 绑定挂载需要同类型的挂载点：目录或空文件
+	if m.isDir {
+		if err := os.MkdirAll(target, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", m.target, err)
+		}
+	} else {
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(m.target), err)
+		}
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", m.target, err)
+		}
+		file.Close()
+	}
+
+	if err := syscall.Mount(m.source, target, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+		return fmt.Errorf("failed to bind mount %s: %w", m.source, err)
+	}
+
+	// 绑定挂载的只读属性需要再次remount才能生效
+	if m.kind == rootfsBindReadOnly {
+		flags := uintptr(syscall.MS_BIND | syscall.MS_REMOUNT | syscall.MS_RDONLY | syscall.MS_NOSUID | syscall.MS_NODEV)
+		if err := syscall.Mount("", target, "", flags, ""); err != nil {
+			return fmt.Errorf("failed to remount %s read-only: %w", m.source, err)
+		}
+	}
+	return nil
+}
+
+// 切换到新根并卸载旧根，最后将新根本身设为只读
+func pivotRoot(root string) error {
+	oldRoot := filepath.Join(root, rootfsOldRoot)
+	if err := os.Mkdir(oldRoot, 0700); err != nil {
+		return fmt.Errorf("failed to create old root: %w", err)
+	}
+	if err := syscall.PivotRoot(root, oldRoot); err != nil {
+		return fmt.Errorf("failed to pivot_root: %w", err)
+	}
+	if err := syscall.Chdir("/"); err != nil {
+		return fmt.Errorf("failed to chdir to new root: %w", err)
+	}
+
+	oldRoot = "/" + rootfsOldRoot
+	if err := syscall.Unmount(oldRoot, syscall.MNT_DETACH); err != nil {
+		return fmt.Errorf("failed to unmount old root: %w", err)
+	}
+	if err := os.Remove(oldRoot); err != nil {
+		return fmt.Errorf("failed to remove old root: %w", err)
+	}
+
+	if err := syscall.Mount("", "/", "", syscall.MS_REMOUNT|syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("failed to remount root read-only: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package sandbox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPlanRootfsMounts(t *testing.T) {
+	host := t.TempDir()
+	usr := filepath.Join(host, "usr")
+	if err := os.MkdirAll(filepath.Join(usr, "lib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// merged-usr系统上/bin是指向usr/bin的符号链接
+	if err := os.Symlink("usr/bin", filepath.Join(host, "bin")); err != nil {
+		t.Fatal(err)
+	}
+	scratch := filepath.Join(host, "tmp")
+	workDir := filepath.Join(scratch, "run")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	mounts, err := planRootfsMounts(&RootfsConfig{
+		ReadOnlyPaths: []string{usr, filepath.Join(host, "bin"), filepath.Join(usr, "lib"), filepath.Join(host, "missing")},
+		WritablePaths: []string{scratch},
+		WorkDir:       workDir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := make(map[string]rootfsMountKind)
+	index := make(map[string]int)
+	for i, m := range mounts {
+		kinds[m.target] = m.kind
+		index[m.target] = i
+	}
+
+	if kind, ok := kinds[usr]; !ok || kind != rootfsBindReadOnly {
+		t.Fatalf("usr should be bind mounted read-only, got %v", mounts)
+	}
+	if _, ok := kinds[filepath.Join(usr, "lib")]; ok {
+		t.Fatal("path covered by a read-only bind mount should be skipped")
+	}
+	if _, ok := kinds[filepath.Join(host, "missing")]; ok {
+		t.Fatal("missing host path should be skipped")
+	}
+	if kinds[filepath.Join(host, "bin")] != rootfsSymlink {
+		t.Fatal("symlink should be recreated instead of bind mounted")
+	}
+	if kinds[scratch] != rootfsTmpfs {
+		t.Fatal("writable directory should get a private tmpfs")
+	}
+	if kinds["/dev/null"] != rootfsBindWritable || kinds["/proc"] != rootfsProc {
+		t.Fatal("minimal /dev and /proc should always be provided")
+	}
+	if kinds[workDir] != rootfsBindWritable {
+		t.Fatal("work dir should be bind mounted read-write")
+	}
+	if index[scratch] > index[workDir] || index["/dev"] > index["/dev/null"] {
+		t.Fatal("parent mounts must come before nested ones")
+	}
+}
+
+func TestExecuteBuildsMinimalRootfs(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("rootfs requires root")
+	}
+
+	// nobody用户需要能够进入工作目录
+	workDir, err := os.MkdirTemp("", "rootfs-execute-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+	if err := os.Chmod(workDir, 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	script := `ls -A /
+if touch /usr/probe 2>/dev/null; then echo usr-writable; fi
+if touch /probe 2>/dev/null; then echo root-writable; fi
+if [ -e /etc/hostname ]; then echo host-visible; fi
+echo scratch > /tmp/scratch && cat /tmp/scratch
+echo ok > result.txt
+`
+	config := &SandboxConfig{
+		UID:           65534,
+		GID:           65534,
+		WorkDir:       workDir,
+		TimeLimit:     2000,
+		WallTimeLimit: 5000,
+		MemoryLimit:   65536,
+		StackLimit:    8192,
+		FileSizeLimit: 1024,
+		ProcessLimit:  16,
+		EnableRootfs:  true,
+		ReadOnlyPaths: []string{"/bin", "/lib", "/lib64", "/usr"},
+		WritablePaths: []string{"/tmp", "/dev/null"},
+		OutputFile:    filepath.Join(workDir, "output.txt"),
+		ErrorFile:     filepath.Join(workDir, "error.txt"),
+		Environment:   []string{"PATH=/usr/bin:/bin"},
+	}
+
+	result, err := NewSystemCallSandbox(config).Execute(context.Background(), "/bin/sh", []string{"-c", script})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if result.Status != StatusAccepted || result.ExitCode != 0 {
+		t.Fatalf("unexpected result: status=%d exit=%d signal=%d stderr=%q", result.Status, result.ExitCode, result.Signal, result.ErrorOutput)
+	}
+
+	output, err := os.ReadFile(config.OutputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) == 0 || lines[len(lines)-1] != "scratch" {
+		t.Fatalf("unexpected output %q", output)
+	}
+
+	// 新根中只有配置的路径，没有主机上的/etc、/root等
+	var entries []string
+	for _, line := range lines[:len(lines)-1] {
+		if strings.HasSuffix(line, "-writable") || line == "host-visible" {
+			t.Fatalf("sandbox isolation broken: %s", line)
+		}
+		entries = append(entries, line)
+	}
+	sort.Strings(entries)
+	want := []string{"bin", "dev", "proc", "tmp", "usr"}
+	for _, path := range []string{"/lib", "/lib64"} {
+		if _, err := os.Lstat(path); err == nil {
+			want = append(want, strings.TrimPrefix(path, "/"))
+		}
+	}
+	sort.Strings(want)
+	if strings.Join(entries, " ") != strings.Join(want, " ") {
+		t.Fatalf("unexpected root entries %v, want %v", entries, want)
+	}
+
+	if content, err := os.ReadFile(filepath.Join(workDir, "result.txt")); err != nil || strings.TrimSpace(string(content)) != "ok" {
+		t.Fatalf("work dir should be writable: %q %v", content, err)
+	}
+
+	// 沙箱内的/tmp是独立的tmpfs，不会写到主机上
+	if _, err := os.Stat("/tmp/scratch"); err == nil {
+		t.Fatal("sandbox /tmp leaked to the host")
+	}
+}
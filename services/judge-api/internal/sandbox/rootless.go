@@ -0,0 +1,180 @@
+package sandbox
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// 无root权限的判题（rootless模式）
+// 原理：普通用户可以创建user namespace，并在其中拥有完整的capability；init与PID/网络/挂载namespace一起创建在新的user namespace中，
+// loopback、挂载、根文件系统与chroot都在其中完成，判题服务本身不需要任何特权
+// 非特权进程只能映射自己：沙箱内的root(0)映射到判题服务的用户，执行阶段无法切换到nobody，
+// 改为在execve之前锁定SECBIT_NOROOT并清空capability，目标程序以没有任何特权的root身份运行
+// 目标程序在主机上与判题服务是同一个用户，能读取判题服务可读的所有文件，因此rootless模式下应启用最小根文件系统（EnableRootfs）
+// 控制组需要委派给判题服务用户的cgroup v2子树（systemd的Delegate=yes，或容器中可写的/sys/fs/cgroup），见PrepareDelegatedCgroup
+
+// prctl选项
+const (
+	prSetSecurebits      = 28
+	prCapAmbient         = 47
+	prCapAmbientClearAll = 4
+)
+
+// securebits（linux/securebits.h）：uid为0时execve不再获得capability，并锁定该设置
+const (
+	secbitNoRoot       = 1 << 0
+	secbitNoRootLocked = 1 << 1
+)
+
+// capset使用的版本3接口（linux/capability.h）
+const linuxCapabilityVersion3 = 0x20080522
+
+type capUserHeader struct {
+	version uint32
+	pid     int32
+}
+
+type capUserData struct {
+	effective   uint32
+	permitted   uint32
+	inheritable uint32
+}
+
+// 在启动init的SysProcAttr上创建user namespace，并把沙箱内的root映射到判题服务的用户与组
+// 映射由Go运行时在子进程execve之前写入；非特权进程写入gid_map前必须禁用setgroups
+func setRootlessIDMappings(attr *syscall.SysProcAttr) {
+	attr.Cloneflags |= syscall.CLONE_NEWUSER
+	attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Geteuid(), Size: 1}}
+	attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getegid(), Size: 1}}
+	attr.GidMappingsEnableSetgroups = false
+}
+
+// 放弃user namespace中的全部capability：清空bounding集合与ambient集合，锁定SECBIT_NOROOT后清空当前集合
+// 之后execve的程序即使uid为0也不会重新获得capability
+func dropCapabilities() error {
+	lastCap, err := readLastCap()
+	if err != nil {
+		return err
+	}
+	for capability := 0; capability <= lastCap; capability++ {
+		if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, syscall.PR_CAPBSET_DROP, uintptr(capability), 0); errno != 0 {
+			return fmt.Errorf("failed to drop bounding capability %d: %w", capability, errno)
+		}
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prCapAmbient, prCapAmbientClearAll, 0); errno != 0 {
+		return fmt.Errorf("failed to clear ambient capabilities: %w", errno)
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetSecurebits, secbitNoRoot|secbitNoRootLocked, 0); errno != 0 {
+		return fmt.Errorf("failed to set securebits: %w", errno)
+	}
+
+	header := capUserHeader{version: linuxCapabilityVersion3}
+	var data [2]capUserData
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_CAPSET, uintptr(unsafe.Pointer(&header)), uintptr(unsafe.Pointer(&data[0])), 0); errno != 0 {
+		return fmt.Errorf("failed to clear capabilities: %w", errno)
+	}
+	return nil
+}
+
+// 内核支持的最大capability编号
+func readLastCap() (int, error) {
+	content, err := os.ReadFile("/proc/sys/kernel/cap_last_cap")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cap_last_cap: %w", err)
+	}
+	lastCap, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid cap_last_cap: %w", err)
+	}
+	return lastCap, nil
+}
+
+// 非特权进程只能降低硬限制：超过当前硬限制的部分按当前硬限制设置
+func clampRlimit(limit SandboxHelperRlimit) SandboxHelperRlimit {
+	var current syscall.Rlimit
+	if err := syscall.Getrlimit(limit.Resource, &current); err != nil {
+		return limit
+	}
+	if limit.Max > current.Max {
+		limit.Max = current.Max
+	}
+	if limit.Cur > limit.Max {
+		limit.Cur = limit.Max
+	}
+	return limit
+}
+
+// ChownToSandboxUser 将判题服务写入的文件交给沙箱内的运行用户(nobody)
+// rootless模式下沙箱内的root就是判题服务的用户，文件无需（也无法）修改所有者
+func ChownToSandboxUser(path string, rootless bool) error {
+	if rootless {
+		return nil
+	}
+	return os.Chown(path, 65534, 65534)
+}
+
+// PrepareDelegatedCgroup 准备rootless模式下判题控制组的上级目录，返回该目录
+// parent为空时使用判题服务自身所在的cgroup v2组（systemd以Delegate=yes启动，或容器中的/sys/fs/cgroup）
+// cgroup v2中有进程的组不能再向子组开放控制器，组内的进程（包括判题服务自身）先移入其下的supervisor子组
+func PrepareDelegatedCgroup(parent string) (string, error) {
+	if DetectCgroupVersion(CgroupRootPath) != CgroupV2 {
+		return "", fmt.Errorf("delegated cgroups require cgroup v2")
+	}
+	if parent == "" {
+		own, err := readOwnCgroupV2()
+		if err != nil {
+			return "", err
+		}
+		parent = filepath.Join(CgroupRootPath, own)
+	}
+
+	content, err := os.ReadFile(filepath.Join(parent, "cgroup.procs"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", parent, err)
+	}
+	pids := strings.Fields(string(content))
+	if len(pids) > 0 {
+		supervisor := filepath.Join(parent, "supervisor")
+		if err := os.MkdirAll(supervisor, 0755); err != nil {
+			return "", fmt.Errorf("failed to create %s: %w", supervisor, err)
+		}
+		for _, pid := range pids {
+			// 进程可能已经退出
+			if err := os.WriteFile(filepath.Join(supervisor, "cgroup.procs"), []byte(pid), 0644); err != nil && !errors.Is(err, syscall.ESRCH) {
+				return "", fmt.Errorf("failed to move process %s out of %s: %w", pid, parent, err)
+			}
+		}
+		logx.Infof("Moved %d processes into %s", len(pids), supervisor)
+	}
+
+	return parent, nil
+}
+
+// 当前进程在cgroup v2统一层级中的路径（/proc/self/cgroup中的"0::"行）
+func readOwnCgroupV2() (string, error) {
+	file, err := os.Open("/proc/self/cgroup")
+	if err != nil {
+		return "", fmt.Errorf("failed to read /proc/self/cgroup: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if path, ok := strings.CutPrefix(scanner.Text(), "0::"); ok {
+			return path, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read /proc/self/cgroup: %w", err)
+	}
+	return "", fmt.Errorf("no cgroup v2 entry in /proc/self/cgroup")
+}
@@ -0,0 +1,89 @@
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// rootless模式下目标程序是沙箱内没有任何capability的root，预先启动的init同样创建user namespace
+func TestExecuteRootlessDropsCapabilities(t *testing.T) {
+	pool := NewSandboxPool(SandboxPoolConfig{Name: "rootless-test", Zygotes: 1, Rootless: true})
+	defer pool.Close()
+
+	for _, pooled := range []bool{false, true} {
+		if pooled {
+			waitForPooledInit(t, pool)
+		}
+
+		result, output := runShellInSandboxWith(t, `id -u; grep -E '^Cap(Eff|Prm|Bnd)' /proc/self/status; cat /proc/self/uid_map`, func(config *SandboxConfig) {
+			config.Rootless = true
+			if pooled {
+				config.Pool = pool
+			}
+		})
+
+		if result.ExitCode != 0 {
+			t.Fatalf("pooled=%v: unexpected exit code %d, stderr %q", pooled, result.ExitCode, result.ErrorOutput)
+		}
+		lines := strings.Split(output, "\n")
+		if len(lines) != 5 || lines[0] != "0" {
+			t.Fatalf("pooled=%v: unexpected output %q", pooled, output)
+		}
+		for _, line := range lines[1:4] {
+			if !strings.HasSuffix(line, "0000000000000000") {
+				t.Fatalf("pooled=%v: capabilities not dropped: %q", pooled, line)
+			}
+		}
+		// 只映射了判题服务自身的用户
+		if fields := strings.Fields(lines[4]); len(fields) != 3 || fields[0] != "0" || fields[2] != "1" {
+			t.Fatalf("pooled=%v: unexpected uid_map %q", pooled, lines[4])
+		}
+	}
+
+	if stats := pool.Stats(); stats.ZygoteHits != 1 {
+		t.Fatalf("expected the pooled run to use pooled init, got %+v", stats)
+	}
+}
+
+// rootless模式下目标程序是判题服务写入的文件的所有者，只读挂载的守卫脚本与构建缓存仍不能被改写
+func TestExecuteRootlessReadOnlyMountsResistOwner(t *testing.T) {
+	cacheDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(cacheDir, "entry"), []byte("cached"), 0444); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(cacheDir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(cacheDir, 0755) })
+
+	var guardFile string
+	result, output := runShellInSandboxWith(t, `
+chmod 644 guard.js 2>/dev/null && echo guard-chmod
+{ echo replaced > guard.js; } 2>/dev/null && echo guard-written
+rm -f guard.js 2>/dev/null; [ -e guard.js ] || echo guard-removed
+chmod 755 "$CACHE" 2>/dev/null && echo cache-chmod
+{ echo replaced > "$CACHE/entry"; } 2>/dev/null && echo cache-written
+echo done`, func(config *SandboxConfig) {
+		guardFile = filepath.Join(config.WorkDir, "guard.js")
+		if err := os.WriteFile(guardFile, []byte("guard"), 0444); err != nil {
+			t.Fatal(err)
+		}
+		config.Rootless = true
+		config.EnableRootfs = true
+		config.ReadOnlyPaths = []string{"/bin", "/lib", "/lib64", "/usr", cacheDir, guardFile}
+		config.WritablePaths = []string{"/tmp", "/dev/null"}
+		config.Environment = append(config.Environment, "CACHE="+cacheDir)
+	})
+
+	if result.ExitCode != 0 || output != "done" {
+		t.Fatalf("read-only mounts were modified: exit=%d output=%q stderr=%q", result.ExitCode, output, result.ErrorOutput)
+	}
+	if content, err := os.ReadFile(guardFile); err != nil || string(content) != "guard" {
+		t.Fatalf("guard file changed on the host: %q %v", content, err)
+	}
+	if info, err := os.Stat(cacheDir); err != nil || info.Mode().Perm() != 0555 {
+		t.Fatalf("cache dir mode changed on the host: %v %v", info, err)
+	}
+}
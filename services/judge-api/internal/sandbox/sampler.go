@@ -0,0 +1,214 @@
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// 资源使用采样
+// 原理：执行期间按固定间隔记录内存使用与累计CPU时间，得到随时间变化的曲线，
+// 用于区分内存是逐渐增长还是瞬间暴涨（内存超限诊断），也供管理员调整Java/Python的内存倍数
+// 启用cgroups时读取控制组的内存使用量与CPU时间，覆盖整棵进程树；
+// 否则沿/proc/<pid>/task/<tid>/children遍历init的全部后代进程（不含init自身），累加常驻内存与CPU时间
+// 样本数达到上限后两两合并（内存取较大值，保留峰值）并加倍采样间隔，长时间运行的程序也只保留有限的样本
+
+// 保留的最大样本数
+const maxResourceSamples = 512
+
+// /proc/<pid>/stat中CPU时间的单位（USER_HZ，Linux上固定为100）
+const procClockTicksPerSecond = 100
+
+// ResourceSample 一次资源采样
+type ResourceSample struct {
+	TimeMs    int64 `json:"t"`   // 距目标程序启动的时间(毫秒)
+	MemoryKB  int64 `json:"mem"` // 内存使用(KB)
+	CPUTimeMs int64 `json:"cpu"` // 累计CPU时间(毫秒)
+}
+
+// 执行期间的资源采样器
+type resourceSampler struct {
+	interval time.Duration
+	start    time.Time
+	read     func() (memoryKB, cpuTimeMs int64, ok bool)
+	samples  []ResourceSample
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// 开始采样，未配置采样间隔时返回nil
+func (s *SystemCallSandbox) startResourceSampler(initPid int, start time.Time) *resourceSampler {
+	if s.config.ResourceSampleIntervalMs <= 0 {
+		return nil
+	}
+
+	sampler := &resourceSampler{
+		interval: time.Duration(s.config.ResourceSampleIntervalMs) * time.Millisecond,
+		start:    start,
+		stop:     make(chan struct{}),
+	}
+	if s.config.EnableCgroups && s.cgroupManager != nil {
+		manager := s.cgroupManager
+		sampler.read = func() (int64, int64, bool) {
+			memory, cpu, err := manager.SampleUsage()
+			if err != nil {
+				return 0, 0, false
+			}
+			return memory / 1024, cpu / int64(time.Millisecond), true
+		}
+	} else {
+		sampler.read = func() (int64, int64, bool) {
+			return readProcessTreeUsage(initPid)
+		}
+	}
+
+	sampler.wg.Add(1)
+	go sampler.run()
+	return sampler
+}
+
+func (r *resourceSampler) run() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case now := <-ticker.C:
+			memoryKB, cpuTimeMs, ok := r.read()
+			if !ok {
+				// 目标程序尚未启动或进程树已退出
+				continue
+			}
+			r.samples = append(r.samples, ResourceSample{
+				TimeMs:    now.Sub(r.start).Milliseconds(),
+				MemoryKB:  memoryKB,
+				CPUTimeMs: cpuTimeMs,
+			})
+			if len(r.samples) >= maxResourceSamples {
+				r.samples = mergeResourceSamples(r.samples)
+				r.interval *= 2
+				ticker.Reset(r.interval)
+			}
+		}
+	}
+}
+
+// 停止采样并返回样本
+func (r *resourceSampler) finish() []ResourceSample {
+	if r == nil {
+		return nil
+	}
+	close(r.stop)
+	r.wg.Wait()
+
+	logx.Debugf("Collected %d resource samples at %v", len(r.samples), r.interval)
+	return r.samples
+}
+
+// 相邻样本两两合并：时间与CPU取后一个，内存取较大值
+func mergeResourceSamples(samples []ResourceSample) []ResourceSample {
+	merged := samples[:0]
+	for i := 0; i < len(samples); i += 2 {
+		sample := samples[i]
+		if i+1 < len(samples) {
+			next := samples[i+1]
+			sample.TimeMs, sample.CPUTimeMs = next.TimeMs, next.CPUTimeMs
+			if next.MemoryKB > sample.MemoryKB {
+				sample.MemoryKB = next.MemoryKB
+			}
+		}
+		merged = append(merged, sample)
+	}
+	return merged
+}
+
+// 累加init全部后代进程的常驻内存（KB）与CPU时间（毫秒）
+// 已退出子进程的CPU时间计入回收它的父进程（cutime/cstime）；被init回收的孤儿进程不再计入，以最终的rusage为准
+func readProcessTreeUsage(initPid int) (int64, int64, bool) {
+	var memoryKB, cpuTicks int64
+	found := false
+
+	pending := procChildren(initPid)
+	for len(pending) > 0 {
+		pid := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		rssKB, ticks, ok := readProcessUsage(pid)
+		if !ok {
+			continue
+		}
+		found = true
+		memoryKB += rssKB
+		cpuTicks += ticks
+		pending = append(pending, procChildren(pid)...)
+	}
+	return memoryKB, cpuTicks * 1000 / procClockTicksPerSecond, found
+}
+
+// 进程所有线程的子进程
+func procChildren(pid int) []int {
+	files, _ := filepath.Glob(filepath.Join("/proc", strconv.Itoa(pid), "task", "*", "children"))
+
+	var children []int
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for _, field := range strings.Fields(string(content)) {
+			if child, err := strconv.Atoi(field); err == nil {
+				children = append(children, child)
+			}
+		}
+	}
+	return children
+}
+
+// 读取单个进程的常驻内存（KB，来自status的VmRSS）与CPU时间（时钟滴答，utime+stime+cutime+cstime）
+func readProcessUsage(pid int) (int64, int64, bool) {
+	procDir := filepath.Join("/proc", strconv.Itoa(pid))
+
+	stat, err := os.ReadFile(filepath.Join(procDir, "stat"))
+	if err != nil {
+		return 0, 0, false
+	}
+	// 进程名可能包含空格与括号，从最后一个右括号之后开始解析；其后第12至15个字段为utime、stime、cutime、cstime
+	end := strings.LastIndexByte(string(stat), ')')
+	if end < 0 {
+		return 0, 0, false
+	}
+	fields := strings.Fields(string(stat[end+1:]))
+	// 僵尸进程已释放内存，不计入
+	if len(fields) < 15 || fields[0] == "Z" {
+		return 0, 0, false
+	}
+	var ticks int64
+	for _, field := range fields[11:15] {
+		value, _ := strconv.ParseInt(field, 10, 64)
+		ticks += value
+	}
+
+	var rssKB int64
+	status, err := os.ReadFile(filepath.Join(procDir, "status"))
+	if err == nil {
+		for _, line := range strings.Split(string(status), "\n") {
+			if strings.HasPrefix(line, "VmRSS:") {
+				fields := strings.Fields(line)
+				if len(fields) >= 2 {
+					rssKB, _ = strconv.ParseInt(fields[1], 10, 64)
+				}
+				break
+			}
+		}
+	}
+	return rssKB, ticks, true
+}
@@ -0,0 +1,57 @@
+package sandbox
+
+import (
+	"testing"
+)
+
+func TestMergeResourceSamplesKeepsPeak(t *testing.T) {
+	samples := []ResourceSample{
+		{TimeMs: 10, MemoryKB: 100, CPUTimeMs: 5},
+		{TimeMs: 20, MemoryKB: 900, CPUTimeMs: 12},
+		{TimeMs: 30, MemoryKB: 300, CPUTimeMs: 20},
+		{TimeMs: 40, MemoryKB: 200, CPUTimeMs: 28},
+		{TimeMs: 50, MemoryKB: 250, CPUTimeMs: 35},
+	}
+	merged := mergeResourceSamples(samples)
+
+	want := []ResourceSample{
+		{TimeMs: 20, MemoryKB: 900, CPUTimeMs: 12},
+		{TimeMs: 40, MemoryKB: 300, CPUTimeMs: 28},
+		{TimeMs: 50, MemoryKB: 250, CPUTimeMs: 35},
+	}
+	if len(merged) != len(want) {
+		t.Fatalf("expected %d samples, got %+v", len(want), merged)
+	}
+	for i := range want {
+		if merged[i] != want[i] {
+			t.Fatalf("sample %d = %+v, want %+v", i, merged[i], want[i])
+		}
+	}
+}
+
+func TestExecuteSamplesGrowingMemory(t *testing.T) {
+	// 每50毫秒追加约1MB，运行约半秒
+	result, _ := runShellInSandboxWith(t, `s=x; i=0; while [ $i -lt 10 ]; do s="$s$(head -c 1048576 /dev/zero | tr '\0' x)"; i=$((i+1)); sleep 0.05; done`, func(config *SandboxConfig) {
+		config.ResourceSampleIntervalMs = 10
+		config.MemoryLimit = 262144
+	})
+
+	samples := result.ResourceUsage.Samples
+	if len(samples) < 10 {
+		t.Fatalf("expected samples every 10ms over ~500ms, got %d", len(samples))
+	}
+	for i := 1; i < len(samples); i++ {
+		if samples[i].TimeMs < samples[i-1].TimeMs || samples[i].CPUTimeMs < samples[i-1].CPUTimeMs {
+			t.Fatalf("samples must be monotonic in time and CPU: %+v then %+v", samples[i-1], samples[i])
+		}
+	}
+	first, peak := samples[0].MemoryKB, int64(0)
+	for _, sample := range samples {
+		if sample.MemoryKB > peak {
+			peak = sample.MemoryKB
+		}
+	}
+	if peak < first+4096 {
+		t.Fatalf("expected memory to grow by several MB, first=%dKB peak=%dKB", first, peak)
+	}
+}
@@ -3,10 +3,14 @@ package sandbox
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 	"unsafe"
@@ -23,6 +27,33 @@ const (
 	StatusRuntimeError
 	StatusSystemError
 	StatusCompileError
+	StatusRestrictedFunction // 调用了被seccomp禁止的系统调用
+)
+
+// 执行状态的名称，与判题结果中的状态一致（沙箱不比较输出，正常结束为accepted）
+var statusNames = map[int]string{
+	StatusAccepted:            "accepted",
+	StatusTimeLimitExceeded:   "time_limit_exceeded",
+	StatusMemoryLimitExceeded: "memory_limit_exceeded",
+	StatusOutputLimitExceeded: "output_limit_exceeded",
+	StatusRuntimeError:        "runtime_error",
+	StatusSystemError:         "system_error",
+	StatusCompileError:        "compile_error",
+	StatusRestrictedFunction:  "restricted_function",
+}
+
+// StatusName 返回执行状态的名称，未知状态返回system_error
+func StatusName(status int) string {
+	if name, ok := statusNames[status]; ok {
+		return name
+	}
+	return "system_error"
+}
+
+// 时间限制的度量方式
+const (
+	TimeLimitMetricCPU  = "cpu"  // CPU时间（整棵进程树的user+sys）超过TimeLimit判为超时
+	TimeLimitMetricWall = "wall" // 墙钟时间超过TimeLimit判为超时，适用于交互题等需要计入等待时间的题目
 )
 
 // 沙箱配置
@@ -33,17 +64,42 @@ type SandboxConfig struct {
 	Chroot  string // chroot根目录
 	WorkDir string // 工作目录
 
+	// 最小根文件系统：在新的mount namespace中pivot_root到tmpfs，只挂载配置的路径（优先于Chroot）
+	EnableRootfs  bool     // 启用最小根文件系统
+	ReadOnlyPaths []string // 只读绑定挂载的主机路径（工具链、动态库等）
+	WritablePaths []string // 可写路径（目录为独立tmpfs，设备文件读写绑定挂载）
+
 	// 资源限制
-	TimeLimit     int64 // CPU时间限制(毫秒)
-	WallTimeLimit int64 // 墙钟时间限制(毫秒)
+	TimeLimit     int64 // 时间限制(毫秒)，按TimeLimitMetric度量
+	WallTimeLimit int64 // 墙钟时间限制(毫秒)，CPU计时下用于终止sleep、阻塞读等空闲程序
 	MemoryLimit   int64 // 内存限制(KB)
 	StackLimit    int64 // 栈大小限制(KB)
 	FileSizeLimit int64 // 文件大小限制(KB)
 	ProcessLimit  int   // 进程数限制
+	OutputLimit   int64 // 标准输出、标准错误各自的字节数上限，0表示不限制
+
+	// 资源采样间隔(毫秒)，执行期间记录内存与CPU时间的变化（见sampler.go），0表示不采样
+	ResourceSampleIntervalMs int64
+
+	// 时间限制的度量：cpu（默认，整棵进程树的user+sys时间）或wall（墙钟时间）
+	TimeLimitMetric string
+
+	// V8/JVM等运行时启动时预留大量虚拟地址空间，RLIMIT_AS按内存限制设置会导致无法启动
+	// 开启后不限制虚拟地址空间，内存由cgroups与运行时自身的堆上限控制
+	SkipAddressSpaceLimit bool
 
 	// 系统调用控制
-	AllowedSyscalls []int // 允许的系统调用号
-	EnableSeccomp   bool  // 启用seccomp过滤
+	AllowedSyscalls []int            // 允许的系统调用号
+	DeniedSyscalls  []int            // 显式禁止的系统调用号（优先于白名单）
+	SyscallArgRules []SyscallArgRule // 白名单中系统调用的参数规则（如clone只能创建线程）
+	EnableSeccomp   bool             // 启用seccomp过滤
+
+	// 学习模式：不限制系统调用，记录目标程序使用的全部系统调用（见trace.go，仅用于管理命令）
+	TraceSyscalls bool
+
+	// 每次执行都位于独立的network namespace中，只有loopback接口
+	// 开启后放行套接字相关系统调用，供需要本地套接字（如客户端/服务端交互）的题目使用
+	AllowLocalSockets bool
 
 	// 输入输出
 	InputFile  string // 输入文件路径
@@ -75,9 +131,16 @@ type SandboxConfig struct {
 
 	// cgroups高级配置
 	CPUQuotaPercent int     // CPU配额百分比（0-100）
-	CPUSetCores     string  // 绑定的CPU核心（如"0-1"或"0,2"）
+	CPUSetCores     string  // 绑定的CPU核心（如"0-1"或"0,2"），执行阶段设置CPU亲和性，启用cgroups时同时写入cpuset.cpus
 	MemorySwapRatio float64 // 内存swap比例（swap = memory * ratio）
 	IOWeightPercent int     // I/O权重百分比（10-1000）
+	CgroupParent    string  // 判题控制组所在的cgroup v2目录（rootless模式下为委派的子树），为空时为/sys/fs/cgroup
+
+	// 无root权限运行（见rootless.go）：沙箱内的root映射到判题服务的用户，不切换UID/GID，execve前放弃全部capability
+	Rootless bool
+
+	// 预热的资源池（预先启动的init与可复用的控制组，见pool.go），为空时每次执行临时创建
+	Pool *SandboxPool
 }
 
 // 执行结果
@@ -85,11 +148,20 @@ type ExecuteResult struct {
 	Status      int    // 执行状态
 	ExitCode    int    // 退出码
 	Signal      int    // 信号
-	TimeUsed    int64  // 实际使用时间(毫秒)
+	TimeUsed    int64  // 按TimeLimitMetric计的使用时间(毫秒)
+	CPUTime     int64  // CPU时间(毫秒)，整棵进程树的user+sys
+	WallTime    int64  // 墙钟时间(毫秒)，从目标程序启动到主进程结束
+	StartupTime int64  // 运行时启动耗时(毫秒)，已从各项时间中扣除（如JVM）
 	MemoryUsed  int64  // 实际使用内存(KB)
 	OutputSize  int64  // 输出大小
 	ErrorOutput string // 错误信息
 
+	// StatusRestrictedFunction时被seccomp拦截的系统调用
+	RestrictedSyscall     int    // 系统调用号
+	RestrictedSyscallName string // 系统调用名称，如socket
+
+	Syscalls map[int]int // 学习模式下观察到的系统调用号及调用次数
+
 	// 详细资源使用统计
 	ResourceUsage *ResourceUsageDetail `json:"resource_usage,omitempty"`
 }
@@ -98,7 +170,7 @@ type ExecuteResult struct {
 type ResourceUsageDetail struct {
 	// setrlimit统计数据
 	SetrlimitStats struct {
-		CPUTimeUsed  int64 // CPU时间使用(毫秒)
+		CPUTimeUsed  int64 // CPU时间使用(毫秒)，user+sys
 		MaxRSSUsed   int64 // 最大常驻内存(KB)
 		WallTimeUsed int64 // 墙钟时间(毫秒)
 	} `json:"setrlimit_stats"`
@@ -116,8 +188,17 @@ type ResourceUsageDetail struct {
 		IOWriteBytes    int64   // I/O写入字节数
 	} `json:"cgroups_stats"`
 
+	// 进程树统计（由PID namespace中的init进程上报）
+	ProcessStats struct {
+		Processes     int // 沙箱内回收的进程总数（含主进程）
+		OrphansKilled int // 主进程结束时仍存活、被强制终止的进程数（如守护进程化的子进程）
+	} `json:"process_stats"`
+
+	// 执行期间的内存与CPU时间采样（按ResourceSampleIntervalMs），用于观察内存是逐渐增长还是瞬间暴涨
+	Samples []ResourceSample `json:"samples,omitempty"`
+
 	// 综合判断结果
-	LimitExceeded   string `json:"limit_exceeded"` // 超限类型："memory", "cpu", "time", "none"
+	LimitExceeded   string `json:"limit_exceeded"` // 超限类型："memory", "cpu"（CPU时间）, "wall"（墙钟/空闲超时）, "output", "pids", "none"
 	ControlMethod   string `json:"control_method"` // 控制方式："setrlimit", "cgroups", "hybrid"
 	PerformanceData struct {
 		SetupTimeMs   int64 // 资源控制设置耗时(毫秒)
@@ -130,6 +211,9 @@ type ResourceUsageDetail struct {
 type SystemCallSandbox struct {
 	config        *SandboxConfig
 	cgroupManager *CgroupManager // cgroup管理器
+	cgroupPooled  bool           // 控制组取自资源池，执行结束后归还而不是删除
+	rootfsDir     string         // 新根的挂载点（仅存在于本次执行期间）
+	output        *outputCapture // 标准输出与标准错误的监控（仅存在于本次执行期间）
 }
 
 // 创建新的沙箱
@@ -153,9 +237,10 @@ func (s *SystemCallSandbox) buildCgroupConfig() *CgroupConfig {
 	groupName := fmt.Sprintf("judge_%s_%s_%d", s.config.Language, s.config.TaskID, time.Now().UnixNano())
 
 	config := &CgroupConfig{
-		GroupName: groupName,
-		Language:  s.config.Language,
-		TaskID:    s.config.TaskID,
+		GroupName:  groupName,
+		Language:   s.config.Language,
+		TaskID:     s.config.TaskID,
+		ParentPath: s.config.CgroupParent,
 
 		// 内存配置
 		MemoryLimitBytes: s.config.MemoryLimit * 1024, // KB转字节
@@ -211,119 +296,147 @@ func (s *SystemCallSandbox) buildCgroupConfig() *CgroupConfig {
 func (s *SystemCallSandbox) Execute(ctx context.Context, executable string, args []string) (*ExecuteResult, error) {
 	logx.Infof("Starting execution: %s %v", executable, args)
 
-	// 创建命令
-	cmd := exec.CommandContext(ctx, executable, args...)
-
-	// 设置进程属性
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		// 创建新的命名空间 - 构建Cloneflags
-		Cloneflags: s.buildCloneFlags(),
-		// 设置用户和组
-		Credential: &syscall.Credential{
-			Uid: uint32(s.config.UID),
-			Gid: uint32(s.config.GID),
-		},
-		// 设置chroot
-		Chroot: s.config.Chroot,
-	}
+	// 如果需要设置UTS/IPC/Cgroup Namespace，需要在子进程中执行初始化
+	needsNamespaceWrapper := s.config.EnableUTSNS || s.config.EnableIPCNS || s.config.EnableCgroupNS
 
-	// 处理seccomp过滤器初始化
-	finalExecutable := executable
-	finalArgs := args
+	// 设置双层资源限制：setrlimit + cgroups
+	// cgroup需要在构建辅助进程配置之前创建，执行阶段启动后首先加入其中
+	setupStart := time.Now()
+	if err := s.setupResourceLimits(); err != nil {
+		return nil, fmt.Errorf("failed to setup resource limits: %w", err)
+	}
+	setupTime := time.Since(setupStart).Milliseconds()
 
-	if s.config.EnableSeccomp {
-		// 创建seccomp初始化程序
-		seccompInit, err := s.createSeccompInitializer(executable, args, s.config.WorkDir)
+	if s.config.EnableRootfs {
+		// tmpfs挂载在辅助进程的mount namespace中，主机上只留下一个空目录，执行结束后删除
+		rootfsDir, err := os.MkdirTemp("", "judge-rootfs-")
 		if err != nil {
-			return nil, fmt.Errorf("failed to create seccomp initializer: %w", err)
+			return nil, fmt.Errorf("failed to create rootfs mount point: %w", err)
 		}
-		finalExecutable = seccompInit
-		finalArgs = []string{} // seccomp初始化程序不需要额外参数
+		defer os.Remove(rootfsDir)
+		s.rootfsDir = rootfsDir
+	}
 
-		// 延迟清理seccomp相关文件
-		defer s.cleanupSeccompFiles(s.config.WorkDir)
+	// 以辅助模式重新执行判题服务：init成为PID namespace的1号进程，
+	// 执行阶段完成loopback、根文件系统、降权、rlimit与过滤器后execve目标程序
+	helperConfig, err := s.buildSandboxHelperConfig(executable, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sandbox helper config: %w", err)
+	}
+	if s.config.TraceSyscalls {
+		return s.executeTraced(ctx, helperConfig)
 	}
 
-	// 如果需要设置UTS/IPC/Cgroup Namespace，需要在子进程中执行初始化
-	if s.config.EnableUTSNS || s.config.EnableIPCNS || s.config.EnableCgroupNS {
-		// 创建一个包装脚本来处理Namespace初始化
-		wrapperScript, err := s.createNamespaceWrapper(finalExecutable, finalArgs)
+	// 设置输入输出重定向
+	stdin, stdout, stderr, err := s.openIO()
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup IO: %w", err)
+	}
+	defer s.output.close()
+	if stdin != nil {
+		defer stdin.Close()
+	}
+
+	// 资源池中有预先启动的init时直接交付本次执行，省去启动判题服务与创建namespace的开销
+	var cmd *exec.Cmd
+	var reportReader *os.File
+	var startTime time.Time
+	if zygote := s.takePooledInit(needsNamespaceWrapper); zygote != nil {
+		startTime = time.Now()
+		if err := zygote.deliver(helperConfig, stdin, stdout, stderr); err != nil {
+			logx.Errorf("Failed to hand over to pooled sandbox init, starting a new one: %v", err)
+			s.config.Pool.zygoteFailed()
+			zygote.discard()
+		} else {
+			cmd, reportReader = zygote.cmd, zygote.report
+			stop := context.AfterFunc(ctx, func() { cmd.Process.Kill() })
+			defer stop()
+			logx.Infof("Process handed over to pooled sandbox init with PID: %d", cmd.Process.Pid)
+		}
+	}
+
+	if cmd == nil {
+		cmd, reportReader, err = newSandboxInitCommand(ctx, helperConfig)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create namespace wrapper: %w", err)
+			return nil, fmt.Errorf("failed to create sandbox helper: %w", err)
+		}
+		defer closeSandboxHelperFiles(cmd)
+
+		if needsNamespaceWrapper {
+			// 包装脚本完成Namespace初始化后exec辅助进程，辅助模式环境变量与配置管道随之继承
+			wrapperScript, err := s.createNamespaceWrapper(cmd.Path, nil)
+			if err != nil {
+				reportReader.Close()
+				return nil, fmt.Errorf("failed to create namespace wrapper: %w", err)
+			}
+			defer os.Remove(wrapperScript)
+			cmd.Path, cmd.Args = "/bin/sh", []string{"/bin/sh", wrapperScript}
 		}
-		defer os.Remove(wrapperScript)
 
-		// 使用包装脚本替换命令
-		cmd = exec.CommandContext(ctx, "/bin/sh", wrapperScript)
+		// 辅助进程需要root权限完成网络、挂载、chroot与降权，这里只创建命名空间
+		// 判题服务意外退出时init随之被杀死，内核再杀死namespace中的所有进程
 		cmd.SysProcAttr = &syscall.SysProcAttr{
 			Cloneflags: s.buildCloneFlags(),
-			Credential: &syscall.Credential{
-				Uid: uint32(s.config.UID),
-				Gid: uint32(s.config.GID),
-			},
-			Chroot: s.config.Chroot,
+			Pdeathsig:  syscall.SIGKILL,
 		}
-	} else if s.config.EnableSeccomp {
-		// 只需要seccomp，直接使用初始化程序
-		cmd = exec.CommandContext(ctx, finalExecutable, finalArgs...)
-		cmd.SysProcAttr = &syscall.SysProcAttr{
-			Cloneflags: s.buildCloneFlags(),
-			Credential: &syscall.Credential{
-				Uid: uint32(s.config.UID),
-				Gid: uint32(s.config.GID),
-			},
-			Chroot: s.config.Chroot,
+		if s.config.Rootless {
+			setRootlessIDMappings(cmd.SysProcAttr)
+		}
+		// chroot时工作目录是沙箱内的路径，由辅助进程在chroot后切换
+		if s.config.Chroot == "" {
+			cmd.Dir = s.config.WorkDir
+		}
+		// nil的*os.File不能赋给io接口，否则exec会把它当作有效文件
+		if stdin != nil {
+			cmd.Stdin = stdin
+		}
+		if stdout != nil {
+			cmd.Stdout = stdout
+		}
+		if stderr != nil {
+			cmd.Stderr = stderr
 		}
-	}
-
-	// 设置工作目录
-	cmd.Dir = s.config.WorkDir
-
-	// 设置环境变量
-	cmd.Env = s.config.Environment
-
-	// 设置输入输出重定向
-	if err := s.setupIO(cmd); err != nil {
-		return nil, fmt.Errorf("failed to setup IO: %w", err)
-	}
-
-	// 设置双层资源限制：setrlimit + cgroups
-	setupStart := time.Now()
-	if err := s.setupResourceLimits(); err != nil {
-		return nil, fmt.Errorf("failed to setup resource limits: %w", err)
-	}
-	setupTime := time.Since(setupStart).Milliseconds()
 
-	// 启动进程
-	startTime := time.Now()
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start process: %w", err)
-	}
+		// 启动进程
+		startTime = time.Now()
+		if err := cmd.Start(); err != nil {
+			reportReader.Close()
+			return nil, fmt.Errorf("failed to start process: %w", err)
+		}
 
-	logx.Infof("Process started with PID: %d", cmd.Process.Pid)
+		logx.Infof("Process started with PID: %d", cmd.Process.Pid)
 
-	// 设置User Namespace的uid/gid映射（必须在进程启动后立即设置）
-	if err := s.setupUserNamespaceMapping(cmd.Process.Pid); err != nil {
-		cmd.Process.Kill()
-		return nil, fmt.Errorf("failed to setup user namespace mapping: %w", err)
-	}
+		// 管道写端已由init继承，父进程持有的副本需要关闭，init退出后读端才能读到EOF
+		closeSandboxHelperFiles(cmd)
 
-	// 将进程添加到cgroup（如果启用）
-	if s.config.EnableCgroups && s.cgroupManager != nil {
-		if err := s.cgroupManager.AddProcess(cmd.Process.Pid); err != nil {
+		// 设置User Namespace的uid/gid映射（必须在进程启动后立即设置）
+		if err := s.setupUserNamespaceMapping(cmd.Process.Pid); err != nil {
 			cmd.Process.Kill()
-			return nil, fmt.Errorf("failed to add process to cgroup: %w", err)
+			cmd.Wait()
+			reportReader.Close()
+			return nil, fmt.Errorf("failed to setup user namespace mapping: %w", err)
 		}
-		logx.Infof("Process %d added to cgroup: %s", cmd.Process.Pid, s.cgroupManager.config.GroupName)
 	}
+	defer reportReader.Close()
+
+	// 输出超限时杀死init，内核随之杀死namespace中的所有进程
+	initPid := cmd.Process.Pid
+	s.output.start(func() {
+		logx.Infof("Output limit exceeded: limit=%d bytes", s.config.OutputLimit)
+		syscall.Kill(initPid, syscall.SIGKILL)
+	})
+
+	// 执行阶段启动后自行加入cgroup（见CgroupProcs），init本身不计入进程数与资源统计
 
 	// 记录Namespace信息用于调试
 	nsInfo := s.getNamespaceInfo(cmd.Process.Pid)
 	logx.Infof("Process Namespace info: %+v", nsInfo)
 
-	// 监控进程执行
+	// 监控进程执行，同时按间隔采样资源使用
 	monitorStart := time.Now()
-	result, err := s.monitorProcessWithCgroups(cmd.Process.Pid, startTime)
+	sampler := s.startResourceSampler(cmd.Process.Pid, startTime)
+	result, err := s.monitorProcessWithCgroups(cmd.Process.Pid, startTime, reportReader)
+	samples := sampler.finish()
 	if err != nil {
 		// 确保进程被终止
 		cmd.Process.Kill()
@@ -332,6 +445,9 @@ func (s *SystemCallSandbox) Execute(ctx context.Context, executable string, args
 		return nil, fmt.Errorf("failed to monitor process: %w", err)
 	}
 	monitorTime := time.Since(monitorStart).Milliseconds()
+	if result.ResourceUsage != nil {
+		result.ResourceUsage.Samples = samples
+	}
 
 	// 等待进程结束
 	cmd.Wait()
@@ -361,34 +477,50 @@ func (s *SystemCallSandbox) Execute(ctx context.Context, executable string, args
 
 // 设置输入输出重定向
 func (s *SystemCallSandbox) setupIO(cmd *exec.Cmd) error {
+	stdin, stdout, stderr, err := s.openIO()
+	if err != nil {
+		return err
+	}
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
+	if stderr != nil {
+		cmd.Stderr = stderr
+	}
+
+	return nil
+}
+
+// 打开输入文件并创建输出监控，返回交给目标程序的标准输入、标准输出、标准错误（未配置的为nil）
+func (s *SystemCallSandbox) openIO() (stdin, stdout, stderr *os.File, err error) {
 	// 设置输入文件
 	if s.config.InputFile != "" {
-		inputFile, err := os.Open(s.config.InputFile)
+		stdin, err = os.Open(s.config.InputFile)
 		if err != nil {
-			return fmt.Errorf("failed to open input file: %w", err)
+			return nil, nil, nil, fmt.Errorf("failed to open input file: %w", err)
 		}
-		cmd.Stdin = inputFile
 	}
 
-	// 设置输出文件
-	if s.config.OutputFile != "" {
-		outputFile, err := os.Create(s.config.OutputFile)
-		if err != nil {
-			return fmt.Errorf("failed to create output file: %w", err)
+	// 标准输出与标准错误经由管道写入文件，判题服务计数并在超限时终止进程树
+	output, err := newOutputCapture(s.config.OutputFile, s.config.ErrorFile, s.config.OutputLimit)
+	if err != nil {
+		if stdin != nil {
+			stdin.Close()
 		}
-		cmd.Stdout = outputFile
+		return nil, nil, nil, err
 	}
-
-	// 设置错误输出文件
-	if s.config.ErrorFile != "" {
-		errorFile, err := os.Create(s.config.ErrorFile)
-		if err != nil {
-			return fmt.Errorf("failed to create error file: %w", err)
-		}
-		cmd.Stderr = errorFile
+	s.output = output
+	if output.stdout != nil {
+		stdout = output.stdout.writer
+	}
+	if output.stderr != nil {
+		stderr = output.stderr.writer
 	}
 
-	return nil
+	return stdin, stdout, stderr, nil
 }
 
 // 设置双层资源限制：setrlimit + cgroups
@@ -407,15 +539,8 @@ func (s *SystemCallSandbox) setupResourceLimits() error {
 		}
 	}
 
-	// 2. 设置setrlimit（作为基础保护或降级方案）
-	if err := s.setupSetrlimit(); err != nil {
-		if !s.config.EnableCgroups || s.cgroupManager == nil {
-			return fmt.Errorf("setrlimit setup failed: %w", err)
-		}
-		logx.Errorf("setrlimit setup failed, relying on cgroups: %v", err)
-	} else {
-		logx.Info("setrlimit resource control enabled")
-	}
+	// 2. setrlimit（作为基础保护或降级方案）由辅助进程在execve前设置，判题服务自身不受影响
+	logx.Debugf("setrlimit delegated to sandbox helper")
 
 	return nil
 }
@@ -426,6 +551,15 @@ func (s *SystemCallSandbox) setupCgroups() error {
 		return fmt.Errorf("cgroup manager not initialized")
 	}
 
+	// 优先复用资源池中的控制组，只需重新设置限制
+	if s.config.Pool != nil {
+		if manager := s.config.Pool.takeCgroup(s.cgroupManager.config); manager != nil {
+			s.cgroupManager, s.cgroupPooled = manager, true
+			logx.Infof("Using pooled cgroup: %s", manager.config.GroupName)
+			return nil
+		}
+	}
+
 	// 创建cgroup控制组
 	if err := s.cgroupManager.Create(); err != nil {
 		return fmt.Errorf("failed to create cgroup: %w", err)
@@ -435,61 +569,48 @@ func (s *SystemCallSandbox) setupCgroups() error {
 	return nil
 }
 
-// 设置setrlimit基础资源限制
-func (s *SystemCallSandbox) setupSetrlimit() error {
+// 根据配置构建rlimit列表
+func (s *SystemCallSandbox) buildRlimits() []SandboxHelperRlimit {
+	var limits []SandboxHelperRlimit
+
 	// CPU时间限制（配合cgroups时设置为宽松值）
+	// 精度只有秒，向上取整后作为兜底，是否超时以精确的CPU时间判定；硬限制多留1秒，先收到SIGXCPU
 	if s.config.TimeLimit > 0 {
-		timeLimit := s.config.TimeLimit / 1000 // 转换为秒
+		timeLimit := (s.config.TimeLimit + 999) / 1000 // 转换为秒
 		if s.config.EnableCgroups {
 			// cgroups模式下，setrlimit设置为2倍作为兜底保护
 			timeLimit *= 2
 		}
-		if err := syscall.Setrlimit(syscall.RLIMIT_CPU, &syscall.Rlimit{
-			Cur: uint64(timeLimit),
-			Max: uint64(timeLimit),
-		}); err != nil {
-			return fmt.Errorf("failed to set CPU time limit: %w", err)
-		}
+		limits = append(limits, SandboxHelperRlimit{Resource: syscall.RLIMIT_CPU, Cur: uint64(timeLimit), Max: uint64(timeLimit + 1)})
 		logx.Debugf("Set CPU time limit: %d seconds", timeLimit)
 	}
 
 	// 内存限制（配合cgroups时设置为虚拟内存保护）
-	if s.config.MemoryLimit > 0 {
+	if s.config.SkipAddressSpaceLimit {
+		// rlimit会被后续子进程继承，需显式解除之前执行留下的限制
+		limits = append(limits, SandboxHelperRlimit{Resource: syscall.RLIMIT_AS, Cur: ^uint64(0), Max: ^uint64(0)}) // RLIM_INFINITY
+		logx.Debugf("Virtual memory limit skipped, relying on runtime heap limit")
+	} else if s.config.MemoryLimit > 0 {
 		memoryLimit := s.config.MemoryLimit * 1024 // 转换为字节
 		if s.config.EnableCgroups {
 			// cgroups模式下，setrlimit设置为2倍防止虚拟内存爆炸
 			memoryLimit *= 2
 		}
-		if err := syscall.Setrlimit(syscall.RLIMIT_AS, &syscall.Rlimit{
-			Cur: uint64(memoryLimit),
-			Max: uint64(memoryLimit),
-		}); err != nil {
-			return fmt.Errorf("failed to set memory limit: %w", err)
-		}
+		limits = append(limits, SandboxHelperRlimit{Resource: syscall.RLIMIT_AS, Cur: uint64(memoryLimit), Max: uint64(memoryLimit)})
 		logx.Debugf("Set virtual memory limit: %d bytes", memoryLimit)
 	}
 
 	// 栈大小限制（cgroups无法控制，setrlimit主控）
 	if s.config.StackLimit > 0 {
 		stackLimit := s.config.StackLimit * 1024 // 转换为字节
-		if err := syscall.Setrlimit(syscall.RLIMIT_STACK, &syscall.Rlimit{
-			Cur: uint64(stackLimit),
-			Max: uint64(stackLimit),
-		}); err != nil {
-			return fmt.Errorf("failed to set stack limit: %w", err)
-		}
+		limits = append(limits, SandboxHelperRlimit{Resource: syscall.RLIMIT_STACK, Cur: uint64(stackLimit), Max: uint64(stackLimit)})
 		logx.Debugf("Set stack limit: %d bytes", stackLimit)
 	}
 
 	// 文件大小限制（cgroups无法控制，setrlimit主控）
 	if s.config.FileSizeLimit > 0 {
 		fileSizeLimit := s.config.FileSizeLimit * 1024 // 转换为字节
-		if err := syscall.Setrlimit(syscall.RLIMIT_FSIZE, &syscall.Rlimit{
-			Cur: uint64(fileSizeLimit),
-			Max: uint64(fileSizeLimit),
-		}); err != nil {
-			return fmt.Errorf("failed to set file size limit: %w", err)
-		}
+		limits = append(limits, SandboxHelperRlimit{Resource: syscall.RLIMIT_FSIZE, Cur: uint64(fileSizeLimit), Max: uint64(fileSizeLimit)})
 		logx.Debugf("Set file size limit: %d bytes", fileSizeLimit)
 	}
 
@@ -500,18 +621,32 @@ func (s *SystemCallSandbox) setupSetrlimit() error {
 	}
 
 	// 禁用核心转储
-	if err := syscall.Setrlimit(syscall.RLIMIT_CORE, &syscall.Rlimit{
-		Cur: 0,
-		Max: 0,
-	}); err != nil {
-		logx.Errorf("Failed to disable core dump: %v", err)
-	}
+	limits = append(limits, SandboxHelperRlimit{Resource: syscall.RLIMIT_CORE, Cur: 0, Max: 0})
 
-	return nil
+	return limits
+}
+
+// rlimit资源名称（用于日志）
+func rlimitName(resource int) string {
+	switch resource {
+	case syscall.RLIMIT_CPU:
+		return "CPU"
+	case syscall.RLIMIT_AS:
+		return "AS"
+	case syscall.RLIMIT_STACK:
+		return "STACK"
+	case syscall.RLIMIT_FSIZE:
+		return "FSIZE"
+	case syscall.RLIMIT_CORE:
+		return "CORE"
+	default:
+		return strconv.Itoa(resource)
+	}
 }
 
 // 带cgroups支持的进程监控
-func (s *SystemCallSandbox) monitorProcessWithCgroups(pid int, startTime time.Time) (*ExecuteResult, error) {
+// pid为PID namespace中的init进程，主进程的状态与进程树统计由init通过report上报
+func (s *SystemCallSandbox) monitorProcessWithCgroups(pid int, startTime time.Time, report io.Reader) (*ExecuteResult, error) {
 	result := &ExecuteResult{
 		ResourceUsage: &ResourceUsageDetail{},
 	}
@@ -519,12 +654,27 @@ func (s *SystemCallSandbox) monitorProcessWithCgroups(pid int, startTime time.Ti
 	// 初始化控制方法标识
 	result.ResourceUsage.ControlMethod = s.getControlMethod()
 
+	// ptrace请求只能由附加的线程发出，否则PtraceCont返回ESRCH，进程一直停止到超时
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	// 使用ptrace附加到进程
 	if err := syscall.PtraceAttach(pid); err != nil {
 		return nil, fmt.Errorf("failed to attach ptrace: %w", err)
 	}
 	defer syscall.PtraceDetach(pid)
 
+	// init在墙钟超时后自行终止进程树并上报；超过宽限时间仍未退出则直接杀死init，内核随之杀死namespace内所有进程
+	wallTimeLimit := s.wallTimeLimit()
+	var wallKilled atomic.Bool
+	if wallTimeLimit > 0 {
+		killTimer := time.AfterFunc(time.Duration(wallTimeLimit)*time.Millisecond+sandboxInitKillGrace, func() {
+			wallKilled.Store(true)
+			syscall.Kill(pid, syscall.SIGKILL)
+		})
+		defer killTimer.Stop()
+	}
+
 	var status syscall.WaitStatus
 	var rusage syscall.Rusage
 
@@ -541,11 +691,11 @@ func (s *SystemCallSandbox) monitorProcessWithCgroups(pid int, startTime time.Ti
 
 		// 检查墙钟时间限制
 		elapsed := time.Since(startTime)
-		if s.config.WallTimeLimit > 0 && elapsed > time.Duration(s.config.WallTimeLimit)*time.Millisecond {
+		if wallTimeLimit > 0 && elapsed > time.Duration(wallTimeLimit)*time.Millisecond+sandboxInitKillGrace {
 			syscall.Kill(pid, syscall.SIGKILL)
+			wallKilled.Store(true)
 			result.Status = StatusTimeLimitExceeded
-			result.TimeUsed = s.config.WallTimeLimit
-			result.ResourceUsage.LimitExceeded = "time"
+			result.ResourceUsage.LimitExceeded = "wall"
 			break
 		}
 
@@ -585,74 +735,167 @@ func (s *SystemCallSandbox) monitorProcessWithCgroups(pid int, startTime time.Ti
 
 		// 进程被信号终止
 		if status.Signaled() {
-			signal := status.Signal()
-			result.Signal = int(signal)
-
-			switch signal {
-			case syscall.SIGXCPU:
-				result.Status = StatusTimeLimitExceeded
-				result.ResourceUsage.LimitExceeded = "cpu"
-			case syscall.SIGKILL:
-				if rusage.Maxrss > s.config.MemoryLimit {
-					result.Status = StatusMemoryLimitExceeded
-					result.ResourceUsage.LimitExceeded = "memory"
-				} else {
-					result.Status = StatusTimeLimitExceeded
-					result.ResourceUsage.LimitExceeded = "time"
-				}
-			case syscall.SIGSEGV, syscall.SIGFPE, syscall.SIGABRT:
-				result.Status = StatusRuntimeError
-				result.ResourceUsage.LimitExceeded = "none"
-			default:
-				result.Status = StatusRuntimeError
-				result.ResourceUsage.LimitExceeded = "none"
-			}
+			s.applySignalStatus(result, status.Signal(), rusage.Maxrss)
 			break
 		}
 
 		// 进程停止（被ptrace）
 		if status.Stopped() {
-			// 继续执行进程
-			if err := syscall.PtraceCont(pid, 0); err != nil {
+			// 继续执行进程；ESRCH说明init已在退出（如墙钟超时后），下一次wait4会得到退出状态
+			if err := syscall.PtraceCont(pid, 0); err != nil && err != syscall.ESRCH {
 				return nil, fmt.Errorf("failed to continue process: %w", err)
 			}
 		}
 	}
 
+	// init正常退出时上报主进程的真实状态；init被杀死（未上报）时沿用上面的判断
+	// wait4统计的是init及其回收的整棵进程树（user+sys），需扣除init自身的CPU时间
+	cpuTimeUs := rusage.Utime.Sec*1000000 + rusage.Utime.Usec + rusage.Stime.Sec*1000000 + rusage.Stime.Usec
+	wallTimeUs := time.Since(startTime).Microseconds()
+	wallExceeded := wallKilled.Load()
+	if status.Exited() {
+		if initReport := readSandboxInitReport(report); initReport != nil {
+			s.applyInitReport(result, initReport, rusage.Maxrss)
+			cpuTimeUs -= initReport.InitCPUTimeUs
+			if cpuTimeUs < 0 {
+				cpuTimeUs = 0
+			}
+			// init计时不包含辅助进程启动与隔离设置的开销
+			wallTimeUs = initReport.WallTimeUs
+			wallExceeded = wallExceeded || initReport.WallTimeExceeded
+		}
+	}
+
+	// 进程树已全部退出，等待输出读取完毕；输出超限导致的终止优先于其他判断
+	s.output.wait()
+	result.OutputSize = s.output.outputSize()
+	if s.output.limitExceeded() {
+		result.Status = StatusOutputLimitExceeded
+		result.ResourceUsage.LimitExceeded = "output"
+	}
+
 	// 记录setrlimit资源使用情况
-	result.ResourceUsage.SetrlimitStats.CPUTimeUsed = int64(rusage.Utime.Sec*1000 + rusage.Utime.Usec/1000)
 	result.ResourceUsage.SetrlimitStats.MaxRSSUsed = rusage.Maxrss
-	result.ResourceUsage.SetrlimitStats.WallTimeUsed = time.Since(startTime).Milliseconds()
-
-	// 设置兼容性字段
-	result.TimeUsed = result.ResourceUsage.SetrlimitStats.CPUTimeUsed
 	result.MemoryUsed = result.ResourceUsage.SetrlimitStats.MaxRSSUsed
+	s.applyTimeUsage(result, cpuTimeUs/1000, wallTimeUs/1000, wallExceeded)
 
-	// 检查输出文件大小
-	if s.config.OutputFile != "" {
-		if stat, err := os.Stat(s.config.OutputFile); err == nil {
-			result.OutputSize = stat.Size()
-			// 检查输出大小限制
-			if result.OutputSize > int64(s.config.FileSizeLimit)*1024 {
-				result.Status = StatusOutputLimitExceeded
-				result.ResourceUsage.LimitExceeded = "output"
-			}
-		}
+	// 读取错误输出
+	result.ErrorOutput = s.readErrorOutput()
+
+	return result, nil
+}
+
+// 读取错误输出（标准错误只保留了用于展示的前缀）
+func (s *SystemCallSandbox) readErrorOutput() string {
+	if s.config.ErrorFile == "" {
+		return ""
+	}
+	errorData, err := os.ReadFile(s.config.ErrorFile)
+	if err != nil {
+		logx.Errorf("Failed to read error file %s: %v", s.config.ErrorFile, err)
+		return ""
 	}
+	logx.Debugf("Read error file %s, length=%d", s.config.ErrorFile, len(errorData))
+	return string(errorData)
+}
 
-	// 读取错误输出
-	if s.config.ErrorFile != "" {
-		if errorData, err := os.ReadFile(s.config.ErrorFile); err == nil {
-			result.ErrorOutput = string(errorData)
-			logx.Infof("Debug: Read error file %s, length=%d, content='%s'", s.config.ErrorFile, len(result.ErrorOutput), result.ErrorOutput)
+// 根据终止进程的信号判断执行状态
+func (s *SystemCallSandbox) applySignalStatus(result *ExecuteResult, signal syscall.Signal, maxRSS int64) {
+	result.Signal = int(signal)
+
+	switch signal {
+	case syscall.SIGXCPU:
+		result.Status = StatusTimeLimitExceeded
+		result.ResourceUsage.LimitExceeded = "cpu"
+	case syscall.SIGKILL:
+		if maxRSS > s.config.MemoryLimit {
+			result.Status = StatusMemoryLimitExceeded
+			result.ResourceUsage.LimitExceeded = "memory"
 		} else {
-			logx.Errorf("Debug: Failed to read error file %s: %v", s.config.ErrorFile, err)
+			// RLIMIT_CPU硬限制或cgroup触发的终止，墙钟超时由applyTimeUsage区分
+			result.Status = StatusTimeLimitExceeded
+			result.ResourceUsage.LimitExceeded = "cpu"
 		}
+	case syscall.SIGSEGV, syscall.SIGFPE, syscall.SIGABRT:
+		result.Status = StatusRuntimeError
+		result.ResourceUsage.LimitExceeded = "none"
+	default:
+		result.Status = StatusRuntimeError
+		result.ResourceUsage.LimitExceeded = "none"
+	}
+}
+
+// 根据init上报的主进程状态与进程树统计更新执行结果
+func (s *SystemCallSandbox) applyInitReport(result *ExecuteResult, report *SandboxInitReport, maxRSS int64) {
+	result.ResourceUsage.ProcessStats.Processes = report.Processes
+	result.ResourceUsage.ProcessStats.OrphansKilled = report.OrphansKilled
+	if report.OrphansKilled > 0 {
+		logx.Infof("Killed %d processes left behind by the main process", report.OrphansKilled)
+	}
+
+	switch {
+	case report.SeccompViolation:
+		// init拦截SIGSYS后以SIGKILL终止进程树，这里还原为SIGSYS
+		result.Status = StatusRestrictedFunction
+		result.Signal = int(syscall.SIGSYS)
+		result.RestrictedSyscall = report.Syscall
+		result.RestrictedSyscallName = SyscallName(report.Syscall)
+		result.ResourceUsage.LimitExceeded = "none"
+		logx.Infof("Restricted syscall blocked by seccomp: %s(%d)", result.RestrictedSyscallName, result.RestrictedSyscall)
+	case report.WallTimeExceeded:
+		result.Status = StatusTimeLimitExceeded
+		result.Signal = int(syscall.SIGKILL)
+		result.ResourceUsage.LimitExceeded = "wall"
+	case report.Signal != 0:
+		s.applySignalStatus(result, syscall.Signal(report.Signal), maxRSS)
+	default:
+		result.Status = StatusAccepted
+		result.ExitCode = report.ExitCode
+		result.ResourceUsage.LimitExceeded = "none"
+	}
+}
+
+// 记录CPU时间与墙钟时间，按时间度量选取TimeUsed并区分CPU超时与墙钟（空闲）超时
+// 只重新判定正常结束与已判为超时的结果，内存、输出超限与运行错误保持不变
+func (s *SystemCallSandbox) applyTimeUsage(result *ExecuteResult, cpuTime, wallTime int64, wallExceeded bool) {
+	result.CPUTime = cpuTime
+	result.WallTime = wallTime
+	result.ResourceUsage.SetrlimitStats.CPUTimeUsed = cpuTime
+	result.ResourceUsage.SetrlimitStats.WallTimeUsed = wallTime
+
+	byWall := s.config.TimeLimitMetric == TimeLimitMetricWall
+	if byWall {
+		result.TimeUsed = wallTime
 	} else {
-		logx.Infof("Debug: ErrorFile not configured")
+		result.TimeUsed = cpuTime
 	}
 
-	return result, nil
+	if result.Status != StatusAccepted && result.Status != StatusTimeLimitExceeded {
+		return
+	}
+
+	limit := s.config.TimeLimit
+	switch {
+	case byWall && (wallExceeded || (limit > 0 && wallTime > limit)):
+		result.Status = StatusTimeLimitExceeded
+		result.ResourceUsage.LimitExceeded = "wall"
+	case !byWall && limit > 0 && cpuTime > limit:
+		result.Status = StatusTimeLimitExceeded
+		result.ResourceUsage.LimitExceeded = "cpu"
+	case wallExceeded:
+		// CPU时间未超限，程序阻塞在sleep、读标准输入等处直到墙钟时间耗尽
+		result.Status = StatusTimeLimitExceeded
+		result.ResourceUsage.LimitExceeded = "wall"
+	}
+}
+
+// 实际生效的墙钟时间限制：按墙钟计时时TimeLimit本身就是墙钟限制
+func (s *SystemCallSandbox) wallTimeLimit() int64 {
+	if s.config.TimeLimitMetric == TimeLimitMetricWall && s.config.TimeLimit > 0 &&
+		(s.config.WallTimeLimit <= 0 || s.config.TimeLimit < s.config.WallTimeLimit) {
+		return s.config.TimeLimit
+	}
+	return s.config.WallTimeLimit
 }
 
 // 检查cgroup资源限制
@@ -728,6 +971,12 @@ func (s *SystemCallSandbox) collectResourceStats(result *ExecuteResult, pid int)
 
 // 清理cgroup资源
 func (s *SystemCallSandbox) cleanupCgroup() {
+	if s.cgroupPooled {
+		// 归还资源池，由其在后台重置后复用
+		s.config.Pool.releaseCgroup(s.cgroupManager)
+		s.cgroupPooled = false
+		return
+	}
 	if s.config.EnableCgroups && s.cgroupManager != nil {
 		if err := s.cgroupManager.Cleanup(); err != nil {
 			logx.Errorf("Failed to cleanup cgroup: %v", err)
@@ -756,6 +1005,10 @@ func (s *SystemCallSandbox) getControlMethod() string {
 func (s *SystemCallSandbox) monitorProcess(pid int, startTime time.Time) (*ExecuteResult, error) {
 	result := &ExecuteResult{}
 
+	// ptrace请求只能由附加的线程发出，否则PtraceCont返回ESRCH，进程一直停止到超时
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	// 使用ptrace附加到进程
 	if err := syscall.PtraceAttach(pid); err != nil {
 		return nil, fmt.Errorf("failed to attach ptrace: %w", err)
@@ -847,16 +1100,7 @@ func (s *SystemCallSandbox) monitorProcess(pid int, startTime time.Time) (*Execu
 	}
 
 	// 读取错误输出
-	if s.config.ErrorFile != "" {
-		if errorData, err := os.ReadFile(s.config.ErrorFile); err == nil {
-			result.ErrorOutput = string(errorData)
-			logx.Infof("Debug: Read error file %s, length=%d, content='%s'", s.config.ErrorFile, len(result.ErrorOutput), result.ErrorOutput)
-		} else {
-			logx.Errorf("Debug: Failed to read error file %s: %v", s.config.ErrorFile, err)
-		}
-	} else {
-		logx.Infof("Debug: ErrorFile not configured")
-	}
+	result.ErrorOutput = s.readErrorOutput()
 
 	return result, nil
 }
@@ -870,7 +1114,10 @@ func (s *SystemCallSandbox) CreateWorkDir(baseDir string) (string, error) {
 		return "", fmt.Errorf("failed to create work directory: %w", err)
 	}
 
-	// 设置目录权限
+	// 设置目录权限（rootless模式下沙箱内的root即判题服务的用户）
+	if s.config.Rootless {
+		return workDir, nil
+	}
 	if err := os.Chown(workDir, s.config.UID, s.config.GID); err != nil {
 		return "", fmt.Errorf("failed to chown work directory: %w", err)
 	}
@@ -918,6 +1165,8 @@ func (s *SystemCallSandbox) setupSeccomp() error {
 
 	// 1. 创建seccomp过滤器
 	filter := NewSeccompFilter(s.config.AllowedSyscalls, SECCOMP_RET_KILL_PROCESS)
+	filter.SetDeniedSyscalls(s.config.DeniedSyscalls)
+	filter.SetArgRules(s.config.SyscallArgRules)
 
 	// 2. 验证过滤器配置
 	if err := filter.Validate(); err != nil {
@@ -976,19 +1225,167 @@ func GetSyscallWhitelist(language string) []int {
 			56, 57, 59, 60, 61, 62, 63, 89, 96, 97, 158, 202, 231, 257, 273, 318,
 		}
 	case "python":
+		// CPython解释器启动、import标准库与运行时所需，来自python_syscalls.log与常见题解脚本的跟踪记录
+		// 线程（threading常用于扩大递归栈）需要clone，参数规则限制其只能创建线程，见GetSyscallArgRules
 		return []int{
-			0, 1, 2, 3, 4, 5, 6, 8, 9, 10, 11, 12, 13, 16, 21, 22, 39, 59, 60, 61,
-			79, 89, 97, 158, 231, 257, 273, 318,
+			0,   // read
+			1,   // write
+			3,   // close
+			4,   // stat（glibc 2.33之前）
+			5,   // fstat
+			6,   // lstat（glibc 2.33之前）
+			8,   // lseek
+			9,   // mmap
+			10,  // mprotect
+			11,  // munmap
+			12,  // brk
+			13,  // rt_sigaction
+			14,  // rt_sigprocmask
+			15,  // rt_sigreturn
+			16,  // ioctl（检测终端）
+			17,  // pread64
+			21,  // access
+			25,  // mremap（大列表扩容）
+			28,  // madvise
+			32,  // dup
+			39,  // getpid
+			56,  // clone（只允许创建线程）
+			59,  // execve
+			60,  // exit
+			72,  // fcntl
+			79,  // getcwd
+			89,  // readlink
+			99,  // sysinfo
+			102, // getuid
+			104, // getgid
+			107, // geteuid
+			108, // getegid
+			158, // arch_prctl
+			186, // gettid
+			202, // futex
+			217, // getdents64（import时扫描目录）
+			218, // set_tid_address
+			228, // clock_gettime
+			230, // clock_nanosleep
+			231, // exit_group
+			257, // openat
+			262, // newfstatat
+			273, // set_robust_list
+			302, // prlimit64
+			318, // getrandom
+			334, // rseq
 		}
 	case "go":
+		// 静态链接的Go程序（CGO_ENABLED=0）运行时所需的系统调用，由真实Go二进制验证
+		// 不包含fork/wait4/kill：goroutine调度只需要clone线程
 		return []int{
-			0, 1, 2, 3, 4, 5, 8, 9, 10, 11, 12, 13, 16, 21, 22, 39, 56, 57, 59, 60,
-			61, 62, 89, 96, 97, 158, 202, 231, 257, 273, 318,
+			0,   // read
+			1,   // write
+			3,   // close
+			5,   // fstat
+			8,   // lseek
+			9,   // mmap
+			10,  // mprotect
+			11,  // munmap
+			12,  // brk
+			13,  // rt_sigaction
+			14,  // rt_sigprocmask
+			15,  // rt_sigreturn
+			17,  // pread64
+			24,  // sched_yield
+			28,  // madvise
+			35,  // nanosleep
+			39,  // getpid
+			56,  // clone（创建运行时线程）
+			59,  // execve
+			60,  // exit
+			72,  // fcntl
+			97,  // getrlimit
+			131, // sigaltstack
+			158, // arch_prctl
+			160, // setrlimit
+			186, // gettid
+			202, // futex
+			204, // sched_getaffinity
+			222, // timer_create
+			223, // timer_settime
+			226, // timer_delete
+			228, // clock_gettime
+			230, // clock_nanosleep
+			231, // exit_group
+			233, // epoll_ctl
+			234, // tgkill（抢占信号）
+			257, // openat
+			262, // newfstatat
+			281, // epoll_pwait
+			290, // eventfd2
+			291, // epoll_create1
+			293, // pipe2
+			302, // prlimit64
+			318, // getrandom
 		}
-	case "javascript":
+	case "javascript", "typescript":
+		// Node.js运行时所需（V8后台线程、libuv事件循环、读取标准输入）
+		// 不包含socket/connect等网络调用；clone只能创建线程（clone3返回ENOSYS，glibc回退到clone），
+		// 目标程序之后的execve由init拦截，子进程不再只依赖模块加载守卫
 		return []int{
-			0, 1, 2, 3, 4, 5, 6, 8, 9, 10, 11, 12, 13, 16, 21, 22, 39, 59, 60, 61,
-			89, 97, 158, 231, 257, 273, 318,
+			0,   // read
+			1,   // write
+			3,   // close
+			5,   // fstat
+			8,   // lseek
+			9,   // mmap
+			10,  // mprotect
+			11,  // munmap
+			12,  // brk
+			13,  // rt_sigaction
+			14,  // rt_sigprocmask
+			15,  // rt_sigreturn
+			16,  // ioctl
+			17,  // pread64
+			21,  // access
+			24,  // sched_yield
+			28,  // madvise
+			39,  // getpid
+			51,  // getsockname（libuv识别stdio句柄类型）
+			55,  // getsockopt（libuv识别stdio句柄类型）
+			56,  // clone（创建V8/libuv线程，只允许创建线程）
+			59,  // execve
+			60,  // exit
+			63,  // uname
+			72,  // fcntl
+			79,  // getcwd
+			89,  // readlink
+			99,  // sysinfo
+			102, // getuid
+			104, // getgid
+			107, // geteuid
+			108, // getegid
+			125, // capget
+			158, // arch_prctl
+			186, // gettid
+			200, // tkill
+			202, // futex
+			204, // sched_getaffinity
+			218, // set_tid_address
+			228, // clock_gettime
+			230, // clock_nanosleep
+			231, // exit_group
+			232, // epoll_wait
+			233, // epoll_ctl
+			234, // tgkill（堆溢出时abort）
+			257, // openat
+			262, // newfstatat
+			273, // set_robust_list
+			281, // epoll_pwait
+			290, // eventfd2
+			291, // epoll_create1
+			293, // pipe2
+			302, // prlimit64
+			318, // getrandom
+			330, // pkey_alloc
+			332, // statx
+			334, // rseq
 		}
 	default:
 		// 返回最小权限集合
@@ -996,6 +1393,63 @@ func GetSyscallWhitelist(language string) []int {
 	}
 }
 
+// 获取显式禁止的系统调用列表
+// 网络、创建进程、调试其他进程、切换身份与修改内核/挂载状态的系统调用对任何语言都不应放行，
+// 即使出现在配置的白名单中也会被终止
+func GetSyscallDenylist(language string) []int {
+	return []int{
+		41,  // socket
+		42,  // connect
+		43,  // accept
+		49,  // bind
+		50,  // listen
+		53,  // socketpair
+		57,  // fork
+		58,  // vfork
+		62,  // kill
+		101, // ptrace
+		105, // setuid
+		106, // setgid
+		155, // pivot_root
+		161, // chroot
+		165, // mount
+		166, // umount2
+		169, // reboot
+		175, // init_module
+		246, // kexec_load
+		272, // unshare
+		288, // accept4
+		298, // perf_event_open
+		308, // setns
+		310, // process_vm_readv
+		311, // process_vm_writev
+		313, // finit_module
+		321, // bpf
+		323, // userfaultfd
+	}
+}
+
+// 获取语言默认的参数规则预设（见seccomp.go中的syscallRulePresets）
+// Python的threading常被用来扩大递归栈，需要创建线程，但不允许fork/subprocess
+// JVM与V8的JIT需要可写可执行的内存，Java在/tmp写入性能数据，不使用对应的规则；V8与libuv只创建线程
+// open_read_only只允许在工作目录中写文件，/tmp等可写路径同样只读
+func GetSyscallRuleNames(language string) []string {
+	switch language {
+	case "cpp", "c", "go", "python":
+		return []string{SyscallRuleCloneThreadOnly, SyscallRuleOpenReadOnly, SyscallRuleNoWritableExec}
+	case "javascript", "typescript":
+		return []string{SyscallRuleCloneThreadOnly}
+	default:
+		return nil
+	}
+}
+
+// 获取语言默认的参数规则
+func GetSyscallArgRules(language string) []SyscallArgRule {
+	rules, _ := ResolveSyscallArgRules(GetSyscallRuleNames(language))
+	return rules
+}
+
 // 验证程序路径安全性
 func (s *SystemCallSandbox) ValidatePath(path string) error {
 	// 检查路径是否在允许的范围内
@@ -1018,15 +1472,25 @@ func (s *SystemCallSandbox) ValidatePath(path string) error {
 	return nil
 }
 
+// 执行阶段需要加入的cgroup.procs文件（cgroup创建失败降级为setrlimit时为空）
+func (s *SystemCallSandbox) cgroupProcsFiles() []string {
+	if !s.config.EnableCgroups || s.cgroupManager == nil {
+		return nil
+	}
+	return s.cgroupManager.ProcsFiles()
+}
+
 // 构建Clone标志位 - 根据配置动态构建需要的Namespace隔离标志
 func (s *SystemCallSandbox) buildCloneFlags() uintptr {
 	// 基础的三种Namespace（已实现）
+	// Network Namespace对每次编译与运行都开启，网络隔离不依赖seccomp白名单（见network.go）
 	flags := syscall.CLONE_NEWPID | syscall.CLONE_NEWNET | syscall.CLONE_NEWNS
 
 	// User Namespace隔离
 	// 原理：创建新的用户命名空间，实现uid/gid映射，将沙箱内的root权限映射到主机普通用户
 	// 作用：防止用户代码获取真实的主机特权，即使在沙箱内获得root也无法影响主机
-	if s.config.EnableUserNS {
+	// rootless模式的user namespace与映射由setRootlessIDMappings设置
+	if s.config.EnableUserNS && !s.config.Rootless {
 		flags |= syscall.CLONE_NEWUSER
 		logx.Info("Enabled User Namespace isolation - uid/gid mapping will be configured")
 	}
@@ -1062,7 +1526,7 @@ func (s *SystemCallSandbox) buildCloneFlags() uintptr {
 // 原理：通过写入/proc/PID/uid_map和/proc/PID/gid_map文件实现映射关系
 // 映射格式：inside_id outside_id length（沙箱内ID 主机ID 映射长度）
 func (s *SystemCallSandbox) setupUserNamespaceMapping(pid int) error {
-	if !s.config.EnableUserNS {
+	if !s.config.EnableUserNS || s.config.Rootless {
 		return nil
 	}
 
@@ -1371,11 +1835,11 @@ func NewLanguageSandboxConfig(language, workDir string) *SandboxConfig {
 		config.TimeLimit = 5000     // 5秒
 		config.AllowedSyscalls = GetSyscallWhitelist("go")
 
-	case "javascript":
+	case "javascript", "typescript":
 		// Node.js需要较多内存
 		config.MemoryLimit = 196608 // 192MB
 		config.TimeLimit = 8000     // 8秒
-		config.AllowedSyscalls = GetSyscallWhitelist("javascript")
+		config.AllowedSyscalls = GetSyscallWhitelist(language)
 
 	default:
 		// 未知语言使用最严格限制
@@ -1384,6 +1848,9 @@ func NewLanguageSandboxConfig(language, workDir string) *SandboxConfig {
 		config.AllowedSyscalls = GetSyscallWhitelist("")
 	}
 
+	config.DeniedSyscalls = GetSyscallDenylist(language)
+	config.SyscallArgRules = GetSyscallArgRules(language)
+
 	// 确保seccomp过滤器启用并配置了系统调用白名单
 	if len(config.AllowedSyscalls) > 0 {
 		config.EnableSeccomp = true
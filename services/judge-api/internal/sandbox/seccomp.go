@@ -2,6 +2,7 @@ package sandbox
 
 import (
 	"fmt"
+	"sort"
 	"syscall"
 	"unsafe"
 
@@ -22,6 +23,9 @@ const (
 	// seccomp系统调用
 	SYS_SECCOMP = 317
 
+	// prctl选项：禁止execve获取新权限，非特权进程安装seccomp过滤器的前提
+	PR_SET_NO_NEW_PRIVS = 38
+
 	// seccomp操作
 	SECCOMP_SET_MODE_STRICT  = 0
 	SECCOMP_SET_MODE_FILTER  = 1
@@ -107,9 +111,114 @@ type BPFProgram struct {
 
 // seccomp过滤器
 type SeccompFilter struct {
-	allowedSyscalls map[int]bool     // 允许的系统调用集合
-	defaultAction   uint32           // 默认动作
-	instructions    []BPFInstruction // BPF指令集
+	allowedSyscalls map[int]bool             // 允许的系统调用集合
+	deniedSyscalls  map[int]bool             // 显式禁止的系统调用集合，优先于白名单与默认动作
+	argRules        map[int][]SyscallArgRule // 白名单中需要检查参数的系统调用
+	defaultAction   uint32                   // 默认动作
+	denyAction      uint32                   // 违规动作：架构不符、命中黑名单或参数规则不满足
+	instructions    []BPFInstruction         // BPF指令集
+	comments        map[int]string           // 指令注释，用于反汇编
+}
+
+// BPF跳转目标：跳转偏移在指令生成完成后统一回填
+type bpfLabel int
+
+const (
+	labelNext    bpfLabel = iota // 顺序执行下一条指令
+	labelDefault                 // 默认动作
+	labelAllow                   // 允许
+	labelDeny                    // 违规动作（默认终止进程）
+	labelENOSYS                  // 返回ENOSYS
+	labelTrace                   // 交给跟踪目标程序的init检查
+	labelDynamic                 // 参数检查块与错误码返回的标签从这里开始分配
+)
+
+// 待回填跳转偏移的指令
+type bpfJump struct {
+	index int
+	jt    bpfLabel
+	jf    bpfLabel
+}
+
+// 系统调用号与参数规则用到的常量
+const (
+	SYS_OPEN          = 2
+	SYS_MMAP          = 9
+	SYS_MPROTECT      = 10
+	SYS_CLONE         = 56
+	SYS_OPENAT        = 257
+	SYS_PKEY_MPROTECT = 329
+	SYS_CLONE3        = 435
+	SYS_OPENAT2       = 437
+
+	CLONE_THREAD = 0x00010000
+	O_ACCMODE    = 0x3
+	O_CREAT      = 0x40
+	O_TRUNC      = 0x200
+	PROT_WRITE   = 0x2
+	PROT_EXEC    = 0x4
+
+	EACCES = 13
+	ENOSYS = 38
+)
+
+// 参数在用户内存中、BPF无法检查的等价系统调用
+// 被检查的系统调用配置了参数规则时，这些系统调用返回ENOSYS，glibc随之回退到可检查的版本
+var uninspectableVariants = map[int]int{
+	SYS_CLONE:  SYS_CLONE3,
+	SYS_OPENAT: SYS_OPENAT2,
+}
+
+// SyscallArgRule 系统调用参数规则
+// 参数低32位与Mask按位与的结果等于Value（NotEqual时为不等于）才放行；同一系统调用的多条规则需全部满足
+// BPF只能读取寄存器中的参数，无法解引用指针；路径等内存中的参数由跟踪目标程序的init检查（Trace，见openpath.go）
+type SyscallArgRule struct {
+	Syscall   int    `json:"syscall"`
+	Arg       int    `json:"arg"` // 参数序号(0-5)
+	Mask      uint32 `json:"mask"`
+	Value     uint32 `json:"value"`
+	NotEqual  bool   `json:"not_equal"`
+	Errno     int    `json:"errno"`     // 不满足时返回的错误码，0表示终止进程
+	Trace     bool   `json:"trace"`     // 不满足时返回SECCOMP_RET_TRACE，由init检查内存中的参数后决定是否放行，优先于Errno
+	Condition string `json:"condition"` // 反汇编中显示的条件
+}
+
+// 参数规则预设，在各语言配置的SyscallRules中按名称引用
+const (
+	SyscallRuleCloneThreadOnly = "clone_thread_only" // clone只能创建线程，不能创建进程
+	SyscallRuleOpenReadOnly    = "open_read_only"    // open/openat在工作目录之外只能以只读方式打开，写入返回EACCES
+	SyscallRuleNoWritableExec  = "no_writable_exec"  // mmap/mprotect不能创建同时可写可执行的内存
+)
+
+var syscallRulePresets = map[string][]SyscallArgRule{
+	SyscallRuleCloneThreadOnly: {
+		{Syscall: SYS_CLONE, Arg: 0, Mask: CLONE_THREAD, Value: CLONE_THREAD, Condition: "flags & CLONE_THREAD == CLONE_THREAD"},
+	},
+	// 只读打开（不含O_CREAT/O_TRUNC，O_RDONLY|O_TRUNC在Linux上同样会截断文件）直接放行，
+	// 写方式的打开交给init按路径检查，只有工作目录之内的才放行（见openpath.go）
+	SyscallRuleOpenReadOnly: {
+		{Syscall: SYS_OPEN, Arg: 1, Mask: O_ACCMODE | O_CREAT | O_TRUNC, Value: 0, Trace: true, Condition: "flags & (O_ACCMODE|O_CREAT|O_TRUNC) == O_RDONLY"},
+		{Syscall: SYS_OPENAT, Arg: 2, Mask: O_ACCMODE | O_CREAT | O_TRUNC, Value: 0, Trace: true, Condition: "flags & (O_ACCMODE|O_CREAT|O_TRUNC) == O_RDONLY"},
+	},
+	// 可写映射先去掉写权限再加执行权限（W^X），JIT运行时（JVM、V8）不能使用
+	SyscallRuleNoWritableExec: {
+		{Syscall: SYS_MMAP, Arg: 2, Mask: PROT_WRITE | PROT_EXEC, Value: PROT_WRITE | PROT_EXEC, NotEqual: true, Condition: "prot & (PROT_WRITE|PROT_EXEC) != PROT_WRITE|PROT_EXEC"},
+		{Syscall: SYS_MPROTECT, Arg: 2, Mask: PROT_WRITE | PROT_EXEC, Value: PROT_WRITE | PROT_EXEC, NotEqual: true, Condition: "prot & (PROT_WRITE|PROT_EXEC) != PROT_WRITE|PROT_EXEC"},
+		{Syscall: SYS_PKEY_MPROTECT, Arg: 2, Mask: PROT_WRITE | PROT_EXEC, Value: PROT_WRITE | PROT_EXEC, NotEqual: true, Condition: "prot & (PROT_WRITE|PROT_EXEC) != PROT_WRITE|PROT_EXEC"},
+	},
+}
+
+// ResolveSyscallArgRules 将预设名称展开为参数规则
+func ResolveSyscallArgRules(names []string) ([]SyscallArgRule, error) {
+	var rules []SyscallArgRule
+	for _, name := range names {
+		preset, ok := syscallRulePresets[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown syscall rule: %s", name)
+		}
+		rules = append(rules, preset...)
+	}
+	return rules, nil
 }
 
 // 创建新的seccomp过滤器
@@ -117,6 +226,7 @@ func NewSeccompFilter(allowedSyscalls []int, defaultAction uint32) *SeccompFilte
 	filter := &SeccompFilter{
 		allowedSyscalls: make(map[int]bool),
 		defaultAction:   defaultAction,
+		denyAction:      SECCOMP_RET_KILL_PROCESS,
 		instructions:    make([]BPFInstruction, 0),
 	}
 
@@ -129,69 +239,228 @@ func NewSeccompFilter(allowedSyscalls []int, defaultAction uint32) *SeccompFilte
 	return filter
 }
 
+// 设置显式禁止的系统调用
+// 即使出现在白名单中或默认动作为LOG（调试模式），这些系统调用也会执行违规动作
+func (f *SeccompFilter) SetDeniedSyscalls(deniedSyscalls []int) {
+	f.deniedSyscalls = make(map[int]bool, len(deniedSyscalls))
+	for _, syscallNum := range deniedSyscalls {
+		f.deniedSyscalls[syscallNum] = true
+	}
+}
+
+// 设置违规动作，默认为SECCOMP_RET_KILL_PROCESS
+// 判题时使用SECCOMP_RET_TRAP：SIGSYS的siginfo中带有被拦截的系统调用号，由跟踪目标程序的init读取（见reaper.go）
+func (f *SeccompFilter) SetDenyAction(action uint32) {
+	f.denyAction = action
+}
+
+// 设置参数规则，只作用于白名单中的系统调用
+func (f *SeccompFilter) SetArgRules(rules []SyscallArgRule) {
+	f.argRules = make(map[int][]SyscallArgRule)
+	for _, rule := range rules {
+		f.argRules[rule.Syscall] = append(f.argRules[rule.Syscall], rule)
+	}
+}
+
+// 被检查的系统调用（放行且配置了参数规则）
+func (f *SeccompFilter) inspected(syscallNum int) bool {
+	return f.allowedSyscalls[syscallNum] && !f.deniedSyscalls[syscallNum] && len(f.argRules[syscallNum]) > 0
+}
+
 // 构建BPF程序
 // 原理：将系统调用白名单转换为BPF指令序列，实现高效的系统调用过滤
+// 布局：架构检查、黑名单、无法检查参数的变体、白名单、默认动作、各系统调用的参数检查块、返回动作
+// BPF只能向前跳转，参数检查块位于白名单之后、返回动作之前
 func (f *SeccompFilter) buildBPFProgram() error {
 	logx.Info("Building BPF program for seccomp filter")
 
 	// 重置指令集
 	f.instructions = f.instructions[:0]
+	f.comments = make(map[int]string)
+	var jumps []bpfJump
+
+	// 添加跳转指令，跳转偏移稍后回填
+	addJump := func(code uint16, k uint32, jt, jf bpfLabel, comment string) {
+		jumps = append(jumps, bpfJump{index: len(f.instructions), jt: jt, jf: jf})
+		f.addInstruction(code, 0, 0, k)
+		f.comments[len(f.instructions)-1] = comment
+	}
+	addComment := func(comment string) {
+		f.comments[len(f.instructions)-1] = comment
+	}
+
+	// 动态分配的标签：每个参数检查块一个，每个错误码返回一个
+	nextLabel := labelDynamic
+	ruleLabels := make(map[int]bpfLabel)
+	errnoLabels := make(map[int]bpfLabel)
+	var errnos []int
+	for _, syscallNum := range sortedSyscalls(f.allowedSyscalls) {
+		if !f.inspected(syscallNum) {
+			continue
+		}
+		ruleLabels[syscallNum] = nextLabel
+		nextLabel++
+		for _, rule := range f.argRules[syscallNum] {
+			if _, ok := errnoLabels[rule.Errno]; rule.Errno != 0 && !rule.Trace && !ok {
+				errnoLabels[rule.Errno] = nextLabel
+				errnos = append(errnos, rule.Errno)
+				nextLabel++
+			}
+		}
+	}
+	needENOSYS := false
+	needTrace := false
 
 	// 1. 验证架构 - 确保是x86_64架构
 	// 加载架构字段到累加器
 	f.addInstruction(BPF_LD|BPF_W|BPF_ABS, 0, 0, SECCOMP_DATA_ARCH_OFFSET)
-	// 比较是否为x86_64架构
-	f.addInstruction(BPF_JMP|BPF_JEQ|BPF_K, 0, 1, 0xc000003e) // AUDIT_ARCH_X86_64
-	// 架构不匹配则终止进程
-	f.addInstruction(BPF_RET|BPF_K, 0, 0, SECCOMP_RET_KILL_PROCESS)
+	addComment("arch")
+	// 比较是否为x86_64架构，不匹配则终止进程
+	addJump(BPF_JMP|BPF_JEQ|BPF_K, 0xc000003e, labelNext, labelDeny, "arch == AUDIT_ARCH_X86_64")
 
 	// 2. 加载系统调用号到累加器
 	f.addInstruction(BPF_LD|BPF_W|BPF_ABS, 0, 0, SECCOMP_DATA_NR_OFFSET)
+	addComment("syscall number")
 
-	// 3. 构建系统调用白名单检查逻辑
-	// 将允许的系统调用号排序，构建高效的跳转表
-	allowedSyscallList := make([]int, 0, len(f.allowedSyscalls))
-	for syscallNum := range f.allowedSyscalls {
-		allowedSyscallList = append(allowedSyscallList, syscallNum)
+	// 3. 显式禁止的系统调用优先匹配
+	for _, syscallNum := range sortedSyscalls(f.deniedSyscalls) {
+		addJump(BPF_JMP|BPF_JEQ|BPF_K, uint32(syscallNum), labelDeny, labelNext, SyscallName(syscallNum)+": denied")
 	}
 
-	// 简单排序（冒泡排序，适用于小规模数据）
-	for i := 0; i < len(allowedSyscallList)-1; i++ {
-		for j := 0; j < len(allowedSyscallList)-i-1; j++ {
-			if allowedSyscallList[j] > allowedSyscallList[j+1] {
-				allowedSyscallList[j], allowedSyscallList[j+1] = allowedSyscallList[j+1], allowedSyscallList[j]
-			}
+	// 4. 无法检查参数的变体返回ENOSYS（如clone3），否则参数规则可以被绕过
+	for _, syscallNum := range sortedSyscalls(f.allowedSyscalls) {
+		variant, ok := uninspectableVariants[syscallNum]
+		if !ok || !f.inspected(syscallNum) || f.deniedSyscalls[variant] {
+			continue
 		}
+		addJump(BPF_JMP|BPF_JEQ|BPF_K, uint32(variant), labelENOSYS, labelNext,
+			fmt.Sprintf("%s: arguments not inspectable, use %s", SyscallName(variant), SyscallName(syscallNum)))
+		needENOSYS = true
 	}
 
-	// 为每个允许的系统调用生成检查指令
-	for i, syscallNum := range allowedSyscallList {
-		// 检查是否等于当前系统调用号
-		if i == len(allowedSyscallList)-1 {
-			// 最后一个系统调用，不匹配则执行默认动作
-			f.addInstruction(BPF_JMP|BPF_JEQ|BPF_K, 1, 0, uint32(syscallNum))
-		} else {
-			// 不是最后一个，匹配则允许，不匹配则继续检查下一个
-			jumpOffset := len(allowedSyscallList) - i
-			if jumpOffset > 255 {
-				jumpOffset = 255 // 限制在uint8范围内
-			}
-			f.addInstruction(BPF_JMP|BPF_JEQ|BPF_K, uint8(jumpOffset), 0, uint32(syscallNum))
+	// 5. 系统调用白名单，按系统调用号排序；配置了参数规则的跳转到对应的检查块
+	for _, syscallNum := range sortedSyscalls(f.allowedSyscalls) {
+		if f.deniedSyscalls[syscallNum] {
+			continue
+		}
+		if label, ok := ruleLabels[syscallNum]; ok {
+			addJump(BPF_JMP|BPF_JEQ|BPF_K, uint32(syscallNum), label, labelNext, SyscallName(syscallNum)+": check arguments")
+			continue
 		}
+		addJump(BPF_JMP|BPF_JEQ|BPF_K, uint32(syscallNum), labelAllow, labelNext, SyscallName(syscallNum))
 	}
 
-	// 4. 默认动作 - 不在白名单中的系统调用
+	// 6. 不在白名单中的系统调用执行默认动作
+	targets := map[bpfLabel]int{labelDefault: len(f.instructions)}
 	f.addInstruction(BPF_RET|BPF_K, 0, 0, f.defaultAction)
 
-	// 5. 允许动作 - 在白名单中的系统调用
+	// 7. 参数检查块：依次检查每条规则，全部满足才放行
+	for _, syscallNum := range sortedSyscalls(f.allowedSyscalls) {
+		label, ok := ruleLabels[syscallNum]
+		if !ok {
+			continue
+		}
+		targets[label] = len(f.instructions)
+		rules := f.argRules[syscallNum]
+		for i, rule := range rules {
+			fail := labelDeny
+			switch {
+			case rule.Trace:
+				fail = labelTrace
+				needTrace = true
+			case rule.Errno != 0:
+				fail = errnoLabels[rule.Errno]
+			}
+			pass := labelNext
+			if i == len(rules)-1 {
+				pass = labelAllow
+			}
+			condition := rule.Condition
+			if condition == "" {
+				condition = rule.describe()
+			}
+
+			// 小端序下参数的低32位位于偏移处
+			f.addInstruction(BPF_LD|BPF_W|BPF_ABS, 0, 0, uint32(SECCOMP_DATA_ARGS_OFFSET+8*rule.Arg))
+			addComment(fmt.Sprintf("%s: args[%d]", SyscallName(syscallNum), rule.Arg))
+			if rule.Mask != 0xffffffff {
+				f.addInstruction(BPF_ALU|BPF_AND|BPF_K, 0, 0, rule.Mask)
+			}
+			if rule.NotEqual {
+				addJump(BPF_JMP|BPF_JEQ|BPF_K, rule.Value, fail, pass, SyscallName(syscallNum)+": "+condition)
+			} else {
+				addJump(BPF_JMP|BPF_JEQ|BPF_K, rule.Value, pass, fail, SyscallName(syscallNum)+": "+condition)
+			}
+		}
+	}
+
+	// 8. 返回动作
+	targets[labelAllow] = len(f.instructions)
 	f.addInstruction(BPF_RET|BPF_K, 0, 0, SECCOMP_RET_ALLOW)
+	targets[labelDeny] = len(f.instructions)
+	f.addInstruction(BPF_RET|BPF_K, 0, 0, f.denyAction)
+	if needENOSYS {
+		targets[labelENOSYS] = len(f.instructions)
+		f.addInstruction(BPF_RET|BPF_K, 0, 0, SECCOMP_RET_ERRNO|ENOSYS)
+	}
+	if needTrace {
+		targets[labelTrace] = len(f.instructions)
+		f.addInstruction(BPF_RET|BPF_K, 0, 0, SECCOMP_RET_TRACE)
+	}
+	for _, errno := range errnos {
+		targets[errnoLabels[errno]] = len(f.instructions)
+		f.addInstruction(BPF_RET|BPF_K, 0, 0, SECCOMP_RET_ERRNO|uint32(errno))
+	}
+
+	// 回填跳转偏移（相对于下一条指令）
+	resolve := func(index int, label bpfLabel) (uint8, error) {
+		if label == labelNext {
+			return 0, nil
+		}
+		offset := targets[label] - index - 1
+		if offset > 255 {
+			return 0, fmt.Errorf("jump offset %d exceeds BPF limit, too many syscall rules", offset)
+		}
+		return uint8(offset), nil
+	}
+	for _, jump := range jumps {
+		jt, err := resolve(jump.index, jump.jt)
+		if err != nil {
+			return err
+		}
+		jf, err := resolve(jump.index, jump.jf)
+		if err != nil {
+			return err
+		}
+		f.instructions[jump.index].JT = jt
+		f.instructions[jump.index].JF = jf
+	}
 
-	logx.Infof("Built BPF program with %d instructions for %d allowed syscalls",
-		len(f.instructions), len(allowedSyscallList))
+	logx.Infof("Built BPF program with %d instructions for %d allowed, %d denied and %d argument-checked syscalls",
+		len(f.instructions), len(f.allowedSyscalls), len(f.deniedSyscalls), len(ruleLabels))
 
 	return nil
 }
 
+// 未提供Condition时的条件描述
+func (r SyscallArgRule) describe() string {
+	op := "=="
+	if r.NotEqual {
+		op = "!="
+	}
+	return fmt.Sprintf("args[%d] & 0x%x %s 0x%x", r.Arg, r.Mask, op, r.Value)
+}
+
+// 将系统调用集合转换为有序列表
+func sortedSyscalls(syscalls map[int]bool) []int {
+	list := make([]int, 0, len(syscalls))
+	for syscallNum := range syscalls {
+		list = append(list, syscallNum)
+	}
+	sort.Ints(list)
+	return list
+}
+
 // 添加BPF指令的辅助函数
 func (f *SeccompFilter) addInstruction(code uint16, jt, jf uint8, k uint32) {
 	instruction := BPFInstruction{
@@ -264,6 +533,7 @@ func (f *SeccompFilter) Validate() error {
 }
 
 // 获取BPF程序的人类可读表示（用于调试）
+// 每条指令后附注释：比较的系统调用名与参数规则的条件
 func (f *SeccompFilter) GetBPFDisassembly() []string {
 	var disasm []string
 
@@ -278,41 +548,71 @@ func (f *SeccompFilter) GetBPFDisassembly() []string {
 			} else {
 				line = fmt.Sprintf("%3d: LD  #%d", i, inst.K)
 			}
+		case BPF_ALU:
+			switch inst.Code & 0xf0 {
+			case BPF_AND:
+				line = fmt.Sprintf("%3d: AND #0x%x", i, inst.K)
+			case BPF_OR:
+				line = fmt.Sprintf("%3d: OR  #0x%x", i, inst.K)
+			default:
+				line = fmt.Sprintf("%3d: ALU code=0x%x k=0x%x", i, inst.Code, inst.K)
+			}
 		case BPF_JMP:
-			if inst.Code&BPF_JEQ != 0 {
+			switch inst.Code & 0xf0 {
+			case BPF_JA:
+				line = fmt.Sprintf("%3d: JA  %d", i, inst.K)
+			case BPF_JEQ:
 				line = fmt.Sprintf("%3d: JEQ #%d jt=%d jf=%d", i, inst.K, inst.JT, inst.JF)
-			} else if inst.Code&BPF_JGT != 0 {
+			case BPF_JGT:
 				line = fmt.Sprintf("%3d: JGT #%d jt=%d jf=%d", i, inst.K, inst.JT, inst.JF)
-			} else if inst.Code&BPF_JGE != 0 {
+			case BPF_JGE:
 				line = fmt.Sprintf("%3d: JGE #%d jt=%d jf=%d", i, inst.K, inst.JT, inst.JF)
-			} else {
+			case BPF_JSET:
+				line = fmt.Sprintf("%3d: JSET #0x%x jt=%d jf=%d", i, inst.K, inst.JT, inst.JF)
+			default:
 				line = fmt.Sprintf("%3d: JMP jt=%d jf=%d", i, inst.JT, inst.JF)
 			}
 		case BPF_RET:
-			var action string
-			switch inst.K {
-			case SECCOMP_RET_ALLOW:
-				action = "ALLOW"
-			case SECCOMP_RET_KILL_PROCESS:
-				action = "KILL_PROCESS"
-			case SECCOMP_RET_KILL_THREAD:
-				action = "KILL_THREAD"
-			case SECCOMP_RET_TRAP:
-				action = "TRAP"
-			default:
-				action = fmt.Sprintf("0x%x", inst.K)
-			}
-			line = fmt.Sprintf("%3d: RET %s", i, action)
+			line = fmt.Sprintf("%3d: RET %s", i, seccompActionName(inst.K))
 		default:
 			line = fmt.Sprintf("%3d: ??? code=0x%x k=%d", i, inst.Code, inst.K)
 		}
 
+		if comment, ok := f.comments[i]; ok {
+			line = fmt.Sprintf("%-32s ; %s", line, comment)
+		}
 		disasm = append(disasm, line)
 	}
 
 	return disasm
 }
 
+// seccomp返回动作的名称
+func seccompActionName(action uint32) string {
+	switch action {
+	case SECCOMP_RET_ALLOW:
+		return "ALLOW"
+	case SECCOMP_RET_KILL_PROCESS:
+		return "KILL_PROCESS"
+	case SECCOMP_RET_KILL_THREAD:
+		return "KILL_THREAD"
+	case SECCOMP_RET_TRAP:
+		return "TRAP"
+	case SECCOMP_RET_LOG:
+		return "LOG"
+	case SECCOMP_RET_TRACE:
+		return "TRACE"
+	case SECCOMP_RET_ERRNO | ENOSYS:
+		return "ERRNO(ENOSYS)"
+	case SECCOMP_RET_ERRNO | EACCES:
+		return "ERRNO(EACCES)"
+	}
+	if action&0xffff0000 == SECCOMP_RET_ERRNO {
+		return fmt.Sprintf("ERRNO(%d)", action&0xffff)
+	}
+	return fmt.Sprintf("0x%x", action)
+}
+
 // 创建基于语言的seccomp过滤器
 func CreateLanguageSeccompFilter(language string) (*SeccompFilter, error) {
 	// 获取语言特定的系统调用白名单
@@ -323,6 +623,8 @@ func CreateLanguageSeccompFilter(language string) (*SeccompFilter, error) {
 
 	// 创建过滤器，默认动作为终止进程
 	filter := NewSeccompFilter(allowedSyscalls, SECCOMP_RET_KILL_PROCESS)
+	filter.SetDeniedSyscalls(GetSyscallDenylist(language))
+	filter.SetArgRules(GetSyscallArgRules(language))
 
 	logx.Infof("Created seccomp filter for %s with %d allowed syscalls",
 		language, len(allowedSyscalls))
@@ -353,8 +655,10 @@ func CreateLoggingSeccompFilter(language string) (*SeccompFilter, error) {
 		return nil, fmt.Errorf("no syscall whitelist found for language: %s", language)
 	}
 
-	// 使用LOG动作而不是KILL，便于调试
+	// 使用LOG动作而不是KILL，便于调试；显式禁止的系统调用仍然终止进程
 	filter := NewSeccompFilter(allowedSyscalls, SECCOMP_RET_LOG)
+	filter.SetDeniedSyscalls(GetSyscallDenylist(language))
+	filter.SetArgRules(GetSyscallArgRules(language))
 
 	logx.Infof("Created logging seccomp filter for %s", language)
 	return filter, nil
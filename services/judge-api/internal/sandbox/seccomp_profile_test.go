@@ -0,0 +1,712 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// 测试二进制按生产环境的方式以辅助模式重新执行自身，过滤器只作用于辅助进程，不影响测试进程
+func TestMain(m *testing.M) {
+	if IsSandboxHelper() {
+		RunSandboxHelper()
+	}
+	os.Exit(m.Run())
+}
+
+// 在指定语言的seccomp过滤器下运行程序，返回标准输出与等待状态
+func runUnderSeccomp(t *testing.T, language string, stdin string, argv ...string) (string, syscall.WaitStatus) {
+	t.Helper()
+	return runUnderSeccompWithRules(t, language, GetSyscallArgRules(language), stdin, argv...)
+}
+
+// 以语言白名单与指定的参数规则运行命令
+func runUnderSeccompWithRules(t *testing.T, language string, rules []SyscallArgRule, stdin string, argv ...string) (string, syscall.WaitStatus) {
+	t.Helper()
+
+	cmd, err := newSandboxHelperCommand(context.Background(), &SandboxHelperConfig{
+		Executable:      argv[0],
+		Args:            argv,
+		Env:             append(os.Environ(), "GOMAXPROCS=1"),
+		EnableSeccomp:   true,
+		AllowedSyscalls: GetSyscallWhitelist(language),
+		DeniedSyscalls:  GetSyscallDenylist(language),
+		SyscallArgRules: rules,
+		DefaultAction:   SECCOMP_RET_KILL_PROCESS,
+	})
+	if err != nil {
+		t.Fatalf("failed to create seccomp helper: %v", err)
+	}
+	defer closeSandboxHelperFiles(cmd)
+
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatalf("failed to run seccomp helper: %v", err)
+	}
+
+	status := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	if status.Exited() && status.ExitStatus() == SandboxHelperFailureCode && strings.HasPrefix(stderr.String(), "sandbox helper:") {
+		if strings.Contains(stderr.String(), "no_new_privs") || strings.Contains(stderr.String(), "install seccomp filter") {
+			t.Skipf("seccomp filters cannot be installed in this environment: %s", stderr.String())
+		}
+		t.Fatalf("seccomp helper failed: %s", stderr.String())
+	}
+	return stdout.String(), status
+}
+
+// 使用本机Go工具链编译单文件程序（与GoExecutor相同的静态链接方式）
+func buildGoProgram(t *testing.T, source string) string {
+	t.Helper()
+
+	goBinary, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not found")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module main\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	binary := filepath.Join(dir, "main")
+	cmd := exec.Command(goBinary, "build", "-trimpath", "-buildvcs=false", "-o", binary, ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=0", "GOFLAGS=-mod=readonly", "GOWORK=off", "GOPROXY=off")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build go program: %v\n%s", err, output)
+	}
+	return binary
+}
+
+// Go白名单必须覆盖运行时启动、goroutine调度、GC、定时器与标准输入输出
+func TestGoSyscallWhitelistRunsRealBinary(t *testing.T) {
+	binary := buildGoProgram(t, `package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+func main() {
+	reader := bufio.NewReader(os.Stdin)
+	var a, b int
+	fmt.Fscan(reader, &a, &b)
+
+	var wg sync.WaitGroup
+	results := make([][]int, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = make([]int, 1<<16)
+		}(i)
+	}
+	wg.Wait()
+	runtime.GC()
+	time.Sleep(10 * time.Millisecond)
+
+	fmt.Println(a + b + len(results[0])*0)
+}
+`)
+
+	output, status := runUnderSeccomp(t, "go", "1 2\n", binary)
+	if status.Signaled() {
+		t.Fatalf("go program killed by signal %v under seccomp whitelist", status.Signal())
+	}
+	if status.ExitStatus() != 0 {
+		t.Fatalf("go program exited with %d", status.ExitStatus())
+	}
+	if strings.TrimSpace(output) != "3" {
+		t.Fatalf("unexpected output %q", output)
+	}
+}
+
+// 创建套接字不在Go白名单中，应被SIGSYS终止
+func TestGoSyscallWhitelistBlocksSocket(t *testing.T) {
+	binary := buildGoProgram(t, `package main
+
+import "syscall"
+
+func main() {
+	syscall.Socket(syscall.AF_INET, syscall.SOCK_STREAM, 0)
+}
+`)
+
+	_, status := runUnderSeccomp(t, "go", "", binary)
+	if !status.Signaled() || status.Signal() != syscall.SIGSYS {
+		t.Fatalf("expected SIGSYS, got %v", status)
+	}
+}
+
+// 完整执行流程中违规应判为StatusRestrictedFunction并给出系统调用名称，程序捕获SIGSYS也无法继续执行
+// 在完整执行路径下以Go白名单运行程序，返回执行结果与标准输出
+// prepare不为空时在运行前准备工作目录
+func executeGoUnderSeccomp(t *testing.T, source string, prepare func(workDir string)) (*ExecuteResult, string) {
+	t.Helper()
+	if os.Geteuid() != 0 {
+		t.Skip("sandbox execution requires root")
+	}
+	binary := buildGoProgram(t, source)
+
+	// nobody用户需要能够进入工作目录并执行程序
+	workDir, err := os.MkdirTemp("", "seccomp-execute-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(workDir) })
+	if err := os.Chmod(workDir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	program := filepath.Join(workDir, "main")
+	if err := os.WriteFile(program, data, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if prepare != nil {
+		prepare(workDir)
+	}
+
+	config := &SandboxConfig{
+		UID:           65534,
+		GID:           65534,
+		WorkDir:       workDir,
+		TimeLimit:     5000,
+		WallTimeLimit: 10000,
+		MemoryLimit:   262144,
+		StackLimit:    8192,
+		FileSizeLimit: 1024,
+		ProcessLimit:  64,
+		OutputFile:    filepath.Join(workDir, "output.txt"),
+		ErrorFile:     filepath.Join(workDir, "error.txt"),
+		Environment:   []string{"GOMAXPROCS=1"},
+		// 与GoExecutor一致：Go运行时启动时预留大量虚拟地址空间
+		SkipAddressSpaceLimit: true,
+		EnableSeccomp:         true,
+		AllowedSyscalls:       GetSyscallWhitelist("go"),
+		DeniedSyscalls:        GetSyscallDenylist("go"),
+		SyscallArgRules:       GetSyscallArgRules("go"),
+	}
+	result, err := NewSystemCallSandbox(config).Execute(context.Background(), program, nil)
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	output, _ := os.ReadFile(config.OutputFile)
+	return result, string(output)
+}
+
+func TestExecuteReportsRestrictedSyscall(t *testing.T) {
+	result, output := executeGoUnderSeccomp(t, `package main
+
+import (
+	"fmt"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	signal.Ignore(syscall.SIGSYS)
+	syscall.Socket(syscall.AF_INET, syscall.SOCK_STREAM, 0)
+	fmt.Println("survived")
+}
+`, nil)
+
+	if result.Status != StatusRestrictedFunction || result.RestrictedSyscallName != "socket" ||
+		result.RestrictedSyscall != 41 || result.Signal != int(syscall.SIGSYS) {
+		t.Fatalf("unexpected result: status=%d syscall=%s(%d) signal=%d output=%q error=%q", result.Status,
+			result.RestrictedSyscallName, result.RestrictedSyscall, result.Signal, output, result.ErrorOutput)
+	}
+	if strings.Contains(output, "survived") {
+		t.Fatalf("program continued after the violation: %q", output)
+	}
+}
+
+// execve在白名单中只为了启动目标程序，目标程序再次execve（即使是它自己）按违规处理
+func TestExecuteRejectsSecondExecve(t *testing.T) {
+	result, output := executeGoUnderSeccomp(t, `package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+func main() {
+	if len(os.Args) > 1 {
+		fmt.Println("replaced")
+		return
+	}
+	fmt.Println("started")
+	err := syscall.Exec("/proc/self/exe", []string{"main", "again"}, nil)
+	fmt.Println("exec failed:", err)
+}
+`, nil)
+
+	if result.Status != StatusRestrictedFunction || result.RestrictedSyscallName != "execve" {
+		t.Fatalf("unexpected result: status=%d syscall=%s output=%q error=%q", result.Status,
+			result.RestrictedSyscallName, output, result.ErrorOutput)
+	}
+	if !strings.Contains(output, "started") || strings.Contains(output, "replaced") {
+		t.Fatalf("unexpected output: %q", output)
+	}
+}
+
+// 参数规则：只读打开与W^X映射正常工作，可写可执行映射与创建进程被终止
+// 没有init跟踪时open_read_only的写方式打开返回ENOSYS，这里不启用，路径检查见TestExecuteAllowsWritesOnlyInWorkDir
+func TestGoSyscallArgRules(t *testing.T) {
+	binary := buildGoProgram(t, `package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+func main() {
+	switch os.Args[1] {
+	case "read":
+		_, err := os.ReadFile(os.Args[0])
+		fmt.Println(err)
+	case "rx":
+		mem, err := syscall.Mmap(-1, 0, 4096, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE|syscall.MAP_ANON)
+		if err == nil {
+			err = syscall.Mprotect(mem, syscall.PROT_READ|syscall.PROT_EXEC)
+		}
+		fmt.Println(err)
+	case "wx":
+		syscall.Mmap(-1, 0, 4096, syscall.PROT_READ|syscall.PROT_WRITE|syscall.PROT_EXEC, syscall.MAP_PRIVATE|syscall.MAP_ANON)
+	case "mprotect":
+		mem, _ := syscall.Mmap(-1, 0, 4096, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE|syscall.MAP_ANON)
+		syscall.Mprotect(mem, syscall.PROT_READ|syscall.PROT_WRITE|syscall.PROT_EXEC)
+	case "fork":
+		syscall.ForkExec("/bin/true", []string{"true"}, nil)
+	}
+	fmt.Println("done")
+}
+`)
+	rules, err := ResolveSyscallArgRules([]string{SyscallRuleCloneThreadOnly, SyscallRuleNoWritableExec})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		mode   string
+		output string
+		killed bool
+	}{
+		{mode: "read", output: "<nil>\ndone"},
+		{mode: "rx", output: "<nil>\ndone"},
+		{mode: "wx", killed: true},
+		{mode: "mprotect", killed: true},
+		{mode: "fork", killed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			output, status := runUnderSeccompWithRules(t, "go", rules, "", binary, tt.mode)
+			if tt.killed {
+				if !status.Signaled() || status.Signal() != syscall.SIGSYS {
+					t.Fatalf("expected SIGSYS, got %v output=%q", status, output)
+				}
+				return
+			}
+			if status.Signaled() || status.ExitStatus() != 0 || strings.TrimSpace(output) != tt.output {
+				t.Fatalf("unexpected result %v output=%q", status, output)
+			}
+		})
+	}
+}
+
+// open_read_only：写方式的打开只放行工作目录之内的路径，..、符号链接与dirfd都不能绕过；只读打开不受影响
+func TestExecuteAllowsWritesOnlyInWorkDir(t *testing.T) {
+	// nobody可写的外部目录：写入被拒绝只可能来自路径检查
+	outside, err := os.MkdirTemp("", "seccomp-outside-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(outside) })
+	if err := os.Chmod(outside, 0777); err != nil {
+		t.Fatal(err)
+	}
+	escape := filepath.Join(filepath.Dir(outside), "seccomp-escape.txt")
+	t.Cleanup(func() { os.Remove(escape) })
+
+	result, output := executeGoUnderSeccomp(t, fmt.Sprintf(`package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+func report(name string, err error) {
+	switch {
+	case err == nil:
+		fmt.Println(name, "ok")
+	case errors.Is(err, syscall.EACCES):
+		fmt.Println(name, "denied")
+	default:
+		fmt.Println(name, err)
+	}
+}
+
+func create(name, path string) {
+	file, err := os.Create(path)
+	if err == nil {
+		file.Close()
+	}
+	report(name, err)
+}
+
+func main() {
+	outside := %q
+	dir, _ := os.ReadFile("workdir.txt")
+	create("relative", "relative.txt")
+	create("absolute", filepath.Join(string(dir), "absolute.txt"))
+	create("subdir", "sub/nested.txt")
+	create("outside", filepath.Join(outside, "outside.txt"))
+	create("parent", "../seccomp-escape.txt")
+	create("link", "link")
+	create("linkdir", "linkdir/through.txt")
+
+	fd, err := syscall.Open(outside, syscall.O_RDONLY|syscall.O_DIRECTORY, 0)
+	report("read", err)
+	_, err = syscall.Openat(fd, "dirfd.txt", syscall.O_WRONLY|syscall.O_CREAT, 0644)
+	report("dirfd", err)
+}
+`, outside), func(workDir string) {
+		// 白名单不包含getcwd，由程序读取工作目录的绝对路径
+		if err := os.WriteFile(filepath.Join(workDir, "workdir.txt"), []byte(workDir), 0644); err != nil {
+			t.Fatal(err)
+		}
+		sub := filepath.Join(workDir, "sub")
+		if err := os.Mkdir(sub, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(sub, 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(filepath.Join(outside, "link.txt"), filepath.Join(workDir, "link")); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(outside, filepath.Join(workDir, "linkdir")); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	want := "relative ok\nabsolute ok\nsubdir ok\noutside denied\nparent denied\nlink denied\nlinkdir denied\nread ok\ndirfd denied"
+	if result.Status != StatusAccepted || strings.TrimSpace(output) != want {
+		t.Fatalf("unexpected result: status=%d syscall=%s output=%q error=%q", result.Status,
+			result.RestrictedSyscallName, output, result.ErrorOutput)
+	}
+	entries, err := os.ReadDir(outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("files were created outside the work dir: %v", entries)
+	}
+	if _, err := os.Stat(escape); !os.IsNotExist(err) {
+		t.Fatalf("file was created in the parent directory: %v", err)
+	}
+}
+
+func TestSeccompDisassemblyShowsArgRules(t *testing.T) {
+	rules, err := ResolveSyscallArgRules([]string{SyscallRuleCloneThreadOnly, SyscallRuleOpenReadOnly, SyscallRuleNoWritableExec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter := NewSeccompFilter(GetSyscallWhitelist("go"), SECCOMP_RET_KILL_PROCESS)
+	filter.SetDeniedSyscalls(GetSyscallDenylist("go"))
+	filter.SetArgRules(rules)
+	if err := filter.buildBPFProgram(); err != nil {
+		t.Fatal(err)
+	}
+
+	disasm := strings.Join(filter.GetBPFDisassembly(), "\n")
+	for _, want := range []string{
+		"; clone3: arguments not inspectable, use clone",
+		"; clone: check arguments",
+		"; clone: flags & CLONE_THREAD == CLONE_THREAD",
+		"AND #0x243",
+		"; openat: flags & (O_ACCMODE|O_CREAT|O_TRUNC) == O_RDONLY",
+		"; mmap: prot & (PROT_WRITE|PROT_EXEC) != PROT_WRITE|PROT_EXEC",
+		"RET TRACE",
+		"RET ERRNO(ENOSYS)",
+	} {
+		if !strings.Contains(disasm, want) {
+			t.Fatalf("disassembly should contain %q:\n%s", want, disasm)
+		}
+	}
+}
+
+// 定位本机Node解释器
+func lookupNode(t *testing.T) string {
+	t.Helper()
+
+	nodeBinary, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node not found")
+	}
+	return nodeBinary
+}
+
+// JavaScript白名单必须覆盖V8启动、后台线程、定时器以及以可读流方式读取标准输入
+func TestJavaScriptSyscallWhitelistRunsRealScript(t *testing.T) {
+	nodeBinary := lookupNode(t)
+
+	script := filepath.Join(t.TempDir(), "main.js")
+	source := `const lines = [];
+require('readline').createInterface({ input: process.stdin })
+  .on('line', line => lines.push(line))
+  .on('close', () => {
+    setTimeout(() => {
+      const [a, b] = lines[0].split(' ').map(Number);
+      const data = new Array(1 << 20).fill(0).map((_, i) => i);
+      console.log(a + b + data.length * 0);
+    }, 5);
+  });
+`
+	if err := os.WriteFile(script, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output, status := runUnderSeccomp(t, "javascript", "1 2\n", nodeBinary, "--max-old-space-size=64", "--v8-pool-size=1", script)
+	if status.Signaled() {
+		t.Fatalf("node killed by signal %v under seccomp whitelist", status.Signal())
+	}
+	if status.ExitStatus() != 0 {
+		t.Fatalf("node exited with %d", status.ExitStatus())
+	}
+	if strings.TrimSpace(output) != "3" {
+		t.Fatalf("unexpected output %q", output)
+	}
+}
+
+// 建立网络连接需要socket，不在JavaScript白名单中
+func TestJavaScriptSyscallWhitelistBlocksNetwork(t *testing.T) {
+	nodeBinary := lookupNode(t)
+
+	_, status := runUnderSeccomp(t, "javascript", "", nodeBinary, "-e", "require('net').connect(80, '127.0.0.1')")
+	if !status.Signaled() || status.Signal() != syscall.SIGSYS {
+		t.Fatalf("expected SIGSYS, got %v", status)
+	}
+}
+
+// Node只能创建线程：child_process经由fork产生不含CLONE_THREAD的clone，不再只依赖模块加载守卫
+func TestJavaScriptSyscallWhitelistBlocksChildProcess(t *testing.T) {
+	nodeBinary := lookupNode(t)
+
+	output, status := runUnderSeccomp(t, "javascript", "", nodeBinary, "-e",
+		"require('child_process').spawnSync('/bin/true'); console.log('spawned')")
+	if !status.Signaled() || status.Signal() != syscall.SIGSYS {
+		t.Fatalf("expected SIGSYS, got %v output=%q", status, output)
+	}
+}
+
+// 定位本机Python解释器的真实路径
+// pyenv等工具提供的python3是shell脚本，在过滤器下会因fork/execve被终止，因此先在过滤器外解析sys.executable
+func lookupPython(t *testing.T) string {
+	t.Helper()
+
+	pythonBinary, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not found")
+	}
+	output, err := exec.Command(pythonBinary, "-c", "import sys; print(sys.executable)").Output()
+	if err != nil {
+		t.Skipf("failed to resolve python3 interpreter: %v", err)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// 回归脚本：testdata/seccomp/python/allowed下的脚本必须正常退出，forbidden下的脚本必须被SIGSYS终止
+// 同名.in文件作为标准输入；新增用例只需放入脚本文件
+func pythonSeccompScripts(t *testing.T, kind string) []string {
+	t.Helper()
+
+	scripts, err := filepath.Glob(filepath.Join("testdata", "seccomp", "python", kind, "*.py"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scripts) == 0 {
+		t.Fatalf("no %s python scripts found", kind)
+	}
+	return scripts
+}
+
+func TestPythonSyscallProfileRunsAllowedScripts(t *testing.T) {
+	pythonBinary := lookupPython(t)
+
+	for _, script := range pythonSeccompScripts(t, "allowed") {
+		script := script
+		t.Run(strings.TrimSuffix(filepath.Base(script), ".py"), func(t *testing.T) {
+			stdin, err := os.ReadFile(strings.TrimSuffix(script, ".py") + ".in")
+			if err != nil && !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+
+			output, status := runUnderSeccomp(t, "python", string(stdin), pythonBinary, script)
+			if status.Signaled() {
+				t.Fatalf("python killed by signal %v under seccomp profile", status.Signal())
+			}
+			if status.ExitStatus() != 0 {
+				t.Fatalf("python exited with %d", status.ExitStatus())
+			}
+			if strings.TrimSpace(output) == "" {
+				t.Fatal("python script produced no output")
+			}
+		})
+	}
+}
+
+func TestPythonSyscallProfileBlocksForbiddenScripts(t *testing.T) {
+	pythonBinary := lookupPython(t)
+
+	for _, script := range pythonSeccompScripts(t, "forbidden") {
+		script := script
+		t.Run(strings.TrimSuffix(filepath.Base(script), ".py"), func(t *testing.T) {
+			_, status := runUnderSeccomp(t, "python", "", pythonBinary, script)
+			if !status.Signaled() || status.Signal() != syscall.SIGSYS {
+				t.Fatalf("expected SIGSYS, got %v", status)
+			}
+		})
+	}
+}
+
+// 完整执行路径：判题服务以辅助模式安装过滤器并降权执行，不依赖gcc/libseccomp，也不在工作目录留下构建产物
+func TestExecuteInstallsSeccompThroughHelper(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("sandbox execution requires root")
+	}
+
+	binary := buildGoProgram(t, `package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	var a, b int
+	fmt.Scan(&a, &b)
+	fmt.Println(a + b)
+	os.WriteFile("owner.txt", nil, 0644)
+}
+`)
+
+	// nobody用户需要能够进入工作目录并执行程序
+	workDir, err := os.MkdirTemp("", "seccomp-execute-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+	if err := os.Chmod(workDir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	program := filepath.Join(workDir, "main")
+	data, err := os.ReadFile(binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(program, data, 0755); err != nil {
+		t.Fatal(err)
+	}
+	inputFile := filepath.Join(workDir, "input.txt")
+	if err := os.WriteFile(inputFile, []byte("1 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var before syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_AS, &before); err != nil {
+		t.Fatal(err)
+	}
+
+	config := &SandboxConfig{
+		UID:           65534,
+		GID:           65534,
+		WorkDir:       workDir,
+		TimeLimit:     2000,
+		WallTimeLimit: 5000,
+		MemoryLimit:   65536,
+		StackLimit:    8192,
+		FileSizeLimit: 1024,
+		ProcessLimit:  16,
+		EnableSeccomp: true,
+		// Go运行时启动时预留的虚拟地址超过RLIMIT_AS
+		SkipAddressSpaceLimit: true,
+		AllowedSyscalls:       GetSyscallWhitelist("go"),
+		DeniedSyscalls:        GetSyscallDenylist("go"),
+		InputFile:             inputFile,
+		OutputFile:            filepath.Join(workDir, "output.txt"),
+		ErrorFile:             filepath.Join(workDir, "error.txt"),
+		Environment:           []string{"PATH=/usr/bin:/bin", "GOMAXPROCS=1"},
+	}
+
+	result, err := NewSystemCallSandbox(config).Execute(context.Background(), program, nil)
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if result.Status != StatusAccepted || result.ExitCode != 0 {
+		t.Fatalf("unexpected result: status=%d exit=%d signal=%d stderr=%q", result.Status, result.ExitCode, result.Signal, result.ErrorOutput)
+	}
+
+	output, err := os.ReadFile(config.OutputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(output)) != "3" {
+		t.Fatalf("unexpected output %q", output)
+	}
+
+	// 程序创建的文件属于nobody，说明辅助进程已降权
+	info, err := os.Stat(filepath.Join(workDir, "owner.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uid := info.Sys().(*syscall.Stat_t).Uid; uid != 65534 {
+		t.Fatalf("program ran as uid %d, want 65534", uid)
+	}
+
+	// rlimit只作用于辅助进程，判题进程自身不受影响
+	var after syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_AS, &after); err != nil {
+		t.Fatal(err)
+	}
+	if after != before {
+		t.Fatalf("judge process RLIMIT_AS changed from %+v to %+v", before, after)
+	}
+
+	entries, err := os.ReadDir(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "seccomp") {
+			t.Fatalf("unexpected seccomp artifact in work dir: %s", entry.Name())
+		}
+	}
+}
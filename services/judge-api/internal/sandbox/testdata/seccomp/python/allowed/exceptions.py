@@ -0,0 +1,10 @@
+# 异常处理与traceback格式化
+import time
+import traceback
+
+try:
+    1 / 0
+except ZeroDivisionError:
+    message = traceback.format_exc().splitlines()[-1]
+time.sleep(0.01)
+print(message)
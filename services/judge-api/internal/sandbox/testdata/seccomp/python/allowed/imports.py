@@ -0,0 +1,9 @@
+# 判题常用标准库的import与基本运算
+import array, bisect, collections, copy, datetime, decimal, fractions, functools
+import heapq, itertools, json, math, operator, random, re, statistics, string, sys
+
+print(math.gcd(12, 18), heapq.nsmallest(2, [5, 1, 3]), bisect.bisect([1, 2, 4], 3))
+print(re.sub(r"\d", "#", "a1b2"), json.dumps({"a": [1, 2]}), decimal.Decimal(1) / 3)
+print(fractions.Fraction(1, 3) * 3, random.Random(1).randint(1, 9), statistics.mean([1, 2, 3]))
+print(collections.Counter("abca").most_common(1), list(itertools.permutations(range(3), 2))[:2])
+print(datetime.date(2020, 1, 1).isoformat(), functools.reduce(operator.mul, range(1, 6)))
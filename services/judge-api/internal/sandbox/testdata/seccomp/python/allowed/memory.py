@@ -0,0 +1,5 @@
+# 大列表分配与释放（触发mremap/munmap）
+squares = [i * i for i in range(2 * 10**6)]
+table = {i: str(i) for i in range(10**5)}
+del squares
+print(len(table))
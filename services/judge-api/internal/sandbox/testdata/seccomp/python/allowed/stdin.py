@@ -0,0 +1,8 @@
+# 按行读取与一次性读取标准输入
+import sys
+
+input = sys.stdin.readline
+n = int(input())
+values = list(map(int, input().split()))
+rest = sys.stdin.read().split()
+print(n, sum(values), len(rest))
@@ -0,0 +1,19 @@
+# 用线程扩大栈空间进行深递归，需要clone(CLONE_THREAD)
+import sys
+import threading
+
+sys.setrecursionlimit(1 << 20)
+threading.stack_size(1 << 26)
+
+
+def depth(n):
+    return 0 if n == 0 else 1 + depth(n - 1)
+
+
+def main():
+    print(depth(100000))
+
+
+thread = threading.Thread(target=main)
+thread.start()
+thread.join()
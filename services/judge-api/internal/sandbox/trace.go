@@ -0,0 +1,302 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"gopkg.in/yaml.v3"
+)
+
+// 系统调用学习模式
+// 原理：执行阶段安装默认动作为SECCOMP_RET_TRACE的过滤器，目标程序（含所有线程与子进程）的每次系统调用
+// 都会产生PTRACE_EVENT_SECCOMP停止，判题服务读取orig_rax计数后放行，得到程序实际使用的系统调用集合
+// 不使用SECCOMP_RET_LOG：其记录写入内核审计日志，容器中通常无法读取，也无法区分不同的运行
+// 学习模式以wait4(-1)等待所有被跟踪的进程，会回收当前进程的任意子进程，只用于管理命令，不能在判题服务中使用
+
+// ptrace常量（syscall包未定义）
+const (
+	ptraceOptionTraceSeccomp = 0x80     // PTRACE_O_TRACESECCOMP
+	ptraceOptionExitKill     = 0x100000 // PTRACE_O_EXITKILL
+	ptraceEventSeccomp       = 7        // PTRACE_EVENT_SECCOMP
+)
+
+// 跟踪目标程序的全部线程与子进程
+const syscallTraceOptions = ptraceOptionTraceSeccomp | ptraceOptionExitKill |
+	syscall.PTRACE_O_TRACECLONE | syscall.PTRACE_O_TRACEFORK | syscall.PTRACE_O_TRACEVFORK | syscall.PTRACE_O_TRACEEXEC
+
+// SyscallProfile 学习模式生成的系统调用配置
+type SyscallProfile struct {
+	Language string                `yaml:"language"`
+	Samples  int                   `yaml:"samples"`  // 运行的样例程序数
+	Syscalls []SyscallProfileEntry `yaml:"syscalls"` // 按系统调用号排序
+}
+
+// SyscallProfileEntry 观察到的单个系统调用
+type SyscallProfileEntry struct {
+	Name    string `yaml:"name"`
+	Number  int    `yaml:"number"`
+	Count   int    `yaml:"count"`   // 所有样例中的调用总次数
+	Samples int    `yaml:"samples"` // 使用了该系统调用的样例数
+}
+
+// SyscallProfileDiff 观察结果与当前白名单、黑名单的差异
+type SyscallProfileDiff struct {
+	Added  []SyscallProfileEntry // 观察到但不在白名单中，需要加入
+	Unused []int                 // 在白名单中但没有样例使用，可以考虑移除
+	Denied []SyscallProfileEntry // 观察到但被黑名单禁止，需要人工确认
+}
+
+// NewSyscallProfile 汇总各样例的系统调用统计
+func NewSyscallProfile(language string, traces []map[int]int) *SyscallProfile {
+	profile := &SyscallProfile{Language: language, Samples: len(traces)}
+
+	entries := make(map[int]*SyscallProfileEntry)
+	for _, trace := range traces {
+		for nr, count := range trace {
+			entry, ok := entries[nr]
+			if !ok {
+				entry = &SyscallProfileEntry{Name: SyscallName(nr), Number: nr}
+				entries[nr] = entry
+			}
+			entry.Count += count
+			entry.Samples++
+		}
+	}
+
+	for _, entry := range entries {
+		profile.Syscalls = append(profile.Syscalls, *entry)
+	}
+	sort.Slice(profile.Syscalls, func(i, j int) bool {
+		return profile.Syscalls[i].Number < profile.Syscalls[j].Number
+	})
+	return profile
+}
+
+// YAML 序列化为YAML
+func (p *SyscallProfile) YAML() ([]byte, error) {
+	return yaml.Marshal(p)
+}
+
+// Diff 与当前的白名单、黑名单比较
+func (p *SyscallProfile) Diff(allowed, denied []int) *SyscallProfileDiff {
+	allowedSet := make(map[int]bool, len(allowed))
+	for _, nr := range allowed {
+		allowedSet[nr] = true
+	}
+	deniedSet := make(map[int]bool, len(denied))
+	for _, nr := range denied {
+		deniedSet[nr] = true
+	}
+
+	diff := &SyscallProfileDiff{}
+	observed := make(map[int]bool, len(p.Syscalls))
+	for _, entry := range p.Syscalls {
+		observed[entry.Number] = true
+		switch {
+		case deniedSet[entry.Number]:
+			diff.Denied = append(diff.Denied, entry)
+		case !allowedSet[entry.Number]:
+			diff.Added = append(diff.Added, entry)
+		}
+	}
+	for nr := range allowedSet {
+		if !observed[nr] {
+			diff.Unused = append(diff.Unused, nr)
+		}
+	}
+	sort.Ints(diff.Unused)
+	return diff
+}
+
+// Empty 观察结果与当前配置是否一致
+func (d *SyscallProfileDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Unused) == 0 && len(d.Denied) == 0
+}
+
+// String 以diff风格输出：+需要加入白名单，-没有样例使用，!被黑名单禁止
+func (d *SyscallProfileDiff) String() string {
+	var b strings.Builder
+	for _, entry := range d.Added {
+		fmt.Fprintf(&b, "+ %3d %-20s count=%d samples=%d\n", entry.Number, entry.Name, entry.Count, entry.Samples)
+	}
+	for _, nr := range d.Unused {
+		fmt.Fprintf(&b, "- %3d %s\n", nr, SyscallName(nr))
+	}
+	for _, entry := range d.Denied {
+		fmt.Fprintf(&b, "! %3d %-20s count=%d samples=%d (denied)\n", entry.Number, entry.Name, entry.Count, entry.Samples)
+	}
+	return b.String()
+}
+
+// 学习模式执行：不做系统调用限制，记录目标程序使用的全部系统调用
+// 不经过PID namespace中的init（ptrace需要由判题服务直接跟踪执行阶段），其余隔离设置与正常执行相同
+func (s *SystemCallSandbox) executeTraced(ctx context.Context, helperConfig *SandboxHelperConfig) (*ExecuteResult, error) {
+	defer s.cleanupCgroup()
+
+	helperConfig.EnableSeccomp = true
+	helperConfig.AllowedSyscalls = nil
+	helperConfig.DeniedSyscalls = nil
+	helperConfig.SyscallArgRules = nil
+	helperConfig.DefaultAction = SECCOMP_RET_TRACE
+
+	cmd, err := newSandboxHelperCommand(ctx, helperConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox helper: %w", err)
+	}
+	defer closeSandboxHelperFiles(cmd)
+
+	// 执行阶段自成进程组，超时或输出超限时整组终止
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: s.buildCloneFlags() &^ syscall.CLONE_NEWPID,
+		Ptrace:     true,
+		Setpgid:    true,
+		Pdeathsig:  syscall.SIGKILL,
+	}
+	if s.config.Rootless {
+		setRootlessIDMappings(cmd.SysProcAttr)
+	}
+	if s.config.Chroot == "" {
+		cmd.Dir = s.config.WorkDir
+	}
+
+	if err := s.setupIO(cmd); err != nil {
+		return nil, fmt.Errorf("failed to setup IO: %w", err)
+	}
+	defer s.output.close()
+
+	// ptrace请求必须来自启动被跟踪进程的线程
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	startTime := time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start process: %w", err)
+	}
+	closeSandboxHelperFiles(cmd)
+
+	pid := cmd.Process.Pid
+	killGroup := func() { syscall.Kill(-pid, syscall.SIGKILL) }
+	s.output.start(killGroup)
+
+	var wallExceeded atomic.Bool
+	if wallTimeLimit := s.wallTimeLimit(); wallTimeLimit > 0 {
+		timer := time.AfterFunc(time.Duration(wallTimeLimit)*time.Millisecond, func() {
+			wallExceeded.Store(true)
+			killGroup()
+		})
+		defer timer.Stop()
+	}
+
+	syscalls, status, rusage, err := traceSyscalls(pid)
+	if err != nil {
+		killGroup()
+		return nil, fmt.Errorf("failed to trace process: %w", err)
+	}
+	s.output.wait()
+
+	result := &ExecuteResult{
+		ResourceUsage: &ResourceUsageDetail{ControlMethod: s.getControlMethod()},
+		Syscalls:      syscalls,
+		OutputSize:    s.output.outputSize(),
+		MemoryUsed:    rusage.Maxrss,
+	}
+	result.ResourceUsage.LimitExceeded = "none"
+	switch {
+	case s.output.limitExceeded():
+		result.Status = StatusOutputLimitExceeded
+		result.ResourceUsage.LimitExceeded = "output"
+	case wallExceeded.Load():
+		result.Status = StatusTimeLimitExceeded
+		result.ResourceUsage.LimitExceeded = "wall"
+	case status.Signaled():
+		result.Status = StatusRuntimeError
+		result.Signal = int(status.Signal())
+	default:
+		result.Status = StatusAccepted
+		result.ExitCode = status.ExitStatus()
+	}
+	result.CPUTime = (rusage.Utime.Sec+rusage.Stime.Sec)*1000 + (rusage.Utime.Usec+rusage.Stime.Usec)/1000
+	result.WallTime = time.Since(startTime).Milliseconds()
+	result.TimeUsed = result.CPUTime
+	if s.config.TimeLimitMetric == TimeLimitMetricWall {
+		result.TimeUsed = result.WallTime
+	}
+	result.ErrorOutput = s.readErrorOutput()
+
+	logx.Infof("Traced execution completed: status=%d, syscalls=%d", result.Status, len(syscalls))
+	return result, nil
+}
+
+// 跟踪进程树直到全部退出，返回各系统调用号的次数、主进程的退出状态与资源使用
+func traceSyscalls(pid int) (map[int]int, syscall.WaitStatus, syscall.Rusage, error) {
+	syscalls := make(map[int]int)
+	var status, mainStatus syscall.WaitStatus
+	var rusage, mainRusage syscall.Rusage
+
+	// 启动后首先停在辅助进程的execve处，此时设置跟踪选项（之后的线程与子进程自动被跟踪）
+	if _, err := syscall.Wait4(pid, &status, syscall.WALL, nil); err != nil {
+		return nil, status, mainRusage, fmt.Errorf("failed to wait for helper: %w", err)
+	}
+	if !status.Stopped() {
+		return nil, status, mainRusage, fmt.Errorf("helper exited before tracing: %v", status)
+	}
+	if err := syscall.PtraceSetOptions(pid, syscallTraceOptions); err != nil {
+		return nil, status, mainRusage, fmt.Errorf("failed to set ptrace options: %w", err)
+	}
+	if err := syscall.PtraceCont(pid, 0); err != nil {
+		return nil, status, mainRusage, fmt.Errorf("failed to continue helper: %w", err)
+	}
+
+	for {
+		wpid, err := syscall.Wait4(-1, &status, syscall.WALL, &rusage)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err == syscall.ECHILD {
+			break
+		}
+		if err != nil {
+			return nil, mainStatus, mainRusage, err
+		}
+
+		if status.Exited() || status.Signaled() {
+			if wpid == pid {
+				mainStatus, mainRusage = status, rusage
+				// 主进程结束后残留的子进程一律终止
+				syscall.Kill(-pid, syscall.SIGKILL)
+			}
+			continue
+		}
+		if !status.Stopped() {
+			continue
+		}
+
+		signal := 0
+		switch stopSignal := status.StopSignal(); {
+		case stopSignal == syscall.SIGTRAP && status.TrapCause() == ptraceEventSeccomp:
+			var regs syscall.PtraceRegs
+			if err := syscall.PtraceGetRegs(wpid, &regs); err == nil {
+				syscalls[int(regs.Orig_rax)]++
+			}
+		case stopSignal == syscall.SIGTRAP:
+			// clone/fork/exec事件
+		case stopSignal == syscall.SIGSTOP:
+			// 新线程与子进程被自动跟踪时的初始停止
+		default:
+			signal = int(stopSignal)
+		}
+		// ESRCH：进程已被杀死，下一次wait4会得到退出状态
+		if err := syscall.PtraceCont(wpid, signal); err != nil && err != syscall.ESRCH {
+			return nil, mainStatus, mainRusage, fmt.Errorf("failed to continue process %d: %w", wpid, err)
+		}
+	}
+
+	return syscalls, mainStatus, mainRusage, nil
+}
@@ -0,0 +1,55 @@
+package sandbox
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestExecuteTracesSyscalls(t *testing.T) {
+	// 子进程的系统调用同样需要被记录
+	result, output := runShellInSandboxWith(t, "echo traced; ls / > /dev/null", func(config *SandboxConfig) {
+		config.TraceSyscalls = true
+	})
+
+	if result.Status != StatusAccepted || result.ExitCode != 0 || output != "traced" {
+		t.Fatalf("unexpected result: status=%d exit=%d output=%q stderr=%q", result.Status, result.ExitCode, output, result.ErrorOutput)
+	}
+	for _, name := range []string{"execve", "write", "exit_group", "getdents64"} {
+		nr, _ := SyscallNumber(name)
+		if result.Syscalls[nr] == 0 {
+			t.Fatalf("%s should be observed, got %v", name, result.Syscalls)
+		}
+	}
+}
+
+func TestSyscallProfileDiff(t *testing.T) {
+	profile := NewSyscallProfile("c", []map[int]int{
+		{0: 3, 1: 2, 231: 1},
+		{0: 1, 59: 1, 231: 1},
+	})
+
+	data, err := profile.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded SyscallProfile
+	if err := yaml.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Samples != 2 || len(decoded.Syscalls) != 4 {
+		t.Fatalf("unexpected profile:\n%s", data)
+	}
+	if read := decoded.Syscalls[0]; read.Name != "read" || read.Count != 4 || read.Samples != 2 {
+		t.Fatalf("unexpected read entry %+v", read)
+	}
+
+	diff := profile.Diff([]int{0, 1, 3, 231}, []int{59})
+	if len(diff.Added) != 0 || len(diff.Unused) != 1 || diff.Unused[0] != 3 || len(diff.Denied) != 1 || diff.Denied[0].Name != "execve" {
+		t.Fatalf("unexpected diff:\n%s", diff)
+	}
+	if !strings.Contains(diff.String(), "-   3 close") || !strings.Contains(diff.String(), "!  59 execve") {
+		t.Fatalf("unexpected diff output:\n%s", diff)
+	}
+}
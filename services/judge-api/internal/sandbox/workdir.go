@@ -0,0 +1,154 @@
+package sandbox
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// 每次判题独立的tmpfs工作目录
+// 原理：源代码、可执行文件与各测试点的输入输出都放在一个限制大小与inode数的tmpfs中，
+// 选手程序无法写满主机磁盘、也无法创建海量小文件，计时也不受磁盘I/O干扰；判题结束后卸载，内容随之丢弃
+// 编译与每个测试点在各自的mount namespace中执行，判题服务也需要读写这些文件，因此tmpfs挂载在判题服务的namespace中，
+// 沙箱创建namespace时得到它的副本（启用根文件系统时再绑定挂载到新根中）
+// 资源池预先启动的init在tmpfs挂载之前已经创建了namespace：工作目录的上级目录设置为共享挂载（MS_SHARED），
+// 其下新挂载、卸载的tmpfs会传播到这些namespace中；执行阶段切换根文件系统时才改为私有
+
+// WorkDirQuota 工作目录tmpfs的容量限制
+type WorkDirQuota struct {
+	SizeBytes int64 // 总大小（字节）
+	Inodes    int64 // 文件与目录总数
+	UID       int   // tmpfs根目录的所有者（沙箱内的运行用户）
+	GID       int
+}
+
+// PrepareWorkDirRoot 将工作目录的上级目录设置为共享挂载点，并卸载上次运行残留的tmpfs
+// 需要CAP_SYS_ADMIN，失败时调用方应回退为普通目录
+func PrepareWorkDirRoot(root string) error {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", root, err)
+	}
+	// mountinfo中是解析符号链接后的路径
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", root, err)
+	}
+
+	mountPoints, err := readMountPoints()
+	if err != nil {
+		return err
+	}
+
+	// 判题服务异常退出时残留的工作目录tmpfs，先卸载子路径
+	var stale []string
+	for _, mountPoint := range mountPoints {
+		if strings.HasPrefix(mountPoint, root+"/") {
+			stale = append(stale, mountPoint)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(stale)))
+	for _, mountPoint := range stale {
+		if err := syscall.Unmount(mountPoint, syscall.MNT_DETACH); err != nil {
+			logx.Errorf("Failed to unmount stale work directory %s: %v", mountPoint, err)
+		}
+	}
+
+	// 只有挂载点才能设置传播类型，不是挂载点时先绑定挂载到自身
+	isMountPoint := false
+	for _, mountPoint := range mountPoints {
+		if mountPoint == root {
+			isMountPoint = true
+			break
+		}
+	}
+	if !isMountPoint {
+		if err := syscall.Mount(root, root, "", syscall.MS_BIND, ""); err != nil {
+			return fmt.Errorf("failed to bind mount %s: %w", root, err)
+		}
+	}
+	if err := syscall.Mount("", root, "", syscall.MS_SHARED, ""); err != nil {
+		return fmt.Errorf("failed to make %s shared: %w", root, err)
+	}
+	return nil
+}
+
+// MountWorkDir 在已创建的空目录上挂载限制大小与inode数的tmpfs
+// 不使用noexec：编译产物需要在其中执行
+func MountWorkDir(dir string, quota WorkDirQuota) error {
+	options := fmt.Sprintf("mode=0777,uid=%d,gid=%d", quota.UID, quota.GID)
+	if quota.SizeBytes > 0 {
+		options += fmt.Sprintf(",size=%d", quota.SizeBytes)
+	}
+	if quota.Inodes > 0 {
+		options += fmt.Sprintf(",nr_inodes=%d", quota.Inodes)
+	}
+
+	if err := syscall.Mount("judge-workdir", dir, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, options); err != nil {
+		return fmt.Errorf("failed to mount tmpfs on %s: %w", dir, err)
+	}
+	return nil
+}
+
+// UnmountWorkDir 卸载工作目录的tmpfs，仍在使用的进程退出后内核释放其内容
+func UnmountWorkDir(dir string) error {
+	if err := syscall.Unmount(dir, syscall.MNT_DETACH); err != nil {
+		return fmt.Errorf("failed to unmount %s: %w", dir, err)
+	}
+	return nil
+}
+
+// 当前mount namespace中的所有挂载点
+func readMountPoints() ([]string, error) {
+	file, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mountinfo: %w", err)
+	}
+	defer file.Close()
+
+	var mountPoints []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// 第5个字段为挂载点，空格等字符以八进制转义
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		mountPoints = append(mountPoints, unescapeMountPath(fields[4]))
+	}
+	return mountPoints, scanner.Err()
+}
+
+// 还原mountinfo中的八进制转义（如\040表示空格）
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, `\`) {
+		return path
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+3 < len(path) {
+			var value byte
+			valid := true
+			for _, digit := range path[i+1 : i+4] {
+				if digit < '0' || digit > '7' {
+					valid = false
+					break
+				}
+				value = value*8 + byte(digit-'0')
+			}
+			if valid {
+				builder.WriteByte(value)
+				i += 3
+				continue
+			}
+		}
+		builder.WriteByte(path[i])
+	}
+	return builder.String()
+}
@@ -0,0 +1,70 @@
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestUnescapeMountPath(t *testing.T) {
+	cases := map[string]string{
+		"/tmp/judge":         "/tmp/judge",
+		`/tmp/judge\040temp`: "/tmp/judge temp",
+		`/tmp/a\011b\134c`:   "/tmp/a\tb\\c",
+		`/tmp/trailing\04`:   `/tmp/trailing\04`,
+		`/tmp/not\08octal`:   `/tmp/not\08octal`,
+	}
+	for escaped, expected := range cases {
+		if got := unescapeMountPath(escaped); got != expected {
+			t.Errorf("unescapeMountPath(%q) = %q, expected %q", escaped, got, expected)
+		}
+	}
+}
+
+// 资源池在tmpfs挂载之前启动的init也能看到工作目录，写满后返回ENOSPC
+func TestWorkDirTmpfsQuota(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("mounting tmpfs requires root")
+	}
+
+	root := t.TempDir()
+	if err := PrepareWorkDirRoot(root); err != nil {
+		t.Fatalf("prepare work dir root: %v", err)
+	}
+	t.Cleanup(func() { syscall.Unmount(root, syscall.MNT_DETACH) })
+
+	pool := NewSandboxPool(SandboxPoolConfig{Name: "workdir-test", Zygotes: 1})
+	defer pool.Close()
+	waitForPooledInit(t, pool)
+
+	workDir := filepath.Join(root, "judge_1")
+	if err := os.Mkdir(workDir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := MountWorkDir(workDir, WorkDirQuota{SizeBytes: 1 << 20, Inodes: 64, UID: 65534, GID: 65534}); err != nil {
+		t.Fatal(err)
+	}
+	defer UnmountWorkDir(workDir)
+
+	// 标准输出文件也在该tmpfs中，输出前先删除写满的文件
+	result, output := runShellInSandboxWith(t, `if head -c 2097152 /dev/zero > big 2>/dev/null; then r=ok; else r=full; fi; s=$(wc -c < big); rm big; echo $r $s`, func(config *SandboxConfig) {
+		config.Pool = pool
+		config.WorkDir = workDir
+		config.FileSizeLimit = 4096
+		config.OutputFile = filepath.Join(workDir, "output.txt")
+		config.ErrorFile = filepath.Join(workDir, "error.txt")
+	})
+
+	if stats := pool.Stats(); stats.ZygoteHits != 1 {
+		t.Fatalf("expected the run to use pooled init, got %+v", stats)
+	}
+	if result.ExitCode != 0 {
+		t.Fatalf("unexpected exit code %d, stderr %q", result.ExitCode, result.ErrorOutput)
+	}
+	lines := strings.Fields(output)
+	if len(lines) != 2 || lines[0] != "full" || lines[1] == "2097152" {
+		t.Fatalf("expected ENOSPC and a truncated file, got output %q", output)
+	}
+}
@@ -0,0 +1,235 @@
+package scheduler
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/config"
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/sandbox"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// 按资源需求准入
+// 节点用于判题的CPU核心与内存作为预算，每个任务分发前按其内存限制、语言倍数与运行时线程模型预留资源，
+// 预留成功才交给工作器，任务结束后归还；MaxWorkers只限制并发数，不再决定节点能同时运行多少个大任务
+// 预留不足时队首任务等待，而不是跳过它选择更小的任务，避免大内存任务被持续到达的小任务饿死
+// 开启DedicatedCores时预留的核心分配给任务独占，执行阶段绑定（SandboxConfig.CPUSetCores）
+// 时间限制乘以语言时间倍数与测试点数是任务占用预留的最长时间，达到LongRunMs的长任务合计最多占用LongRunCoreRatio比例的核心，
+// 长任务不会占满所有核心，短任务始终有核心可用
+
+// Reservation 任务预留的资源
+type Reservation struct {
+	Cores    int   // 预留的核心数
+	CPUs     []int // 独占的核心编号，未开启DedicatedCores时为空
+	MemoryMB int64
+	LongRun  bool // 是否计入长任务的核心上限
+}
+
+// ResourceBudget 节点的资源预算
+type ResourceBudget struct {
+	conf            config.AdmissionConf
+	compilers       map[string]config.CompilerConf
+	defaultMemoryMB int
+	defaultTimeMs   int
+	longRunCores    int // 长任务合计可以占用的核心数
+
+	mutex        sync.Mutex
+	cpus         []int        // 可分配的核心
+	busy         map[int]bool // 已分配给任务的核心
+	usedCores    int
+	usedLongRun  int // 长任务占用的核心数
+	memoryMB     int64
+	usedMemoryMB int64
+}
+
+func NewResourceBudget(conf config.AdmissionConf, judgeConf *config.JudgeEngineConf) (*ResourceBudget, error) {
+	var cpus []int
+	var err error
+	if conf.CPUSet != "" {
+		cpus, err = sandbox.ParseCPUList(conf.CPUSet)
+	} else {
+		cpus, err = sandbox.AvailableCPUs()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(cpus) == 0 {
+		return nil, fmt.Errorf("no cpus available for judging")
+	}
+
+	memoryMB := int64(conf.MemoryMB)
+	if memoryMB <= 0 {
+		total, err := readMemTotalMB()
+		if err != nil {
+			return nil, err
+		}
+		memoryMB = total - int64(conf.ReservedMemoryMB)
+	}
+	if memoryMB <= 0 {
+		return nil, fmt.Errorf("no memory left for judging after reserving %dMB", conf.ReservedMemoryMB)
+	}
+
+	// 至少允许一个核心运行长任务
+	longRunCores := len(cpus)
+	if conf.LongRunCoreRatio > 0 && conf.LongRunCoreRatio < 1 {
+		longRunCores = int(float64(len(cpus)) * conf.LongRunCoreRatio)
+		if longRunCores < 1 {
+			longRunCores = 1
+		}
+	}
+
+	logx.Infof("Resource budget: cpus=%s, memory=%dMB, long run cores=%d", sandbox.FormatCPUList(cpus), memoryMB, longRunCores)
+	return &ResourceBudget{
+		conf:            conf,
+		compilers:       judgeConf.Compilers,
+		defaultMemoryMB: judgeConf.ResourceLimits.DefaultMemoryLimit,
+		defaultTimeMs:   judgeConf.ResourceLimits.DefaultTimeLimit,
+		longRunCores:    longRunCores,
+		cpus:            cpus,
+		busy:            make(map[int]bool),
+		memoryMB:        memoryMB,
+	}, nil
+}
+
+// Demand 任务需要预留的核心数与内存(MB)
+// 内存为内存限制乘以语言倍数，加上编译器、运行时与工作目录的开销；
+// 测试点依次执行，一个核心即可，多线程运行时（MaxProcesses>1，如JVM、Go、Node）的GC与JIT线程另需核心
+// 超过整个预算的需求按整个预算计，节点空闲时仍能执行
+func (b *ResourceBudget) Demand(task *JudgeTask) (int, int64) {
+	compiler := b.compilers[task.Language]
+
+	memoryLimit := task.MemoryLimit
+	if memoryLimit <= 0 {
+		memoryLimit = b.defaultMemoryMB
+	}
+	multiplier := compiler.MemoryMultiplier
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+	memoryMB := int64(math.Ceil(float64(memoryLimit)*multiplier)) + int64(b.conf.TaskOverheadMB)
+
+	cores := 1
+	if compiler.MaxProcesses > 1 && b.conf.ThreadedCores > 1 {
+		cores = b.conf.ThreadedCores
+	}
+
+	if cores > len(b.cpus) {
+		cores = len(b.cpus)
+	}
+	if memoryMB > b.memoryMB {
+		memoryMB = b.memoryMB
+	}
+	return cores, memoryMB
+}
+
+// RunTimeMs 任务最长占用预留的时间(毫秒)：时间限制乘以语言时间倍数，再乘以依次执行的测试点数
+func (b *ResourceBudget) RunTimeMs(task *JudgeTask) int64 {
+	timeLimit := task.TimeLimit
+	if timeLimit <= 0 {
+		timeLimit = b.defaultTimeMs
+	}
+	multiplier := b.compilers[task.Language].TimeMultiplier
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+	testCases := task.TestCaseCount
+	if testCases < 1 {
+		testCases = 1
+	}
+	return int64(math.Ceil(float64(timeLimit)*multiplier)) * int64(testCases)
+}
+
+// 是否为长任务
+func (b *ResourceBudget) isLongRun(task *JudgeTask) bool {
+	return b.conf.LongRunMs > 0 && b.RunTimeMs(task) >= int64(b.conf.LongRunMs)
+}
+
+// TryReserve 预算足够时为任务预留资源
+// 长任务还需要长任务的核心上限有余量；没有长任务运行时总能预留，需求超过上限的长任务仍能执行
+func (b *ResourceBudget) TryReserve(task *JudgeTask) (*Reservation, bool) {
+	cores, memoryMB := b.Demand(task)
+	longRun := b.isLongRun(task)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.usedCores+cores > len(b.cpus) || b.usedMemoryMB+memoryMB > b.memoryMB {
+		return nil, false
+	}
+	if longRun && b.usedLongRun > 0 && b.usedLongRun+cores > b.longRunCores {
+		return nil, false
+	}
+
+	reservation := &Reservation{Cores: cores, MemoryMB: memoryMB, LongRun: longRun}
+	if b.conf.DedicatedCores {
+		for _, cpu := range b.cpus {
+			if len(reservation.CPUs) == cores {
+				break
+			}
+			if !b.busy[cpu] {
+				b.busy[cpu] = true
+				reservation.CPUs = append(reservation.CPUs, cpu)
+			}
+		}
+	}
+	b.usedCores += cores
+	b.usedMemoryMB += memoryMB
+	if longRun {
+		b.usedLongRun += cores
+	}
+	return reservation, true
+}
+
+// Release 归还任务预留的资源
+func (b *ResourceBudget) Release(reservation *Reservation) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, cpu := range reservation.CPUs {
+		delete(b.busy, cpu)
+	}
+	b.usedCores -= reservation.Cores
+	b.usedMemoryMB -= reservation.MemoryMB
+	if reservation.LongRun {
+		b.usedLongRun -= reservation.Cores
+	}
+}
+
+// Usage 已预留与总的核心数、内存(MB)
+func (b *ResourceBudget) Usage() (int, int, int64, int64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.usedCores, len(b.cpus), b.usedMemoryMB, b.memoryMB
+}
+
+// 物理内存总量(MB)
+func readMemTotalMB() (int64, error) {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read /proc/meminfo: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// MemTotal:       16315888 kB
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kb, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid MemTotal: %w", err)
+			}
+			return kb / 1024, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read /proc/meminfo: %w", err)
+	}
+	return 0, fmt.Errorf("no MemTotal in /proc/meminfo")
+}
@@ -0,0 +1,150 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/config"
+)
+
+func newTestBudget(t *testing.T) *ResourceBudget {
+	budget, err := NewResourceBudget(config.AdmissionConf{
+		CPUSet:         "0-3",
+		MemoryMB:       4096,
+		TaskOverheadMB: 128,
+		ThreadedCores:  2,
+		DedicatedCores: true,
+	}, &config.JudgeEngineConf{
+		ResourceLimits: config.ResourceLimitsConf{DefaultMemoryLimit: 256},
+		Compilers: map[string]config.CompilerConf{
+			"c":    {MemoryMultiplier: 1.0, MaxProcesses: 1},
+			"java": {MemoryMultiplier: 2.0, MaxProcesses: 64},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create budget: %v", err)
+	}
+	return budget
+}
+
+func TestResourceBudgetMemory(t *testing.T) {
+	budget := newTestBudget(t)
+
+	// 1GB的Java任务按两倍内存与两个核心预留，第二个超出内存预算
+	java := &JudgeTask{Language: "java", MemoryLimit: 1024}
+	if cores, memoryMB := budget.Demand(java); cores != 2 || memoryMB != 2176 {
+		t.Fatalf("unexpected java demand: %d cores, %dMB", cores, memoryMB)
+	}
+	first, ok := budget.TryReserve(java)
+	if !ok {
+		t.Fatal("expected the first java task to fit")
+	}
+	if _, ok := budget.TryReserve(java); ok {
+		t.Fatal("expected the second java task to exceed the memory budget")
+	}
+
+	// 小内存的C任务仍可使用剩余的核心
+	small := &JudgeTask{Language: "c", MemoryLimit: 128}
+	second, ok := budget.TryReserve(small)
+	if !ok {
+		t.Fatal("expected a small c task to fit")
+	}
+	third, ok := budget.TryReserve(small)
+	if !ok {
+		t.Fatal("expected another small c task to fit")
+	}
+	if _, ok := budget.TryReserve(small); ok {
+		t.Fatal("expected the cpu budget to be exhausted")
+	}
+
+	// 独占的核心互不重叠
+	seen := make(map[int]bool)
+	for _, reservation := range []*Reservation{first, second, third} {
+		for _, cpu := range reservation.CPUs {
+			if seen[cpu] {
+				t.Fatalf("cpu %d assigned twice", cpu)
+			}
+			seen[cpu] = true
+		}
+	}
+	if len(seen) != 4 {
+		t.Fatalf("expected 4 dedicated cpus, got %v", seen)
+	}
+
+	budget.Release(first)
+	if _, ok := budget.TryReserve(java); !ok {
+		t.Fatal("expected the java task to fit after release")
+	}
+}
+
+func TestResourceBudgetLongRuns(t *testing.T) {
+	budget, err := NewResourceBudget(config.AdmissionConf{
+		CPUSet:           "0-3",
+		MemoryMB:         4096,
+		ThreadedCores:    2,
+		DedicatedCores:   true,
+		LongRunMs:        10000,
+		LongRunCoreRatio: 0.5,
+	}, &config.JudgeEngineConf{
+		ResourceLimits: config.ResourceLimitsConf{DefaultTimeLimit: 1000, DefaultMemoryLimit: 64},
+		Compilers: map[string]config.CompilerConf{
+			"c":      {TimeMultiplier: 1.0, MaxProcesses: 1},
+			"python": {TimeMultiplier: 2.5, MaxProcesses: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create budget: %v", err)
+	}
+
+	// 运行时间为时间限制×语言时间倍数×测试点数
+	short := &JudgeTask{Language: "c", TestCaseCount: 5}
+	long := &JudgeTask{Language: "python", TimeLimit: 2000, TestCaseCount: 2}
+	if runTime := budget.RunTimeMs(short); runTime != 5000 {
+		t.Fatalf("unexpected short run time: %dms", runTime)
+	}
+	if runTime := budget.RunTimeMs(long); runTime != 10000 {
+		t.Fatalf("unexpected long run time: %dms", runTime)
+	}
+
+	// 长任务最多占用一半核心
+	var reservations []*Reservation
+	for i := 0; i < 2; i++ {
+		reservation, ok := budget.TryReserve(long)
+		if !ok || !reservation.LongRun {
+			t.Fatalf("expected long run %d to fit", i)
+		}
+		reservations = append(reservations, reservation)
+	}
+	if _, ok := budget.TryReserve(long); ok {
+		t.Fatal("expected long runs to be limited to half of the cores")
+	}
+
+	// 其余核心留给短任务
+	for i := 0; i < 2; i++ {
+		if _, ok := budget.TryReserve(short); !ok {
+			t.Fatalf("expected short task %d to fit", i)
+		}
+	}
+
+	budget.Release(reservations[0])
+	if _, ok := budget.TryReserve(long); !ok {
+		t.Fatal("expected a long run to fit after release")
+	}
+}
+
+func TestResourceBudgetClampsOversizedTasks(t *testing.T) {
+	budget := newTestBudget(t)
+
+	// 超过整个预算的任务在节点空闲时仍能执行
+	huge := &JudgeTask{Language: "java", MemoryLimit: 8192}
+	if _, memoryMB := budget.Demand(huge); memoryMB != 4096 {
+		t.Fatalf("expected the demand to be clamped, got %dMB", memoryMB)
+	}
+	reservation, ok := budget.TryReserve(huge)
+	if !ok {
+		t.Fatal("expected the oversized task to fit on an idle node")
+	}
+	budget.Release(reservation)
+	if usedCores, _, usedMemoryMB, _ := budget.Usage(); usedCores != 0 || usedMemoryMB != 0 {
+		t.Fatalf("expected an empty budget after release, got %d cores, %dMB", usedCores, usedMemoryMB)
+	}
+}
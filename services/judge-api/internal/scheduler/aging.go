@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"math"
+	"time"
+
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/config"
+)
+
+// 优先级老化
+// 有效优先级从任务的优先级开始，随等待时间按老化曲线向最高优先级提升，等待MaxWait后与最高优先级相同；
+// 有效优先级相同时先创建的任务优先，因此等待满MaxWait的低优先级任务排在新到的比赛任务之前
+// 同一优先级内仍按公平排队的顺序：出队时比较各优先级队首任务的有效优先级
+
+type priorityAging struct {
+	conf config.PriorityAgingConf
+}
+
+// 任务在now时的有效优先级（数值越小越优先）
+func (a *priorityAging) effective(task *JudgeTask, now time.Time) float64 {
+	priority := float64(task.Priority)
+	if !a.conf.Enabled || a.conf.MaxWait <= 0 || task.Priority <= PriorityHigh {
+		return priority
+	}
+
+	progress := now.Sub(task.CreatedAt).Seconds() / float64(a.conf.MaxWait)
+	if progress <= 0 {
+		return priority
+	}
+	if progress > 1 {
+		progress = 1
+	}
+
+	levels := float64(task.Priority - PriorityHigh)
+	switch a.conf.Curve {
+	case "quadratic":
+		// 先慢后快：短时间的等待几乎不影响顺序
+		progress = progress * progress
+	case "step":
+		// 每等待MaxWait/级差秒提升一级
+		progress = math.Floor(progress*levels) / levels
+	}
+	return priority - levels*progress
+}
+
+// x是否排在y之前（x、y为不同优先级的队首任务）
+func (a *priorityAging) before(x, y *JudgeTask, now time.Time) bool {
+	ex, ey := a.effective(x, now), a.effective(y, now)
+	if ex != ey {
+		return ex < ey
+	}
+	return x.CreatedAt.Before(y.CreatedAt)
+}
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/config"
+)
+
+func TestPriorityAgingCurves(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		curve  string
+		waited time.Duration
+		want   float64
+	}{
+		{"linear", 0, 3},
+		{"linear", 50 * time.Second, 2},
+		{"linear", 200 * time.Second, 1},
+		{"quadratic", 50 * time.Second, 2.5},
+		{"step", 40 * time.Second, 3},
+		{"step", 50 * time.Second, 2},
+		{"step", 100 * time.Second, 1},
+	}
+	for _, c := range cases {
+		aging := &priorityAging{conf: config.PriorityAgingConf{Enabled: true, MaxWait: 100, Curve: c.curve}}
+		task := &JudgeTask{Priority: PriorityLow, CreatedAt: now.Add(-c.waited)}
+		if got := aging.effective(task, now); got != c.want {
+			t.Errorf("%s after %v: expected %v, got %v", c.curve, c.waited, c.want, got)
+		}
+	}
+
+	// 最高优先级不再提升，关闭时保持原优先级
+	aging := &priorityAging{conf: config.PriorityAgingConf{Enabled: true, MaxWait: 100}}
+	if got := aging.effective(&JudgeTask{Priority: PriorityHigh, CreatedAt: now.Add(-time.Hour)}, now); got != PriorityHigh {
+		t.Errorf("expected the high priority to stay, got %v", got)
+	}
+	aging.conf.Enabled = false
+	if got := aging.effective(&JudgeTask{Priority: PriorityLow, CreatedAt: now.Add(-time.Hour)}, now); got != PriorityLow {
+		t.Errorf("expected no aging when disabled, got %v", got)
+	}
+}
+
+func TestPriorityAgingPreventsStarvation(t *testing.T) {
+	pq := NewPriorityQueue(config.FairQueueConf{}, config.PriorityAgingConf{Enabled: true, MaxWait: 60, Curve: "linear"})
+	now := time.Now()
+
+	pq.Push(&JudgeTask{ID: "practice_old", UserID: 1, Priority: PriorityLow, CreatedAt: now.Add(-2 * time.Minute)})
+	pq.Push(&JudgeTask{ID: "practice_new", UserID: 2, Priority: PriorityLow, CreatedAt: now.Add(-10 * time.Second)})
+	pq.Push(&JudgeTask{ID: "vip", UserID: 3, Priority: PriorityNormal, CreatedAt: now.Add(-20 * time.Second)})
+	for i := 0; i < 3; i++ {
+		pq.Push(&JudgeTask{ID: "contest", UserID: int64(10 + i), Priority: PriorityHigh, CreatedAt: now.Add(-time.Second)})
+	}
+
+	// 等待超过MaxWait的任务排在所有新到的比赛任务之前，其余低优先级任务仍在比赛任务之后
+	var order []string
+	for _, task := range pq.Ordered(now) {
+		order = append(order, task.ID)
+	}
+	want := []string{"practice_old", "contest", "contest", "contest", "vip", "practice_new"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("unexpected order: got %v, want %v", order, want)
+		}
+	}
+
+	if task := pq.Pop(); task.ID != "practice_old" {
+		t.Fatalf("expected the aged task to be dispatched first, got %s", task.ID)
+	}
+	if pq.Len() != 5 {
+		t.Fatalf("expected 5 tasks left, got %d", pq.Len())
+	}
+}
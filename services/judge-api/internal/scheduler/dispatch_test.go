@@ -0,0 +1,139 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/config"
+)
+
+// 模拟工作器：取任务后立即结束，只测量分发本身
+func drain(s *TaskScheduler, workers int, done func(*JudgeTask)) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := s.next(); task != nil; task = s.next() {
+				s.finish(task)
+				done(task)
+			}
+		}()
+	}
+	return &wg
+}
+
+func TestNextWaitsForReservation(t *testing.T) {
+	budget := newTestBudget(t)
+	s := NewTaskScheduler(&config.TaskQueueConf{TaskTimeout: 60}, nil, NewMemoryTaskStore(time.Hour), "node-a", budget)
+	defer s.cancel()
+
+	java := func(id string) *JudgeTask {
+		return &JudgeTask{ID: id, Language: "java", MemoryLimit: 1024, Priority: PriorityLow}
+	}
+	for _, task := range []*JudgeTask{java("first"), java("second")} {
+		if err := s.SubmitTask(task); err != nil {
+			t.Fatalf("submit failed: %v", err)
+		}
+	}
+
+	first := s.next()
+	if first == nil || first.ID != "first" || first.reservation == nil {
+		t.Fatalf("unexpected first task: %+v", first)
+	}
+
+	// 第二个任务超出内存预算，工作器等待而不是把任务反复放回队列
+	got := make(chan *JudgeTask, 1)
+	go func() { got <- s.next() }()
+	select {
+	case task := <-got:
+		t.Fatalf("expected the worker to wait for the budget, got %s", task.ID)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if s.priorityQueue.Len() != 1 {
+		t.Fatalf("expected the second task to stay queued, got %d tasks", s.priorityQueue.Len())
+	}
+
+	// 第一个任务结束归还资源后立即分发
+	s.finish(first)
+	select {
+	case task := <-got:
+		if task == nil || task.ID != "second" {
+			t.Fatalf("unexpected second task: %+v", task)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the second task after the release")
+	}
+
+	// 调度器停止时等待中的工作器返回
+	go func() { got <- s.next() }()
+	s.cancel()
+	s.priorityQueue.Wake()
+	select {
+	case task := <-got:
+		if task != nil {
+			t.Fatalf("expected no task after stop, got %s", task.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the waiting worker to return on stop")
+	}
+}
+
+func TestNextSkipsCancelledTasks(t *testing.T) {
+	s := newTestScheduler(config.FairQueueConf{})
+	defer s.cancel()
+
+	for _, id := range []string{"cancelled", "pending"} {
+		if err := s.SubmitTask(&JudgeTask{ID: id, Priority: PriorityLow}); err != nil {
+			t.Fatalf("submit failed: %v", err)
+		}
+	}
+	if err := s.CancelTask("cancelled"); err != nil {
+		t.Fatalf("cancel failed: %v", err)
+	}
+
+	if task := s.next(); task == nil || task.ID != "pending" {
+		t.Fatalf("expected the pending task, got %+v", task)
+	}
+	if s.priorityQueue.Len() != 0 {
+		t.Fatalf("expected an empty queue, got %d tasks", s.priorityQueue.Len())
+	}
+}
+
+// 分发吞吐：8个工作器从队列取任务，有无资源准入两种情况
+func BenchmarkDispatch(b *testing.B) {
+	for _, admission := range []bool{false, true} {
+		b.Run(fmt.Sprintf("admission=%v", admission), func(b *testing.B) {
+			var budget *ResourceBudget
+			if admission {
+				var err error
+				budget, err = NewResourceBudget(config.AdmissionConf{CPUSet: "0-7", MemoryMB: 16384, TaskOverheadMB: 128}, &config.JudgeEngineConf{
+					ResourceLimits: config.ResourceLimitsConf{DefaultMemoryLimit: 256},
+				})
+				if err != nil {
+					b.Fatalf("failed to create budget: %v", err)
+				}
+			}
+			s := NewTaskScheduler(&config.TaskQueueConf{TaskTimeout: 60, FairQueue: testFairQueueConf}, nil, NewMemoryTaskStore(time.Hour), "node-a", budget)
+			defer s.cancel()
+
+			var finished sync.WaitGroup
+			finished.Add(b.N)
+			workers := drain(s, 8, func(*JudgeTask) { finished.Done() })
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				s.priorityQueue.Push(&JudgeTask{ID: fmt.Sprintf("task_%d", i), UserID: int64(i % 16), Priority: PriorityLow + i%3 - 2, CreatedAt: time.Now()})
+			}
+			finished.Wait()
+			b.StopTimer()
+
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "tasks/s")
+			s.cancel()
+			s.priorityQueue.Wake()
+			workers.Wait()
+		})
+	}
+}
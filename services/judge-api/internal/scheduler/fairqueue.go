@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/config"
+)
+
+// 同一优先级内的加权公平排队
+// 每个任务属于一个流：重判批次、比赛或用户（依次取第一个存在的），各流按权重分享工作器
+// 原理（start-time fair queueing）：每个优先级维护虚拟时间V，流f记录其最后一个任务的结束标签F(f)；
+// 任务入队时开始标签S=max(V, F(f))，结束标签F=S+1/权重；队列按结束标签排序，出队时V推进到该任务的开始标签
+// 单个用户连续提交时结束标签依次递增，其它用户的新任务插入到它们之间，而不是排在整批之后
+
+// 清理流记录的间隔（出队次数）
+const fairFlowPruneInterval = 1024
+
+// 每个优先级的公平排队状态
+type fairLevel struct {
+	virtualTime float64
+	finish      map[string]float64 // 流 -> 最后一个任务的结束标签
+}
+
+// 加权公平排队的标签计算
+type fairQueue struct {
+	conf   config.FairQueueConf
+	levels map[int]*fairLevel
+	pops   int
+}
+
+func newFairQueue(conf config.FairQueueConf) *fairQueue {
+	return &fairQueue{
+		conf:   conf,
+		levels: make(map[int]*fairLevel),
+	}
+}
+
+// 任务所属的流
+func flowKey(task *JudgeTask) string {
+	switch {
+	case task.BatchID != "":
+		return task.BatchID
+	case task.ContestID > 0:
+		return fmt.Sprintf("contest:%d", task.ContestID)
+	default:
+		return fmt.Sprintf("user:%d", task.UserID)
+	}
+}
+
+// 流的权重：Weights中的指定值优先，否则按流的类型
+func (f *fairQueue) weight(task *JudgeTask, flow string) float64 {
+	if weight, ok := f.conf.Weights[flow]; ok && weight > 0 {
+		return weight
+	}
+	weight := f.conf.UserWeight
+	switch {
+	case task.BatchID != "":
+		weight = f.conf.RejudgeWeight
+	case task.ContestID > 0:
+		weight = f.conf.ContestWeight
+	}
+	if weight <= 0 {
+		return 1
+	}
+	return weight
+}
+
+func (f *fairQueue) level(priority int) *fairLevel {
+	level, ok := f.levels[priority]
+	if !ok {
+		level = &fairLevel{finish: make(map[string]float64)}
+		f.levels[priority] = level
+	}
+	return level
+}
+
+// 为入队的任务计算标签
+// 任务只在提交、重启恢复（标签不持久化）与重试时入队，工作器取出的任务不会放回，因此每次入队都按当时的虚拟时间重新计费
+func (f *fairQueue) tag(task *JudgeTask) {
+	level := f.level(task.Priority)
+	flow := flowKey(task)
+
+	start := level.virtualTime
+	if last := level.finish[flow]; last > start {
+		start = last
+	}
+	task.fairStart = start
+	task.fairFinish = start + 1/f.weight(task, flow)
+	level.finish[flow] = task.fairFinish
+}
+
+// 任务出队：推进所在优先级的虚拟时间，并定期清理已经落后于虚拟时间的流
+func (f *fairQueue) popped(task *JudgeTask) {
+	level := f.level(task.Priority)
+	if task.fairStart > level.virtualTime {
+		level.virtualTime = task.fairStart
+	}
+
+	f.pops++
+	if f.pops%fairFlowPruneInterval != 0 {
+		return
+	}
+	for _, level := range f.levels {
+		for flow, finish := range level.finish {
+			// 结束标签不超过虚拟时间的流与没有记录等价
+			if finish <= level.virtualTime {
+				delete(level.finish, flow)
+			}
+		}
+	}
+}
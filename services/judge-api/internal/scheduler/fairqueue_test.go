@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/config"
+)
+
+var testFairQueueConf = config.FairQueueConf{Enabled: true, UserWeight: 1, ContestWeight: 4, RejudgeWeight: 1}
+
+func newTestScheduler(fair config.FairQueueConf) *TaskScheduler {
+	return NewTaskScheduler(&config.TaskQueueConf{TaskTimeout: 60, FairQueue: fair}, nil, NewMemoryTaskStore(time.Hour), "node-a", nil)
+}
+
+// 出队顺序中每个任务所属的流
+func popFlows(pq *PriorityQueue) []string {
+	var flows []string
+	for task := pq.Pop(); task != nil; task = pq.Pop() {
+		flows = append(flows, flowKey(task))
+	}
+	return flows
+}
+
+func TestFairQueuePositionAfterBurst(t *testing.T) {
+	s := newTestScheduler(testFairQueueConf)
+	defer s.cancel()
+
+	for i := 0; i < 10; i++ {
+		if err := s.SubmitTask(&JudgeTask{ID: fmt.Sprintf("spam_%d", i), UserID: 1, Priority: PriorityLow}); err != nil {
+			t.Fatalf("submit failed: %v", err)
+		}
+	}
+	if err := s.SubmitTask(&JudgeTask{ID: "other", UserID: 2, Priority: PriorityLow}); err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+
+	// 另一个用户的任务排在刷提交用户的第一个任务之后，而不是整批之后
+	position, err := s.GetTaskPosition("other")
+	if err != nil {
+		t.Fatalf("position failed: %v", err)
+	}
+	if position != 2 {
+		t.Fatalf("expected position 2, got %d", position)
+	}
+
+	// 更高优先级的任务仍然排在最前
+	if err := s.SubmitTask(&JudgeTask{ID: "contest", UserID: 3, ContestID: 7, Priority: PriorityHigh}); err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	if position, _ := s.GetTaskPosition("contest"); position != 1 {
+		t.Fatalf("expected the high priority task first, got position %d", position)
+	}
+}
+
+func TestFairQueueWeights(t *testing.T) {
+	conf := testFairQueueConf
+	conf.Weights = map[string]float64{"user:1": 2}
+	pq := NewPriorityQueue(conf, config.PriorityAgingConf{})
+
+	for i := 0; i < 6; i++ {
+		pq.Push(&JudgeTask{UserID: 1, Priority: PriorityLow})
+		pq.Push(&JudgeTask{UserID: 2, Priority: PriorityLow})
+	}
+
+	// 权重2的流每轮出队两个任务，直到其任务用完
+	got := fmt.Sprint(popFlows(pq))
+	want := "[user:1 user:2 user:1 user:1 user:2 user:1 user:1 user:2 user:1 user:2 user:2 user:2]"
+	if got != want {
+		t.Fatalf("unexpected order:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestFairQueueRejudgeBatch(t *testing.T) {
+	pq := NewPriorityQueue(testFairQueueConf, config.PriorityAgingConf{})
+
+	// 大批重判与比赛提交同为高优先级，比赛权重更高
+	for i := 0; i < 8; i++ {
+		pq.Push(&JudgeTask{UserID: int64(i), BatchID: "rejudge:problem:1", Priority: PriorityHigh})
+	}
+	for i := 0; i < 4; i++ {
+		pq.Push(&JudgeTask{UserID: int64(100 + i), ContestID: 7, Priority: PriorityHigh})
+	}
+
+	// 比赛的4个任务在重判批次的第一个任务前后全部出队
+	flows := popFlows(pq)
+	contest := 0
+	for _, flow := range flows[:5] {
+		if flow == "contest:7" {
+			contest++
+		}
+	}
+	if contest != 4 {
+		t.Fatalf("expected the contest tasks first, got %v", flows)
+	}
+}
+
+func TestFairQueueDisabled(t *testing.T) {
+	pq := NewPriorityQueue(config.FairQueueConf{}, config.PriorityAgingConf{})
+	base := time.Now()
+	for i := 0; i < 3; i++ {
+		pq.Push(&JudgeTask{UserID: 1, Priority: PriorityLow, CreatedAt: base.Add(time.Duration(i) * time.Millisecond)})
+	}
+	pq.Push(&JudgeTask{UserID: 2, Priority: PriorityLow, CreatedAt: base.Add(time.Second)})
+
+	got := fmt.Sprint(popFlows(pq))
+	if got != "[user:1 user:1 user:1 user:2]" {
+		t.Fatalf("expected FIFO order, got %s", got)
+	}
+}
@@ -3,12 +3,14 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/dszqbsm/code-judger/services/judge-api/internal/config"
 	"github.com/dszqbsm/code-judger/services/judge-api/internal/judge"
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/sandbox"
 	"github.com/dszqbsm/code-judger/services/judge-api/internal/types"
 
 	"github.com/zeromicro/go-zero/core/logx"
@@ -32,57 +34,69 @@ const (
 
 // 判题任务
 type JudgeTask struct {
-	ID           string             `json:"id"`
-	SubmissionID int64              `json:"submission_id"`
-	ProblemID    int64              `json:"problem_id"`
-	UserID       int64              `json:"user_id"`
-	Language     string             `json:"language"`
-	Code         string             `json:"code"`
-	TimeLimit    int                `json:"time_limit"`
-	MemoryLimit  int                `json:"memory_limit"`
-	TestCases    []*types.TestCase  `json:"test_cases"`
-	Priority     int                `json:"priority"`
-	Status       string             `json:"status"`
-	CreatedAt    time.Time          `json:"created_at"`
-	StartedAt    *time.Time         `json:"started_at,omitempty"`
-	CompletedAt  *time.Time         `json:"completed_at,omitempty"`
-	Result       *types.JudgeResult `json:"result,omitempty"`
-	Error        string             `json:"error,omitempty"`
-	RetryCount   int                `json:"retry_count"`
-	Context      context.Context    `json:"-"`
-	CancelFunc   context.CancelFunc `json:"-"`
+	ID              string             `json:"id"`
+	SubmissionID    int64              `json:"submission_id"`
+	ProblemID       int64              `json:"problem_id"`
+	UserID          int64              `json:"user_id"`
+	Language        string             `json:"language"`
+	Code            string             `json:"code"`
+	TimeLimit       int                `json:"time_limit"`
+	MemoryLimit     int                `json:"memory_limit"`
+	TimeLimitMetric string             `json:"time_limit_metric,omitempty"` // cpu或wall，为空时使用全局配置
+	TestCases       []*types.TestCase  `json:"test_cases"`
+	TestCaseCount   int                `json:"test_case_count"` // 测试点数量，存储中不保存测试数据，由此计算进度
+	Priority        int                `json:"priority"`
+	Status          string             `json:"status"`
+	CreatedAt       time.Time          `json:"created_at"`
+	StartedAt       *time.Time         `json:"started_at,omitempty"`
+	CompletedAt     *time.Time         `json:"completed_at,omitempty"`
+	Result          *types.JudgeResult `json:"result,omitempty"`
+	Error           string             `json:"error,omitempty"`
+	RetryCount      int                `json:"retry_count"`
+	NodeID          string             `json:"node_id"` // 执行任务的判题节点
+	ContestID       int64              `json:"contest_id,omitempty"`
+	BatchID         string             `json:"batch_id,omitempty"` // 重判批次，同一批次的任务在公平排队中共享一个流
+	Context         context.Context    `json:"-"`
+	CancelFunc      context.CancelFunc `json:"-"`
+
+	// 公平排队的开始与结束标签（见fairqueue.go）
+	fairStart  float64
+	fairFinish float64
+
+	// 分发时预留的资源（见admission.go），任务结束后归还
+	reservation *Reservation
 }
 
 // 工作器
 type Worker struct {
-	ID       int
-	TaskChan chan *JudgeTask
-	QuitChan chan bool
-	Judge    *judge.JudgeEngine
+	ID    int
+	Judge *judge.JudgeEngine
+	Store TaskStore
 }
 
-func NewWorker(id int, judge *judge.JudgeEngine) *Worker {
+func NewWorker(id int, judge *judge.JudgeEngine, store TaskStore) *Worker {
 	return &Worker{
-		ID:       id,
-		TaskChan: make(chan *JudgeTask),
-		QuitChan: make(chan bool),
-		Judge:    judge,
+		ID:    id,
+		Judge: judge,
+		Store: store,
 	}
 }
 
-func (w *Worker) Start(wg *sync.WaitGroup) {
+// 空闲时从调度器取下一个任务，调度器停止后退出
+func (w *Worker) Start(wg *sync.WaitGroup, s *TaskScheduler) {
 	defer wg.Done()
 
 	logx.Infof("Worker %d started", w.ID)
 
 	for {
-		select {
-		case task := <-w.TaskChan:
-			w.processTask(task)
-		case <-w.QuitChan:
+		task := s.next()
+		if task == nil {
 			logx.Infof("Worker %d stopped", w.ID)
 			return
 		}
+		logx.Infof("Task %s assigned to worker %d", task.ID, w.ID)
+		w.processTask(task)
+		s.finish(task)
 	}
 }
 
@@ -93,17 +107,26 @@ func (w *Worker) processTask(task *JudgeTask) {
 	task.Status = TaskStatusRunning
 	now := time.Now()
 	task.StartedAt = &now
+	saveTask(w.Store, task)
+
+	var cpus string
+	if task.reservation != nil {
+		cpus = sandbox.FormatCPUList(task.reservation.CPUs)
+	}
 
 	// 执行判题
 	result, err := w.Judge.Judge(task.Context, &judge.JudgeRequest{
-		SubmissionID: task.SubmissionID,
-		ProblemID:    task.ProblemID,
-		UserID:       task.UserID,
-		Language:     task.Language,
-		Code:         task.Code,
-		TimeLimit:    task.TimeLimit,
-		MemoryLimit:  task.MemoryLimit,
-		TestCases:    task.TestCases,
+		SubmissionID:    task.SubmissionID,
+		ProblemID:       task.ProblemID,
+		UserID:          task.UserID,
+		Language:        task.Language,
+		Code:            task.Code,
+		TimeLimit:       task.TimeLimit,
+		MemoryLimit:     task.MemoryLimit,
+		TimeLimitMetric: task.TimeLimitMetric,
+		TestCases:       task.TestCases,
+		WorkerID:        w.ID,
+		CPUSetCores:     cpus,
 	})
 
 	// 更新任务结果
@@ -119,17 +142,13 @@ func (w *Worker) processTask(task *JudgeTask) {
 		task.Result = result
 		logx.Infof("Worker %d task %s completed successfully", w.ID, task.ID)
 	}
-}
-
-func (w *Worker) Stop() {
-	w.QuitChan <- true
+	saveTask(w.Store, task)
 }
 
 // 任务调度器
 type TaskScheduler struct {
 	config        *config.TaskQueueConf
 	workers       []*Worker
-	taskQueue     chan *JudgeTask
 	priorityQueue *PriorityQueue
 	tasks         sync.Map // map[string]*JudgeTask
 	stats         *SchedulerStats
@@ -137,8 +156,17 @@ type TaskScheduler struct {
 	ctx           context.Context
 	cancel        context.CancelFunc
 	judge         *judge.JudgeEngine
+	store         TaskStore
+	budget        *ResourceBudget // 为nil时不做资源准入
+	nodeID        string
+	recovered     []*JudgeTask   // 启动时从存储中恢复的任务
+	loader        TestCaseLoader // 恢复任务时重新获取测试数据，为nil时无法恢复
 }
 
+// TestCaseLoader 按题目ID获取测试数据
+// 任务存储只保存调度与状态字段，恢复的任务需要重新获取测试数据才能执行
+type TestCaseLoader func(ctx context.Context, problemID int64) ([]*types.TestCase, error)
+
 // 调度器统计信息
 type SchedulerStats struct {
 	TotalTasks     int64 `json:"total_tasks"`
@@ -149,23 +177,25 @@ type SchedulerStats struct {
 	CancelledTasks int64 `json:"cancelled_tasks"`
 }
 
-func NewTaskScheduler(config *config.TaskQueueConf, judge *judge.JudgeEngine) *TaskScheduler {
+func NewTaskScheduler(config *config.TaskQueueConf, judge *judge.JudgeEngine, store TaskStore, nodeID string, budget *ResourceBudget) *TaskScheduler {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	scheduler := &TaskScheduler{
 		config:        config,
 		workers:       make([]*Worker, config.MaxWorkers),
-		taskQueue:     make(chan *JudgeTask, config.QueueSize),
-		priorityQueue: NewPriorityQueue(),
+		priorityQueue: NewPriorityQueue(config.FairQueue, config.PriorityAging),
 		stats:         &SchedulerStats{},
 		ctx:           ctx,
 		cancel:        cancel,
 		judge:         judge,
+		store:         store,
+		budget:        budget,
+		nodeID:        nodeID,
 	}
 
 	// 创建工作器
 	for i := 0; i < config.MaxWorkers; i++ {
-		scheduler.workers[i] = NewWorker(i, judge)
+		scheduler.workers[i] = NewWorker(i, judge, store)
 	}
 
 	return scheduler
@@ -174,18 +204,18 @@ func NewTaskScheduler(config *config.TaskQueueConf, judge *judge.JudgeEngine) *T
 func (s *TaskScheduler) Start() error {
 	logx.Info("Starting task scheduler...")
 
-	// 启动工作器
+	// 恢复上次运行时未完成的任务，在分发器启动前重新排队
+	s.recoverTasks()
+
+	// 停止时唤醒等待任务的工作器
+	context.AfterFunc(s.ctx, s.priorityQueue.Wake)
+
+	// 启动工作器，空闲的工作器直接从优先级队列取任务
 	for _, worker := range s.workers {
 		s.wg.Add(1)
-		go worker.Start(&s.wg)
+		go worker.Start(&s.wg, s)
 	}
 
-	// 启动任务分发器
-	go s.dispatch()
-
-	// 启动任务分配器（从taskQueue分发给workers）
-	go s.distributeToWorkers()
-
 	// 启动统计更新器
 	go s.updateStats()
 
@@ -196,17 +226,15 @@ func (s *TaskScheduler) Start() error {
 func (s *TaskScheduler) Stop() error {
 	logx.Info("Stopping task scheduler...")
 
-	// 取消上下文
+	// 取消上下文，工作器完成当前任务后退出
 	s.cancel()
 
-	// 停止工作器
-	for _, worker := range s.workers {
-		worker.Stop()
-	}
-
 	// 等待所有工作器停止
 	s.wg.Wait()
 
+	// 工作器已全部停止，释放其沙箱资源池
+	s.judge.Close()
+
 	logx.Info("Task scheduler stopped")
 	return nil
 }
@@ -218,13 +246,23 @@ func (s *TaskScheduler) SubmitTask(task *JudgeTask) error {
 		task.ID = fmt.Sprintf("task_%d_%d", task.SubmissionID, time.Now().UnixNano())
 	}
 
-	// 设置任务上下文
-	task.Context, task.CancelFunc = context.WithTimeout(s.ctx, time.Duration(s.config.TaskTimeout)*time.Second)
 	task.Status = TaskStatusPending
 	task.CreatedAt = time.Now()
+	task.TestCaseCount = len(task.TestCases)
+	s.enqueue(task)
+
+	logx.Infof("Task submitted: %s (priority: %d)", task.ID, task.Priority)
+	return nil
+}
+
+// 设置任务上下文，保存并加入优先级队列
+func (s *TaskScheduler) enqueue(task *JudgeTask) {
+	task.Context, task.CancelFunc = context.WithTimeout(s.ctx, time.Duration(s.config.TaskTimeout)*time.Second)
+	task.NodeID = s.nodeID
 
 	// 存储任务
 	s.tasks.Store(task.ID, task)
+	saveTask(s.store, task)
 
 	// 加入优先级队列
 	s.priorityQueue.Push(task)
@@ -232,9 +270,95 @@ func (s *TaskScheduler) SubmitTask(task *JudgeTask) error {
 	// 更新统计
 	atomic.AddInt64(&s.stats.TotalTasks, 1)
 	atomic.AddInt64(&s.stats.PendingTasks, 1)
+}
 
-	logx.Infof("Task submitted: %s (priority: %d)", task.ID, task.Priority)
-	return nil
+// 恢复本节点上次运行时等待中与执行中的任务
+// 执行中的任务被重启打断，计入重试次数；超过上限的任务可能正是导致重启的原因，直接判为失败
+// 存储中没有测试数据，重新排队前按题目ID重新获取，获取失败的任务判为失败
+func (s *TaskScheduler) recoverTasks() {
+	tasks, err := s.store.ListUnfinished(s.nodeID)
+	if err != nil {
+		logx.Errorf("Failed to recover unfinished tasks: %v", err)
+		return
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+	})
+
+	// 同一题目的任务共用一次获取的测试数据
+	testCases := make(map[int64][]*types.TestCase)
+	for _, task := range tasks {
+		if task.Status == TaskStatusRunning {
+			task.RetryCount++
+			if task.RetryCount > s.config.RetryTimes {
+				s.failRecoveredTask(task, "judge service restarted while the task was running")
+				logx.Errorf("Task %s exceeded max retry count after restart", task.ID)
+				continue
+			}
+		}
+
+		cases, ok := testCases[task.ProblemID]
+		if !ok {
+			var err error
+			if cases, err = s.loadTestCases(task.ProblemID); err != nil {
+				s.failRecoveredTask(task, err.Error())
+				logx.Errorf("Failed to reload test cases of task %s: %v", task.ID, err)
+				continue
+			}
+			testCases[task.ProblemID] = cases
+		}
+		task.TestCases = cases
+		task.TestCaseCount = len(cases)
+
+		task.Status = TaskStatusPending
+		task.StartedAt = nil
+		task.Result = nil
+		s.enqueue(task)
+		s.recovered = append(s.recovered, task)
+	}
+
+	if len(tasks) > 0 {
+		logx.Infof("Recovered %d unfinished tasks", len(tasks))
+	}
+}
+
+// SetTestCaseLoader 设置恢复任务时获取测试数据的方式，需要在Start之前调用
+func (s *TaskScheduler) SetTestCaseLoader(loader TestCaseLoader) {
+	s.loader = loader
+}
+
+// 获取恢复任务的测试数据
+func (s *TaskScheduler) loadTestCases(problemID int64) ([]*types.TestCase, error) {
+	if s.loader == nil {
+		return nil, fmt.Errorf("no test case loader to recover tasks of problem %d", problemID)
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 30*time.Second)
+	defer cancel()
+	testCases, err := s.loader(ctx, problemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load test cases of problem %d: %w", problemID, err)
+	}
+	if len(testCases) == 0 {
+		return nil, fmt.Errorf("problem %d has no test cases", problemID)
+	}
+	return testCases, nil
+}
+
+// 无法继续执行的恢复任务直接失败，状态写回存储
+func (s *TaskScheduler) failRecoveredTask(task *JudgeTask, reason string) {
+	task.Status = TaskStatusFailed
+	task.Error = reason
+	completedAt := time.Now()
+	task.CompletedAt = &completedAt
+	s.tasks.Store(task.ID, task)
+	saveTask(s.store, task)
+	atomic.AddInt64(&s.stats.FailedTasks, 1)
+	s.recovered = append(s.recovered, task)
+}
+
+// RecoveredTasks 启动时恢复的任务，调用方需要继续跟踪它们的结果
+func (s *TaskScheduler) RecoveredTasks() []*JudgeTask {
+	return s.recovered
 }
 
 // 取消任务
@@ -255,6 +379,7 @@ func (s *TaskScheduler) CancelTask(taskID string) error {
 	task.Status = TaskStatusCancelled
 	completedAt := time.Now()
 	task.CompletedAt = &completedAt
+	saveTask(s.store, task)
 
 	// 更新统计
 	if task.StartedAt == nil {
@@ -268,11 +393,15 @@ func (s *TaskScheduler) CancelTask(taskID string) error {
 	return nil
 }
 
-// 获取任务状态
+// 获取任务状态，本节点没有时从任务存储中读取（其它节点或重启前的任务）
 func (s *TaskScheduler) GetTaskStatus(taskID string) (*JudgeTask, error) {
 	taskInterface, exists := s.tasks.Load(taskID)
 	if !exists {
-		return nil, fmt.Errorf("task not found: %s", taskID)
+		task, err := s.store.Load(taskID)
+		if err != nil {
+			return nil, fmt.Errorf("task not found: %s", taskID)
+		}
+		return task, nil
 	}
 
 	task := taskInterface.(*JudgeTask)
@@ -283,22 +412,23 @@ func (s *TaskScheduler) GetTaskStatus(taskID string) (*JudgeTask, error) {
 func (s *TaskScheduler) GetQueueStatus() *QueueStatus {
 	queueItems := make([]*QueueItem, 0)
 
-	// 获取优先级队列中的任务
-	s.priorityQueue.mutex.Lock()
-	for _, task := range s.priorityQueue.tasks {
+	// 按出队顺序获取优先级队列中的任务
+	now := time.Now()
+	for _, task := range s.priorityQueue.Ordered(now) {
 		if task.Status == TaskStatusPending {
 			queueItems = append(queueItems, &QueueItem{
-				SubmissionID:  task.SubmissionID,
-				UserID:        task.UserID,
-				ProblemID:     task.ProblemID,
-				Language:      task.Language,
-				Priority:      task.Priority,
-				QueueTime:     task.CreatedAt.Format(time.RFC3339),
-				EstimatedTime: s.estimateWaitTime(len(queueItems)),
+				SubmissionID:      task.SubmissionID,
+				UserID:            task.UserID,
+				ProblemID:         task.ProblemID,
+				Language:          task.Language,
+				Priority:          task.Priority,
+				EffectivePriority: s.priorityQueue.EffectivePriority(task, now),
+				QueueTime:         task.CreatedAt.Format(time.RFC3339),
+				WaitTime:          int(now.Sub(task.CreatedAt).Seconds()),
+				EstimatedTime:     s.estimateWaitTime(len(queueItems)),
 			})
 		}
 	}
-	s.priorityQueue.mutex.Unlock()
 
 	return &QueueStatus{
 		QueueLength:    len(queueItems),
@@ -312,11 +442,8 @@ func (s *TaskScheduler) GetQueueStatus() *QueueStatus {
 
 // 获取任务在队列中的位置
 func (s *TaskScheduler) GetTaskPosition(taskID string) (int, error) {
-	s.priorityQueue.mutex.Lock()
-	defer s.priorityQueue.mutex.Unlock()
-
-	// 在优先级队列中查找任务位置
-	for i, task := range s.priorityQueue.tasks {
+	// 在优先级队列中按出队顺序查找任务位置
+	for i, task := range s.priorityQueue.Ordered(time.Now()) {
 		if task.ID == taskID && task.Status == TaskStatusPending {
 			return i + 1, nil // 返回1基的位置
 		}
@@ -355,83 +482,38 @@ func (s *TaskScheduler) FindTaskBySubmissionID(submissionID int64) (*JudgeTask,
 	return nil, fmt.Errorf("task not found for submission_id: %d", submissionID)
 }
 
-// 任务分发器（从优先级队列到taskQueue）
-func (s *TaskScheduler) dispatch() {
-	ticker := time.NewTicker(100 * time.Millisecond)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-s.ctx.Done():
-			return
-		case <-ticker.C:
-			// 从优先级队列获取任务
-			task := s.priorityQueue.Pop()
-			if task == nil {
-				continue
-			}
+// 取下一个任务：阻塞直到队列中有任务且队首任务的资源预留成功，调度器停止时返回nil
+func (s *TaskScheduler) next() *JudgeTask {
+	task := s.priorityQueue.Next(s.ctx, s.reserve)
+	if task == nil {
+		return nil
+	}
 
-			// 检查任务是否已取消
-			if task.Status == TaskStatusCancelled {
-				continue
-			}
+	atomic.AddInt64(&s.stats.PendingTasks, -1)
+	atomic.AddInt64(&s.stats.RunningTasks, 1)
+	return task
+}
 
-			// 尝试分配到任务队列
-			select {
-			case s.taskQueue <- task:
-				// 成功分配任务到队列
-				logx.Infof("Task %s dispatched to queue", task.ID)
-			default:
-				// 队列已满，重新加入优先级队列
-				s.priorityQueue.Push(task)
-			}
-		}
+// 为队首任务预留资源，预算不足时任务留在队首，等待其它任务结束后重新尝试
+func (s *TaskScheduler) reserve(task *JudgeTask) bool {
+	if s.budget == nil {
+		return true
 	}
+	reservation, ok := s.budget.TryReserve(task)
+	if !ok {
+		return false
+	}
+	task.reservation = reservation
+	return true
 }
 
-// 任务分配器（从taskQueue分发给workers）
-func (s *TaskScheduler) distributeToWorkers() {
-	for {
-		select {
-		case <-s.ctx.Done():
-			return
-		case task := <-s.taskQueue:
-			// 检查任务是否已取消
-			if task.Status == TaskStatusCancelled {
-				continue
-			}
-
-			// 寻找空闲的worker
-			distributed := false
-			for _, worker := range s.workers {
-				select {
-				case worker.TaskChan <- task:
-					// 成功分配给worker
-					logx.Infof("Task %s distributed to worker %d", task.ID, worker.ID)
-					atomic.AddInt64(&s.stats.PendingTasks, -1)
-					atomic.AddInt64(&s.stats.RunningTasks, 1)
-					distributed = true
-					goto nextTask
-				default:
-					// 这个worker忙，尝试下一个
-					continue
-				}
-			}
-
-			// 如果没有空闲worker，重新放回队列
-			if !distributed {
-				select {
-				case s.taskQueue <- task:
-					// 重新放回队列
-				default:
-					// 队列已满，放回优先级队列
-					s.priorityQueue.Push(task)
-				}
-			}
-
-		nextTask:
-		}
+// 任务结束：归还预留的资源，唤醒等待资源的工作器
+func (s *TaskScheduler) finish(task *JudgeTask) {
+	if task.reservation == nil {
+		return
 	}
+	releaseReservation(s.budget, task)
+	s.priorityQueue.Wake()
 }
 
 // 更新统计信息
@@ -499,36 +581,54 @@ type QueueStatus struct {
 
 // 队列项
 type QueueItem struct {
-	SubmissionID  int64  `json:"submission_id"`
-	UserID        int64  `json:"user_id"`
-	ProblemID     int64  `json:"problem_id"`
-	Language      string `json:"language"`
-	Priority      int    `json:"priority"`
-	QueueTime     string `json:"queue_time"`
-	EstimatedTime int    `json:"estimated_time"`
+	SubmissionID      int64   `json:"submission_id"`
+	UserID            int64   `json:"user_id"`
+	ProblemID         int64   `json:"problem_id"`
+	Language          string  `json:"language"`
+	Priority          int     `json:"priority"`
+	EffectivePriority float64 `json:"effective_priority"` // 老化后的优先级
+	QueueTime         string  `json:"queue_time"`
+	WaitTime          int     `json:"wait_time"` // 已等待时间(秒)
+	EstimatedTime     int     `json:"estimated_time"`
 }
 
-// 优先级队列
+// 优先级队列：tasks先按优先级，同一优先级内按公平排队的结束标签，最后按创建时间排序
+// 出队时在各优先级的队首中选择有效优先级最高的任务（见aging.go）
+// 空闲的工作器在ready上等待，入队、资源归还与调度器停止时被唤醒，没有固定的轮询间隔
 type PriorityQueue struct {
 	tasks []*JudgeTask
 	mutex sync.Mutex
+	ready *sync.Cond
+	fair  *fairQueue // 为nil时同一优先级内按创建时间排序
+	aging *priorityAging
 }
 
-func NewPriorityQueue() *PriorityQueue {
-	return &PriorityQueue{
+func NewPriorityQueue(fair config.FairQueueConf, aging config.PriorityAgingConf) *PriorityQueue {
+	pq := &PriorityQueue{
 		tasks: make([]*JudgeTask, 0),
+		aging: &priorityAging{conf: aging},
 	}
+	pq.ready = sync.NewCond(&pq.mutex)
+	if fair.Enabled {
+		pq.fair = newFairQueue(fair)
+	}
+	return pq
 }
 
 func (pq *PriorityQueue) Push(task *JudgeTask) {
 	pq.mutex.Lock()
 	defer pq.mutex.Unlock()
 
+	if pq.fair != nil {
+		pq.fair.tag(task)
+	}
+
 	// 按优先级插入任务
 	inserted := false
 	for i, t := range pq.tasks {
 		if task.Priority < t.Priority ||
-			(task.Priority == t.Priority && task.CreatedAt.Before(t.CreatedAt)) {
+			(task.Priority == t.Priority && task.fairFinish < t.fairFinish) ||
+			(task.Priority == t.Priority && task.fairFinish == t.fairFinish && task.CreatedAt.Before(t.CreatedAt)) {
 			// 插入到合适位置
 			pq.tasks = append(pq.tasks[:i], append([]*JudgeTask{task}, pq.tasks[i:]...)...)
 			inserted = true
@@ -539,6 +639,9 @@ func (pq *PriorityQueue) Push(task *JudgeTask) {
 	if !inserted {
 		pq.tasks = append(pq.tasks, task)
 	}
+
+	// 唤醒一个空闲的工作器
+	pq.ready.Signal()
 }
 
 func (pq *PriorityQueue) Pop() *JudgeTask {
@@ -548,9 +651,69 @@ func (pq *PriorityQueue) Pop() *JudgeTask {
 	if len(pq.tasks) == 0 {
 		return nil
 	}
+	return pq.remove(pq.head(time.Now()))
+}
+
+// Next 阻塞直到队首任务被admit接受，将其出队返回；ctx结束时返回nil
+// admit在持有队列锁时调用，返回false时任务留在队首，Push或Wake后重新选择（期间老化可能改变队首）
+// 已取消的任务直接丢弃
+func (pq *PriorityQueue) Next(ctx context.Context, admit func(*JudgeTask) bool) *JudgeTask {
+	pq.mutex.Lock()
+	defer pq.mutex.Unlock()
 
-	task := pq.tasks[0]
-	pq.tasks = pq.tasks[1:]
+	for ctx.Err() == nil {
+		if len(pq.tasks) == 0 {
+			pq.ready.Wait()
+			continue
+		}
+
+		next := pq.head(time.Now())
+		task := pq.tasks[next]
+		if task.Status == TaskStatusCancelled {
+			pq.remove(next)
+			continue
+		}
+		if admit != nil && !admit(task) {
+			pq.ready.Wait()
+			continue
+		}
+
+		pq.remove(next)
+		if len(pq.tasks) > 0 {
+			// 还有任务，唤醒下一个空闲的工作器
+			pq.ready.Signal()
+		}
+		return task
+	}
+	return nil
+}
+
+// Wake 唤醒所有等待的工作器重新选择队首任务（资源归还、调度器停止时）
+// 持有队列锁广播，等待者检查条件与进入等待之间不会错过唤醒
+func (pq *PriorityQueue) Wake() {
+	pq.mutex.Lock()
+	defer pq.mutex.Unlock()
+	pq.ready.Broadcast()
+}
+
+// 下一个出队任务的下标：各优先级的队首位于优先级变化处，选择有效优先级最高的一个
+func (pq *PriorityQueue) head(now time.Time) int {
+	next := 0
+	for i := 1; i < len(pq.tasks); i++ {
+		if pq.tasks[i].Priority != pq.tasks[i-1].Priority && pq.aging.before(pq.tasks[i], pq.tasks[next], now) {
+			next = i
+		}
+	}
+	return next
+}
+
+// 移除并返回下标i处的任务
+func (pq *PriorityQueue) remove(i int) *JudgeTask {
+	task := pq.tasks[i]
+	pq.tasks = append(pq.tasks[:i], pq.tasks[i+1:]...)
+	if pq.fair != nil {
+		pq.fair.popped(task)
+	}
 	return task
 }
 
@@ -560,6 +723,40 @@ func (pq *PriorityQueue) Len() int {
 	return len(pq.tasks)
 }
 
+// Ordered 按now时的出队顺序返回队列中的任务：依次合并各优先级的队列，每次取有效优先级最高的队首
+func (pq *PriorityQueue) Ordered(now time.Time) []*JudgeTask {
+	pq.mutex.Lock()
+	defer pq.mutex.Unlock()
+
+	var levels [][]*JudgeTask
+	for start := 0; start < len(pq.tasks); {
+		end := start + 1
+		for end < len(pq.tasks) && pq.tasks[end].Priority == pq.tasks[start].Priority {
+			end++
+		}
+		levels = append(levels, pq.tasks[start:end])
+		start = end
+	}
+
+	ordered := make([]*JudgeTask, 0, len(pq.tasks))
+	for len(ordered) < len(pq.tasks) {
+		best := -1
+		for i, level := range levels {
+			if len(level) > 0 && (best < 0 || pq.aging.before(level[0], levels[best][0], now)) {
+				best = i
+			}
+		}
+		ordered = append(ordered, levels[best][0])
+		levels[best] = levels[best][1:]
+	}
+	return ordered
+}
+
+// EffectivePriority 任务在now时的有效优先级
+func (pq *PriorityQueue) EffectivePriority(task *JudgeTask, now time.Time) float64 {
+	return pq.aging.effective(task, now)
+}
+
 // 任务重试机制
 func (s *TaskScheduler) retryFailedTask(task *JudgeTask) {
 	if task.RetryCount >= s.config.RetryTimes {
@@ -580,3 +777,18 @@ func (s *TaskScheduler) retryFailedTask(task *JudgeTask) {
 		logx.Infof("Retrying task %s (attempt %d)", task.ID, task.RetryCount)
 	})
 }
+
+// 保存任务状态；存储不可用时只记录日志，不影响判题
+func saveTask(store TaskStore, task *JudgeTask) {
+	if err := store.Save(task); err != nil {
+		logx.Errorf("Failed to persist task %s: %v", task.ID, err)
+	}
+}
+
+// 归还任务预留的资源
+func releaseReservation(budget *ResourceBudget, task *JudgeTask) {
+	if budget != nil && task.reservation != nil {
+		budget.Release(task.reservation)
+		task.reservation = nil
+	}
+}
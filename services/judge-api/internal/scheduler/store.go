@@ -0,0 +1,269 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/types"
+
+	"github.com/zeromicro/go-zero/core/stores/redis"
+)
+
+// 任务状态持久化
+// 调度器在内存中保存本节点的任务（含上下文与取消函数），每次状态变化时同时写入TaskStore：
+// 判题服务重启后从中恢复本节点未完成的任务并重新排队，其它节点也能据此查询任务状态
+// 每次状态变化都会写入，存储中只保存调度与状态字段：测试数据可能有数MB，由ProblemID在恢复时重新获取（见TestCaseLoader）；
+// 代码只在任务结束前保存，测试点结果中的输入与期望输出同样属于测试数据，不写入存储
+
+// 任务存储未找到时返回的错误
+var ErrTaskNotFound = fmt.Errorf("task not found in store")
+
+// TaskStore 任务状态存储
+type TaskStore interface {
+	// Save 保存任务的当前状态；未结束的任务计入所属节点的未完成集合
+	Save(task *JudgeTask) error
+	// Load 按任务ID读取
+	Load(taskID string) (*JudgeTask, error)
+	// FindBySubmissionID 按提交ID读取最近一次提交的任务
+	FindBySubmissionID(submissionID int64) (*JudgeTask, error)
+	// ListUnfinished 指定节点上等待中与执行中的任务
+	ListUnfinished(nodeID string) ([]*JudgeTask, error)
+}
+
+// 等待中与执行中的任务需要在重启后恢复
+func isUnfinished(status string) bool {
+	return status == TaskStatusPending || status == TaskStatusRunning
+}
+
+// MemoryTaskStore 进程内的任务存储，不跨重启、不跨节点，用于单机部署与测试
+type MemoryTaskStore struct {
+	mutex        sync.Mutex
+	tasks        map[string][]byte
+	submissions  map[int64]string
+	retention    time.Duration
+	completedAts map[string]time.Time
+}
+
+func NewMemoryTaskStore(retention time.Duration) *MemoryTaskStore {
+	return &MemoryTaskStore{
+		tasks:        make(map[string][]byte),
+		submissions:  make(map[int64]string),
+		retention:    retention,
+		completedAts: make(map[string]time.Time),
+	}
+}
+
+func (m *MemoryTaskStore) Save(task *JudgeTask) error {
+	// 保存副本，调用方之后对任务的修改不影响已保存的状态
+	data, err := marshalTask(task)
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.tasks[task.ID] = data
+	m.submissions[task.SubmissionID] = task.ID
+	if isUnfinished(task.Status) {
+		delete(m.completedAts, task.ID)
+	} else {
+		m.completedAts[task.ID] = time.Now()
+	}
+	m.expireLocked()
+	return nil
+}
+
+func (m *MemoryTaskStore) Load(taskID string) (*JudgeTask, error) {
+	m.mutex.Lock()
+	data, ok := m.tasks[taskID]
+	m.mutex.Unlock()
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	return unmarshalTask(data)
+}
+
+func (m *MemoryTaskStore) FindBySubmissionID(submissionID int64) (*JudgeTask, error) {
+	m.mutex.Lock()
+	taskID, ok := m.submissions[submissionID]
+	m.mutex.Unlock()
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	return m.Load(taskID)
+}
+
+func (m *MemoryTaskStore) ListUnfinished(nodeID string) ([]*JudgeTask, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var tasks []*JudgeTask
+	for _, data := range m.tasks {
+		task, err := unmarshalTask(data)
+		if err != nil {
+			return nil, err
+		}
+		if task.NodeID == nodeID && isUnfinished(task.Status) {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, nil
+}
+
+// 删除超过保留时间的已结束任务
+func (m *MemoryTaskStore) expireLocked() {
+	if m.retention <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-m.retention)
+	for taskID, completedAt := range m.completedAts {
+		if completedAt.After(cutoff) {
+			continue
+		}
+		if task, err := unmarshalTask(m.tasks[taskID]); err == nil && m.submissions[task.SubmissionID] == taskID {
+			delete(m.submissions, task.SubmissionID)
+		}
+		delete(m.tasks, taskID)
+		delete(m.completedAts, taskID)
+	}
+}
+
+// RedisTaskStore 保存在Redis中的任务存储，多个判题节点共享
+// 键结构（prefix默认为judge）：
+//   - {prefix}:task:{taskID}                任务JSON；结束后设置过期时间
+//   - {prefix}:task:submission:{submission} 提交ID对应的最近一次任务ID
+//   - {prefix}:tasks:unfinished:{nodeID}    节点上未完成的任务ID集合，重启时据此恢复
+type RedisTaskStore struct {
+	rds       *redis.Redis
+	prefix    string
+	retention int // 已结束任务的保留时间(秒)
+}
+
+func NewRedisTaskStore(rds *redis.Redis, prefix string, retention int) *RedisTaskStore {
+	return &RedisTaskStore{
+		rds:       rds,
+		prefix:    prefix,
+		retention: retention,
+	}
+}
+
+func (r *RedisTaskStore) taskKey(taskID string) string {
+	return fmt.Sprintf("%s:task:%s", r.prefix, taskID)
+}
+
+func (r *RedisTaskStore) submissionKey(submissionID int64) string {
+	return fmt.Sprintf("%s:task:submission:%d", r.prefix, submissionID)
+}
+
+func (r *RedisTaskStore) unfinishedKey(nodeID string) string {
+	return fmt.Sprintf("%s:tasks:unfinished:%s", r.prefix, nodeID)
+}
+
+func (r *RedisTaskStore) Save(task *JudgeTask) error {
+	data, err := marshalTask(task)
+	if err != nil {
+		return err
+	}
+
+	// 未完成的任务不过期；结束后任务与提交索引按保留时间过期，并移出未完成集合
+	var expiration time.Duration
+	if !isUnfinished(task.Status) {
+		expiration = time.Duration(r.retention) * time.Second
+	}
+	err = r.rds.Pipelined(func(pipe redis.Pipeliner) error {
+		ctx := context.Background()
+		pipe.Set(ctx, r.taskKey(task.ID), data, expiration)
+		pipe.Set(ctx, r.submissionKey(task.SubmissionID), task.ID, expiration)
+		if isUnfinished(task.Status) {
+			pipe.SAdd(ctx, r.unfinishedKey(task.NodeID), task.ID)
+		} else {
+			pipe.SRem(ctx, r.unfinishedKey(task.NodeID), task.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save task %s: %w", task.ID, err)
+	}
+	return nil
+}
+
+func (r *RedisTaskStore) Load(taskID string) (*JudgeTask, error) {
+	data, err := r.rds.Get(r.taskKey(taskID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load task %s: %w", taskID, err)
+	}
+	if data == "" {
+		return nil, ErrTaskNotFound
+	}
+	return unmarshalTask([]byte(data))
+}
+
+func (r *RedisTaskStore) FindBySubmissionID(submissionID int64) (*JudgeTask, error) {
+	taskID, err := r.rds.Get(r.submissionKey(submissionID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find task for submission %d: %w", submissionID, err)
+	}
+	if taskID == "" {
+		return nil, ErrTaskNotFound
+	}
+	return r.Load(taskID)
+}
+
+func (r *RedisTaskStore) ListUnfinished(nodeID string) ([]*JudgeTask, error) {
+	taskIDs, err := r.rds.Smembers(r.unfinishedKey(nodeID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unfinished tasks of %s: %w", nodeID, err)
+	}
+
+	var tasks []*JudgeTask
+	for _, taskID := range taskIDs {
+		task, err := r.Load(taskID)
+		if err == ErrTaskNotFound {
+			// 任务数据已丢失，集合中的ID不再有意义
+			r.rds.Srem(r.unfinishedKey(nodeID), taskID)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// 序列化任务中需要持久化的部分
+func marshalTask(task *JudgeTask) ([]byte, error) {
+	stored := *task
+	stored.TestCases = nil
+	if !isUnfinished(task.Status) {
+		stored.Code = ""
+	}
+	if task.Result != nil {
+		result := *task.Result
+		result.TestCases = make([]types.TestCaseResult, len(task.Result.TestCases))
+		for i, testCase := range task.Result.TestCases {
+			testCase.Input = ""
+			testCase.Expected = ""
+			result.TestCases[i] = testCase
+		}
+		stored.Result = &result
+	}
+
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal task %s: %w", task.ID, err)
+	}
+	return data, nil
+}
+
+func unmarshalTask(data []byte) (*JudgeTask, error) {
+	var task JudgeTask
+	if err := json.Unmarshal(data, &task); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal task: %w", err)
+	}
+	return &task, nil
+}
@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/config"
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/types"
+)
+
+func TestMemoryTaskStoreFindBySubmission(t *testing.T) {
+	store := NewMemoryTaskStore(time.Hour)
+	task := &JudgeTask{ID: "task_1", SubmissionID: 1, Status: TaskStatusPending, NodeID: "node-a"}
+	if err := store.Save(task); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	// 保存的是副本
+	task.Status = TaskStatusCompleted
+
+	found, err := store.FindBySubmissionID(1)
+	if err != nil {
+		t.Fatalf("find failed: %v", err)
+	}
+	if found.ID != "task_1" || found.Status != TaskStatusPending {
+		t.Fatalf("unexpected task: %+v", found)
+	}
+	if _, err := store.FindBySubmissionID(2); err != ErrTaskNotFound {
+		t.Fatalf("expected ErrTaskNotFound, got %v", err)
+	}
+}
+
+// 存储中不保存测试数据；代码只保存到任务结束
+func TestTaskStoreOmitsTestData(t *testing.T) {
+	store := NewMemoryTaskStore(time.Hour)
+	task := &JudgeTask{
+		ID: "task_1", SubmissionID: 1, ProblemID: 7, Code: "int main() {}", Status: TaskStatusPending, NodeID: "node-a",
+		TestCases:     []*types.TestCase{{CaseId: 1, Input: "1 2", ExpectedOutput: "3"}},
+		TestCaseCount: 1,
+	}
+	if err := store.Save(task); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	pending, err := store.Load("task_1")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if pending.TestCases != nil || pending.TestCaseCount != 1 || pending.Code != task.Code || pending.ProblemID != 7 {
+		t.Fatalf("unexpected pending task: %+v", pending)
+	}
+
+	task.Status = TaskStatusCompleted
+	task.Result = &types.JudgeResult{TestCases: []types.TestCaseResult{{CaseId: 1, Status: "accepted", Input: "1 2", Output: "3", Expected: "3"}}}
+	if err := store.Save(task); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	completed, err := store.Load("task_1")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if completed.Code != "" || len(completed.Result.TestCases) != 1 {
+		t.Fatalf("unexpected completed task: %+v", completed)
+	}
+	if result := completed.Result.TestCases[0]; result.Input != "" || result.Expected != "" || result.Output != "3" {
+		t.Fatalf("unexpected stored test case result: %+v", result)
+	}
+	// 调用方的任务不受影响
+	if len(task.TestCases) != 1 || task.Result.TestCases[0].Input != "1 2" {
+		t.Fatal("saving must not modify the task")
+	}
+}
+
+func TestRecoverTasks(t *testing.T) {
+	store := NewMemoryTaskStore(time.Hour)
+	base := time.Now().Add(-time.Minute)
+	for _, task := range []*JudgeTask{
+		{ID: "pending", SubmissionID: 1, ProblemID: 1, NodeID: "node-a", Status: TaskStatusPending, CreatedAt: base.Add(2 * time.Second)},
+		{ID: "running", SubmissionID: 2, ProblemID: 1, NodeID: "node-a", Status: TaskStatusRunning, CreatedAt: base.Add(time.Second)},
+		{ID: "crashing", SubmissionID: 3, ProblemID: 1, NodeID: "node-a", Status: TaskStatusRunning, RetryCount: 2, CreatedAt: base},
+		{ID: "done", SubmissionID: 4, ProblemID: 1, NodeID: "node-a", Status: TaskStatusCompleted, CreatedAt: base},
+		{ID: "other", SubmissionID: 5, ProblemID: 1, NodeID: "node-b", Status: TaskStatusPending, CreatedAt: base},
+		{ID: "missing", SubmissionID: 6, ProblemID: 2, NodeID: "node-a", Status: TaskStatusPending, CreatedAt: base},
+	} {
+		if err := store.Save(task); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+	}
+
+	s := NewTaskScheduler(&config.TaskQueueConf{TaskTimeout: 60, RetryTimes: 2}, nil, store, "node-a", nil)
+	loads := 0
+	s.SetTestCaseLoader(func(ctx context.Context, problemID int64) ([]*types.TestCase, error) {
+		loads++
+		if problemID != 1 {
+			return nil, fmt.Errorf("problem %d not found", problemID)
+		}
+		return []*types.TestCase{{CaseId: 1}, {CaseId: 2}}, nil
+	})
+	s.recoverTasks()
+	defer s.cancel()
+
+	if len(s.RecoveredTasks()) != 4 {
+		t.Fatalf("expected 4 recovered tasks, got %d", len(s.RecoveredTasks()))
+	}
+	// 同一题目的测试数据只获取一次
+	if loads != 2 {
+		t.Fatalf("expected test cases to be loaded once per problem, got %d loads", loads)
+	}
+
+	// 按创建时间重新排队，中断过的任务计入重试次数，测试数据重新获取
+	if first := s.priorityQueue.Pop(); first == nil || first.ID != "running" || first.RetryCount != 1 || len(first.TestCases) != 2 {
+		t.Fatalf("unexpected first task: %+v", first)
+	}
+	if second := s.priorityQueue.Pop(); second == nil || second.ID != "pending" || second.TestCaseCount != 2 {
+		t.Fatalf("unexpected second task: %+v", second)
+	}
+	if s.priorityQueue.Len() != 0 {
+		t.Fatalf("expected an empty queue, got %d tasks", s.priorityQueue.Len())
+	}
+
+	// 超过重试次数的任务直接失败，状态写回存储
+	crashing, err := store.Load("crashing")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if crashing.Status != TaskStatusFailed || crashing.CompletedAt == nil {
+		t.Fatalf("expected the crashing task to fail, got %+v", crashing)
+	}
+	// 测试数据无法获取的任务同样失败
+	missing, err := store.Load("missing")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if missing.Status != TaskStatusFailed || missing.Error == "" {
+		t.Fatalf("expected the task without test cases to fail, got %+v", missing)
+	}
+	if unfinished, _ := store.ListUnfinished("node-a"); len(unfinished) != 2 {
+		t.Fatalf("expected 2 unfinished tasks, got %d", len(unfinished))
+	}
+}
@@ -0,0 +1,326 @@
+package selftest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/languages"
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/sandbox"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// 沙箱安全自检
+// 用与判题相同的语言执行器编译并运行一组恶意程序，逐个确认得到预期的结果，
+// 节点加入判题集群之前、以及修改Compilers或seccomp配置之后运行，防止配置变更悄悄打开隔离缺口
+// 恶意程序在攻击得逞时向标准输出打印escapeMarker并正常退出；被拦截时由沙箱终止，或自行以非零退出码结束
+
+// 攻击得逞的标记，出现在标准输出中即判为失败
+const escapeMarker = "ESCAPED"
+
+// 越界写入的目标：工作目录的上级目录（判题服务的临时目录）中的文件
+const escapeFileName = "selftest_escape.txt"
+
+// Case 一个自检用例，每种语言的程序位于<SuiteDir>/<语言>/<Name><扩展名>
+type Case struct {
+	Name        string
+	Description string
+	Expected    []string // 可接受的结果（见Verdict）
+	// 运行后的额外检查，caseDir为工作目录的上级目录
+	check func(caseDir, outputFile string, opts Options) error
+}
+
+// 对照用例：正常程序必须通过，否则语言环境本身有问题（如解释器不在根文件系统中），其余用例的结果不可信
+var controlCase = Case{
+	Name:        "hello",
+	Description: "print a greeting and exit normally",
+	Expected:    []string{"accepted"},
+	check:       checkGreeting,
+}
+
+// Cases 自检用例
+var Cases = []Case{
+	{
+		Name:        "fork_bomb",
+		Description: "fork processes in an endless loop",
+		Expected:    []string{"restricted_function", "runtime_error", "time_limit_exceeded", "memory_limit_exceeded"},
+	},
+	{
+		Name:        "infinite_output",
+		Description: "write to stdout forever",
+		Expected:    []string{"output_limit_exceeded"},
+		check:       checkOutputCapped,
+	},
+	{
+		Name:        "memory_balloon",
+		Description: "allocate and touch memory until killed",
+		Expected:    []string{"memory_limit_exceeded", "runtime_error"},
+	},
+	{
+		Name:        "network_connect",
+		Description: "open a TCP connection to an external address",
+		Expected:    []string{"restricted_function", "runtime_error"},
+	},
+	{
+		Name:        "read_shadow",
+		Description: "read /etc/shadow",
+		Expected:    []string{"restricted_function", "runtime_error"},
+	},
+	{
+		Name:        "ptrace",
+		Description: "attach to or read the memory of the parent process",
+		Expected:    []string{"restricted_function", "runtime_error"},
+	},
+	{
+		Name:        "write_outside_workdir",
+		Description: "create a file next to the work directory",
+		Expected:    []string{"restricted_function", "runtime_error"},
+		check:       checkNoEscapeFile,
+	},
+	{
+		Name:        "sleep_forever",
+		Description: "sleep without using CPU",
+		Expected:    []string{"time_limit_exceeded"},
+	},
+}
+
+// Options 自检参数
+type Options struct {
+	SuiteDir    string // 恶意程序目录，其下每种语言一个子目录
+	WorkDir     string // 编译与运行的临时目录
+	TimeLimit   int64  // 时间限制(毫秒)，按语言倍数放大
+	MemoryLimit int64  // 内存限制(KB)，按语言倍数放大
+	OutputLimit int64  // 判题配置的输出上限(字节)，0表示不检查输出文件大小
+	Rootless    bool   // 判题服务以rootless模式运行，文件不修改所有者
+}
+
+// CaseResult 单个用例的结果
+type CaseResult struct {
+	Language string
+	Case     string
+	Verdict  string // 观察到的结果
+	Passed   bool
+	Detail   string // 失败原因
+	Duration time.Duration
+}
+
+// Report 自检报告
+type Report struct {
+	Results []CaseResult
+}
+
+// Failed 失败的用例数
+func (r *Report) Failed() int {
+	failed := 0
+	for _, result := range r.Results {
+		if !result.Passed {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Write 输出逐项结果与汇总
+func (r *Report) Write(w io.Writer) {
+	for _, result := range r.Results {
+		status := "PASS"
+		if !result.Passed {
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "%s  %-12s %-22s %-22s %6dms", status, result.Language, result.Case, result.Verdict, result.Duration.Milliseconds())
+		if result.Detail != "" {
+			fmt.Fprintf(w, "  %s", result.Detail)
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "%d passed, %d failed\n", len(r.Results)-r.Failed(), r.Failed())
+}
+
+// Verdict 执行结果对应的判题结果名称；正常结束但退出码非零按运行错误计
+func Verdict(result *sandbox.ExecuteResult) string {
+	if result.Status == sandbox.StatusAccepted && result.ExitCode != 0 {
+		return sandbox.StatusName(sandbox.StatusRuntimeError)
+	}
+	return sandbox.StatusName(result.Status)
+}
+
+// Run 对一种语言先运行对照用例，通过后再运行全部恶意程序
+func Run(ctx context.Context, language string, executor languages.LanguageExecutor, opts Options) []CaseResult {
+	control := runAndCheck(ctx, language, controlCase, executor, opts)
+	results := []CaseResult{control}
+	for _, c := range Cases {
+		if !control.Passed {
+			// 语言环境不可用时恶意程序同样会失败，不能据此判断隔离有效
+			results = append(results, CaseResult{Language: language, Case: c.Name, Verdict: "-", Detail: "skipped: the control program failed"})
+			continue
+		}
+		results = append(results, runAndCheck(ctx, language, c, executor, opts))
+	}
+	return results
+}
+
+// 运行单个用例并与预期结果比较
+func runAndCheck(ctx context.Context, language string, c Case, executor languages.LanguageExecutor, opts Options) CaseResult {
+	start := time.Now()
+	verdict, err := runCase(ctx, c, executor, filepath.Join(opts.SuiteDir, language), opts)
+
+	result := CaseResult{Language: language, Case: c.Name, Verdict: verdict.name, Duration: time.Since(start)}
+	switch {
+	case err != nil:
+		result.Detail = err.Error()
+	case !contains(c.Expected, verdict.name):
+		result.Detail = fmt.Sprintf("expected %s", strings.Join(c.Expected, " or "))
+		if verdict.syscall != "" {
+			result.Detail += fmt.Sprintf(", blocked syscall %s", verdict.syscall)
+		}
+		if verdict.stderr != "" {
+			result.Detail += fmt.Sprintf(", stderr: %s", verdict.stderr)
+		}
+	default:
+		result.Passed = true
+	}
+	if !result.Passed {
+		logx.Errorf("Selftest %s/%s failed: verdict=%s %s", language, c.Name, verdict.name, result.Detail)
+	}
+	return result
+}
+
+// 观察到的结果，restricted_function时附带被拦截的系统调用
+type observedVerdict struct {
+	name    string
+	syscall string
+	stderr  string // 标准错误的第一行
+}
+
+// 编译并运行单个用例，返回观察到的结果；攻击得逞或额外检查不通过时返回错误
+func runCase(ctx context.Context, c Case, executor languages.LanguageExecutor, programDir string, opts Options) (observedVerdict, error) {
+	code, err := os.ReadFile(filepath.Join(programDir, c.Name+executor.GetFileExtension()))
+	if err != nil {
+		return observedVerdict{name: "-"}, fmt.Errorf("missing program: %w", err)
+	}
+
+	// 上级目录由判题服务持有，工作目录供沙箱用户写入
+	caseDir, err := os.MkdirTemp(opts.WorkDir, "selftest-")
+	if err != nil {
+		return observedVerdict{name: "-"}, fmt.Errorf("failed to create work dir: %w", err)
+	}
+	defer os.RemoveAll(caseDir)
+	workDir := filepath.Join(caseDir, "work")
+	if err := os.Mkdir(workDir, 0777); err != nil {
+		return observedVerdict{name: "-"}, err
+	}
+	if err := os.Chmod(workDir, 0777); err != nil {
+		return observedVerdict{name: "-"}, err
+	}
+	if err := sandbox.ChownToSandboxUser(workDir, opts.Rootless); err != nil {
+		return observedVerdict{name: "-"}, err
+	}
+
+	compileResult, err := executor.Compile(ctx, string(code), workDir)
+	if err != nil {
+		return observedVerdict{name: "compile_error"}, fmt.Errorf("compile failed: %w", err)
+	}
+	if !compileResult.Success {
+		return observedVerdict{name: "compile_error"}, fmt.Errorf("compile failed: %s", firstLine(compileResult.Message))
+	}
+
+	inputFile := filepath.Join(workDir, "input.txt")
+	if err := os.WriteFile(inputFile, nil, 0644); err != nil {
+		return observedVerdict{name: "-"}, err
+	}
+	outputFile := filepath.Join(workDir, "output.txt")
+	result, err := executor.Execute(ctx, compileResult.ExecutablePath, workDir, &languages.ExecutionConfig{
+		TimeLimit:   int64(float64(opts.TimeLimit) * executor.GetTimeMultiplier()),
+		MemoryLimit: int64(float64(opts.MemoryLimit) * executor.GetMemoryMultiplier()),
+		InputFile:   inputFile,
+		OutputFile:  outputFile,
+		ErrorFile:   filepath.Join(workDir, "error.txt"),
+		Environment: []string{"PATH=/usr/bin:/bin"},
+	})
+	if err != nil {
+		return observedVerdict{name: "system_error"}, fmt.Errorf("execution failed: %w", err)
+	}
+	verdict := observedVerdict{name: Verdict(result), syscall: result.RestrictedSyscallName, stderr: firstLine(result.ErrorOutput)}
+
+	if escaped, err := outputHasMarker(outputFile); err != nil {
+		return verdict, err
+	} else if escaped {
+		return verdict, fmt.Errorf("the attack succeeded")
+	}
+	if c.check != nil {
+		if err := c.check(caseDir, outputFile, opts); err != nil {
+			return verdict, err
+		}
+	}
+	return verdict, nil
+}
+
+// 标准输出开头是否为攻击得逞的标记（只读取开头，无限输出的用例也不会读入整个文件）
+func outputHasMarker(outputFile string) (bool, error) {
+	file, err := os.Open(outputFile)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	buf := make([]byte, len(escapeMarker))
+	n, _ := io.ReadFull(file, buf)
+	return string(buf[:n]) == escapeMarker, nil
+}
+
+// 输出文件不超过判题配置的输出上限
+func checkOutputCapped(caseDir, outputFile string, opts Options) error {
+	if opts.OutputLimit <= 0 {
+		return nil
+	}
+	info, err := os.Stat(outputFile)
+	if err != nil {
+		return nil
+	}
+	if info.Size() > opts.OutputLimit {
+		return fmt.Errorf("output file grew to %d bytes, limit %d", info.Size(), opts.OutputLimit)
+	}
+	return nil
+}
+
+// 对照程序的输出正确
+func checkGreeting(caseDir, outputFile string, opts Options) error {
+	output, err := os.ReadFile(outputFile)
+	if err != nil {
+		return fmt.Errorf("failed to read output: %w", err)
+	}
+	if strings.TrimSpace(string(output)) != "hello" {
+		return fmt.Errorf("unexpected output %q", firstLine(string(output)))
+	}
+	return nil
+}
+
+// 工作目录之外没有出现程序写入的文件
+func checkNoEscapeFile(caseDir, outputFile string, opts Options) error {
+	if _, err := os.Stat(filepath.Join(caseDir, escapeFileName)); err == nil {
+		return fmt.Errorf("%s was created outside the work directory", escapeFileName)
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func firstLine(message string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return line
+}
@@ -38,17 +38,11 @@ func (s *TaskService) FindTaskBySubmissionID(submissionID int64) (*scheduler.Jud
 	task, err := s.svcCtx.TaskScheduler.FindTaskBySubmissionID(submissionID)
 	if err != nil {
 		s.logger.Errorf("在调度器中未找到提交ID %d 的任务: %v", submissionID, err)
-		
-		// 2. 如果调度器中没找到，尝试从缓存或数据库查找
-		task, err = s.findTaskFromCache(submissionID)
+
+		// 2. 调度器中没找到（其它节点或重启前的任务），从任务存储中查找
+		task, err = s.findTaskFromStore(submissionID)
 		if err != nil {
-			s.logger.Errorf("在缓存中也未找到提交ID %d 的任务: %v", submissionID, err)
-			
-			// 3. 最后尝试从持久化存储查找
-			task, err = s.findTaskFromStorage(submissionID)
-			if err != nil {
-				return nil, fmt.Errorf("未找到提交ID %d 对应的判题任务", submissionID)
-			}
+			return nil, fmt.Errorf("未找到提交ID %d 对应的判题任务", submissionID)
 		}
 	}
 
@@ -56,38 +50,14 @@ func (s *TaskService) FindTaskBySubmissionID(submissionID int64) (*scheduler.Jud
 	return task, nil
 }
 
-// findTaskFromCache 从缓存中查找任务
-func (s *TaskService) findTaskFromCache(submissionID int64) (*scheduler.JudgeTask, error) {
-	// TODO: 实现Redis缓存查找
-	// 缓存键格式: judge:task:submission:{submissionID}
-	cacheKey := fmt.Sprintf("judge:task:submission:%d", submissionID)
-	
-	// 这里应该从Redis获取任务信息
-	// taskData, err := s.svcCtx.RedisClient.Get(cacheKey)
-	// if err != nil {
-	//     return nil, fmt.Errorf("缓存中未找到任务: %v", err)
-	// }
-	
-	// var task scheduler.JudgeTask
-	// if err := json.Unmarshal([]byte(taskData), &task); err != nil {
-	//     return nil, fmt.Errorf("解析缓存任务数据失败: %v", err)
-	// }
-	
-	s.logger.Infof("尝试从缓存查找任务: %s", cacheKey)
-	return nil, fmt.Errorf("缓存功能暂未实现")
-}
-
-// findTaskFromStorage 从持久化存储查找任务
-func (s *TaskService) findTaskFromStorage(submissionID int64) (*scheduler.JudgeTask, error) {
-	// TODO: 实现数据库查找
-	// 这里应该查询数据库中的judge_tasks表
-	
-	s.logger.Infof("尝试从数据库查找提交ID %d 的任务", submissionID)
-	
-	// 示例SQL: SELECT * FROM judge_tasks WHERE submission_id = ?
-	// 然后将数据库记录转换为scheduler.JudgeTask结构
-	
-	return nil, fmt.Errorf("数据库查找功能暂未实现")
+// findTaskFromStore 从任务状态存储中查找任务
+func (s *TaskService) findTaskFromStore(submissionID int64) (*scheduler.JudgeTask, error) {
+	task, err := s.svcCtx.TaskStore.FindBySubmissionID(submissionID)
+	if err != nil {
+		s.logger.Errorf("在任务存储中未找到提交ID %d 的任务: %v", submissionID, err)
+		return nil, err
+	}
+	return task, nil
 }
 
 // ValidateTaskAccess 验证任务访问权限
@@ -143,9 +113,9 @@ func (s *TaskService) CalculateTaskProgress(task *scheduler.JudgeTask) int {
 	case scheduler.TaskStatusPending:
 		return 0
 	case scheduler.TaskStatusRunning:
-		if task.Result != nil && len(task.TestCases) > 0 {
+		if task.Result != nil && task.TestCaseCount > 0 {
 			completed := len(task.Result.TestCases)
-			total := len(task.TestCases)
+			total := task.TestCaseCount
 			if total > 0 {
 				progress := (completed * 100) / total
 				// 确保运行中的任务至少显示10%的进度
@@ -172,7 +142,7 @@ func (s *TaskService) GenerateTaskStatusMessage(task *scheduler.JudgeTask) strin
 	}
 
 	currentTestCase := 0
-	totalTestCases := len(task.TestCases)
+	totalTestCases := task.TestCaseCount
 
 	if task.Result != nil {
 		currentTestCase = len(task.Result.TestCases)
@@ -2,15 +2,19 @@ package svc
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/dszqbsm/code-judger/services/judge-api/internal/client"
 	"github.com/dszqbsm/code-judger/services/judge-api/internal/config"
 	"github.com/dszqbsm/code-judger/services/judge-api/internal/judge"
 	"github.com/dszqbsm/code-judger/services/judge-api/internal/messagequeue"
 	"github.com/dszqbsm/code-judger/services/judge-api/internal/scheduler"
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/types"
 
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/core/stores/cache"
+	"github.com/zeromicro/go-zero/core/stores/redis"
 	"github.com/zeromicro/go-zero/core/stores/sqlx"
 )
 
@@ -29,6 +33,9 @@ type ServiceContext struct {
 	// 任务调度器
 	TaskScheduler *scheduler.TaskScheduler
 
+	// 任务状态存储
+	TaskStore scheduler.TaskStore
+
 	// 题目服务客户端
 	ProblemClient client.ProblemServiceClient
 
@@ -52,15 +59,40 @@ func NewServiceContext(c config.Config) *ServiceContext {
 	cacheClient := cache.New(cacheConf, nil, cache.NewStat("judge-api"), nil)
 
 	// 初始化判题引擎
-	judgeEngine := judge.NewJudgeEngine(&c.JudgeEngine)
+	judgeEngine, err := judge.NewJudgeEngine(&c.JudgeEngine)
+	if err != nil {
+		logx.Errorf("Failed to create judge engine: %v", err)
+		panic(err)
+	}
 
-	// 初始化任务调度器
-	taskScheduler := scheduler.NewTaskScheduler(&c.TaskQueue, judgeEngine)
+	// 初始化任务状态存储
+	retention := c.TaskQueue.Store.Retention
+	if retention <= 0 {
+		// 未配置Store时与调度器清理内存中任务的时间一致
+		retention = 86400
+	}
+	var taskStore scheduler.TaskStore
+	if c.TaskQueue.Store.Type == "redis" {
+		// 重启时按节点ID认领未完成的任务，节点ID为空时所有节点共用一个未完成集合，会执行彼此的任务
+		if c.Cluster.NodeId == "" {
+			err := fmt.Errorf("Cluster.NodeId is required with the redis task store")
+			logx.Errorf("Failed to create task store: %v", err)
+			panic(err)
+		}
+		taskStore = scheduler.NewRedisTaskStore(redis.MustNewRedis(c.RedisConf), c.TaskQueue.Store.KeyPrefix, retention)
+		logx.Infof("Using redis task store with prefix %s", c.TaskQueue.Store.KeyPrefix)
+	} else {
+		taskStore = scheduler.NewMemoryTaskStore(time.Duration(retention) * time.Second)
+	}
 
-	// 启动任务调度器
-	if err := taskScheduler.Start(); err != nil {
-		logx.Errorf("Failed to start task scheduler: %v", err)
-		panic(err)
+	// 初始化资源预算，失败时只按MaxWorkers限制并发
+	var budget *scheduler.ResourceBudget
+	if c.TaskQueue.Admission.Enabled {
+		var err error
+		if budget, err = scheduler.NewResourceBudget(c.TaskQueue.Admission, &c.JudgeEngine); err != nil {
+			logx.Errorf("Resource admission disabled: %v", err)
+			budget = nil
+		}
 	}
 
 	// 初始化题目服务客户端
@@ -81,6 +113,26 @@ func NewServiceContext(c config.Config) *ServiceContext {
 		logx.Infof("Using HTTP problem service client: %s", c.ProblemService.HTTP.Endpoint)
 	}
 
+	// 初始化任务调度器（启动时恢复本节点未完成的任务，测试数据从题目服务重新获取）
+	taskScheduler := scheduler.NewTaskScheduler(&c.TaskQueue, judgeEngine, taskStore, c.Cluster.NodeId, budget)
+	taskScheduler.SetTestCaseLoader(func(ctx context.Context, problemID int64) ([]*types.TestCase, error) {
+		problem, err := problemClient.GetProblemDetail(ctx, problemID)
+		if err != nil {
+			return nil, err
+		}
+		testCases := make([]*types.TestCase, len(problem.TestCases))
+		for i := range problem.TestCases {
+			testCases[i] = &problem.TestCases[i]
+		}
+		return testCases, nil
+	})
+
+	// 启动任务调度器
+	if err := taskScheduler.Start(); err != nil {
+		logx.Errorf("Failed to start task scheduler: %v", err)
+		panic(err)
+	}
+
 	// 初始化Kafka生产者
 	kafkaProducer := messagequeue.NewKafkaProducer(c.KafkaConf)
 
@@ -99,6 +151,7 @@ func NewServiceContext(c config.Config) *ServiceContext {
 		Cache:         cacheClient,
 		JudgeEngine:   judgeEngine,
 		TaskScheduler: taskScheduler,
+		TaskStore:     taskStore,
 		ProblemClient: problemClient,
 		KafkaConsumer: kafkaConsumer,
 		KafkaProducer: kafkaProducer,
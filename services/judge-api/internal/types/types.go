@@ -11,8 +11,9 @@ type SubmitJudgeReq struct {
 	SubmissionId int64  `json:"submission_id" validate:"required,min=1"`
 	ProblemId    int64  `json:"problem_id" validate:"required,min=1"`
 	UserId       int64  `json:"user_id" validate:"required,min=1"`
-	Language     string `json:"language" validate:"required,oneof=cpp c java python go javascript"`
+	Language     string `json:"language" validate:"required,oneof=cpp c java python go javascript typescript"`
 	Code         string `json:"code" validate:"required,min=1"`
+	ContestId    int64  `json:"contest_id,optional"` // 比赛提交所属的比赛，公平排队中整场比赛共享一个流
 	// 移除 TimeLimit、MemoryLimit、TestCases
 	// 这些参数应该通过 ProblemId 从题目服务获取
 }
@@ -27,13 +28,14 @@ type TestCase struct {
 
 // 题目信息（从题目服务获取）
 type ProblemInfo struct {
-	ProblemId   int64      `json:"problem_id"`
-	Title       string     `json:"title"`
-	TimeLimit   int        `json:"time_limit"`   // 毫秒
-	MemoryLimit int        `json:"memory_limit"` // MB
-	Languages   []string   `json:"languages"`    // 支持的编程语言
-	TestCases   []TestCase `json:"test_cases"`
-	IsPublic    bool       `json:"is_public"`
+	ProblemId       int64      `json:"problem_id"`
+	Title           string     `json:"title"`
+	TimeLimit       int        `json:"time_limit"`                  // 毫秒
+	MemoryLimit     int        `json:"memory_limit"`                // MB
+	TimeLimitMetric string     `json:"time_limit_metric,omitempty"` // 时间度量：cpu或wall，为空时使用全局配置
+	Languages       []string   `json:"languages"`                   // 支持的编程语言
+	TestCases       []TestCase `json:"test_cases"`
+	IsPublic        bool       `json:"is_public"`
 }
 
 type SubmitJudgeResp struct {
@@ -79,12 +81,26 @@ type CompileInfo struct {
 type TestCaseResult struct {
 	CaseId      int    `json:"case_id"`
 	Status      string `json:"status"`
-	TimeUsed    int    `json:"time_used"`   // 毫秒
+	TimeUsed    int    `json:"time_used"`   // 毫秒，按题目的时间度量计
+	CPUTime     int    `json:"cpu_time"`    // CPU时间(毫秒)，user+sys
+	WallTime    int    `json:"wall_time"`   // 墙钟时间(毫秒)
 	MemoryUsed  int    `json:"memory_used"` // KB
 	Input       string `json:"input"`
 	Output      string `json:"output"`
 	Expected    string `json:"expected"`
 	ErrorOutput string `json:"error_output,omitempty"`
+	// 超时类型：cpu（CPU时间超限）或wall（sleep、等待输入等导致墙钟时间耗尽），仅time_limit_exceeded时有值
+	TimeLimitType string `json:"time_limit_type,omitempty"`
+	// 被seccomp拦截的系统调用名称（如socket），仅restricted_function时有值
+	RestrictedSyscall string `json:"restricted_syscall,omitempty"`
+	// 执行期间的内存与CPU时间采样，用于判断内存是逐渐增长还是瞬间暴涨
+	ResourceSamples []ResourceSample `json:"resource_samples,omitempty"`
+}
+
+type ResourceSample struct {
+	Time    int `json:"t"`   // 距程序启动的时间(毫秒)
+	Memory  int `json:"mem"` // 内存使用(KB)
+	CPUTime int `json:"cpu"` // 累计CPU时间(毫秒)
 }
 
 type JudgeInfo struct {
@@ -198,13 +214,15 @@ type JudgeQueueData struct {
 }
 
 type QueueItem struct {
-	SubmissionId  int64  `json:"submission_id"`
-	UserId        int64  `json:"user_id"`
-	ProblemId     int64  `json:"problem_id"`
-	Language      string `json:"language"`
-	Priority      int    `json:"priority"`
-	QueueTime     string `json:"queue_time"`
-	EstimatedTime int    `json:"estimated_time"`
+	SubmissionId      int64   `json:"submission_id"`
+	UserId            int64   `json:"user_id"`
+	ProblemId         int64   `json:"problem_id"`
+	Language          string  `json:"language"`
+	Priority          int     `json:"priority"`
+	EffectivePriority float64 `json:"effective_priority"` // 老化后的优先级
+	QueueTime         string  `json:"queue_time"`
+	WaitTime          int     `json:"wait_time"` // 已等待时间(秒)
+	EstimatedTime     int     `json:"estimated_time"`
 }
 
 // ==================== 健康检查 ====================
@@ -10,6 +10,7 @@ import (
 
 	"github.com/dszqbsm/code-judger/services/judge-api/internal/config"
 	"github.com/dszqbsm/code-judger/services/judge-api/internal/handler"
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/sandbox"
 	"github.com/dszqbsm/code-judger/services/judge-api/internal/svc"
 	"github.com/dszqbsm/code-judger/common/consul"
 
@@ -21,6 +22,20 @@ import (
 var configFile = flag.String("f", "etc/judge-api.yaml", "the config file")
 
 func main() {
+	// 沙箱以辅助模式重新执行本程序，完成根文件系统、降权与seccomp设置后execve用户程序
+	if sandbox.IsSandboxHelper() {
+		sandbox.RunSandboxHelper()
+	}
+
+	// 管理命令：系统调用学习模式
+	if len(os.Args) > 1 && os.Args[1] == "profile-syscalls" {
+		os.Exit(runProfileSyscalls(os.Args[2:]))
+	}
+	// 管理命令：沙箱安全自检
+	if len(os.Args) > 1 && os.Args[1] == "selftest" {
+		os.Exit(runSelftest(os.Args[2:]))
+	}
+
 	flag.Parse()
 
 	var c config.Config
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/config"
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/judge"
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/languages"
+	"github.com/dszqbsm/code-judger/services/judge-api/internal/profiler"
+
+	"github.com/zeromicro/go-zero/core/conf"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// 管理命令：以学习模式运行样例程序集，生成语言的系统调用白名单并与当前白名单比较
+// 用法：judge-api profile-syscalls -f etc/judge-api.yaml -language cpp [-corpus dir] [-o file]
+func runProfileSyscalls(args []string) int {
+	flags := flag.NewFlagSet("profile-syscalls", flag.ExitOnError)
+	configFile := flags.String("f", "etc/judge-api.yaml", "the config file")
+	language := flags.String("language", "", "the language to profile")
+	corpusDir := flags.String("corpus", "", "the sample programs (default etc/syscall-corpus/<language>)")
+	outputFile := flags.String("o", "", "the proposed profile (default <language>-syscalls.yaml)")
+	flags.Parse(args)
+
+	if *language == "" {
+		fmt.Fprintln(os.Stderr, "profile-syscalls: -language is required")
+		return 2
+	}
+	if *corpusDir == "" {
+		*corpusDir = filepath.Join("etc", "syscall-corpus", *language)
+	}
+	if *outputFile == "" {
+		*outputFile = *language + "-syscalls.yaml"
+	}
+
+	var c config.Config
+	conf.MustLoad(*configFile, &c)
+	// 只输出错误日志，避免淹没差异报告
+	logx.SetLevel(logx.ErrorLevel)
+
+	compiler, ok := c.JudgeEngine.Compilers[*language]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "profile-syscalls: language %s is not configured\n", *language)
+		return 1
+	}
+	// 只创建目标语言的执行器：学习模式会回收本进程的所有子进程，不能与其他语言的后台预热并发
+	manager := languages.NewLanguageManager(map[string]config.CompilerConf{*language: compiler}, judge.NewSandboxPolicy(&c.JudgeEngine))
+	executor, err := manager.GetExecutor(*language)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "profile-syscalls: %v\n", err)
+		return 1
+	}
+	if err := os.MkdirAll(c.JudgeEngine.TempDir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "profile-syscalls: %v\n", err)
+		return 1
+	}
+
+	result, err := profiler.Run(context.Background(), executor, profiler.Options{
+		Language:    *language,
+		CorpusDir:   *corpusDir,
+		WorkDir:     c.JudgeEngine.TempDir,
+		TimeLimit:   int64(c.JudgeEngine.ResourceLimits.MaxTimeLimit),
+		MemoryLimit: int64(c.JudgeEngine.ResourceLimits.DefaultMemoryLimit) * 1024,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "profile-syscalls: %v\n", err)
+		return 1
+	}
+
+	data, err := result.Profile.YAML()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "profile-syscalls: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(*outputFile, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "profile-syscalls: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Wrote %s: %d samples, %d distinct syscalls\n", *outputFile, result.Profile.Samples, len(result.Profile.Syscalls))
+	for _, sample := range result.Failed {
+		fmt.Printf("warning: sample %s did not exit cleanly\n", sample)
+	}
+	if result.Diff.Empty() {
+		fmt.Printf("The current %s whitelist matches the observed syscalls\n", *language)
+		return 0
+	}
+	fmt.Printf("--- current %s whitelist\n+++ observed\n%s", *language, result.Diff)
+	return 0
+}
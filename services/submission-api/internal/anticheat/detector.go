@@ -171,7 +171,7 @@ func (d *Detector) preprocessCode(code, language string) string {
 // removeComments 移除注释
 func (d *Detector) removeComments(code, language string) string {
 	switch language {
-	case "cpp", "c", "java", "javascript", "go":
+	case "cpp", "c", "java", "javascript", "typescript", "go":
 		// 移除单行注释
 		code = regexp.MustCompile(`//.*`).ReplaceAllString(code, "")
 		// 移除多行注释
@@ -147,6 +147,7 @@ func (l *CreateSubmissionLogic) CreateSubmission(req *types.CreateSubmissionReq)
 		Language:     req.Language,
 		Code:         req.Code,
 		Priority:     judgeTaskInfo.Priority,
+		ContestID:    req.ContestID,
 		CreatedAt:    time.Now(),
 	}
 
@@ -196,6 +197,7 @@ type JudgeTask struct {
 	Language     string    `json:"language"`
 	Code         string    `json:"code"`
 	Priority     int       `json:"priority"`
+	ContestID    int64     `json:"contest_id,omitempty"` // 判题服务按比赛公平排队
 	CreatedAt    time.Time `json:"created_at"`
 }
 
@@ -36,7 +36,7 @@ type RejudgeSubmissionRespData struct {
 // 创建提交请求
 type CreateSubmissionReq struct {
 	ProblemID int64  `json:"problem_id" validate:"required"`
-	Language  string `json:"language" validate:"required,oneof=cpp c java python go javascript"`
+	Language  string `json:"language" validate:"required,oneof=cpp c java python go javascript typescript"`
 	Code      string `json:"code" validate:"required,max=65536"`
 	ContestID int64  `json:"contest_id,omitempty"`
 	IsShared  bool   `json:"is_shared,omitempty"`
@@ -325,7 +325,7 @@ INSERT INTO system_configs (config_key, config_value, config_type, description,
 ('site_name', '在线判题系统', 'string', '网站名称', true),
 ('site_description', '基于Go语言的高性能在线判题平台', 'string', '网站描述', true),
 ('max_submission_size', '65536', 'number', '最大代码提交大小(字节)', false),
-('supported_languages', '["cpp", "java", "python", "go", "javascript"]', 'json', '支持的编程语言', true),
+('supported_languages', '["cpp", "java", "python", "go", "javascript", "typescript"]', 'json', '支持的编程语言', true),
 ('default_time_limit', '1000', 'number', '默认时间限制(毫秒)', false),
 ('default_memory_limit', '128', 'number', '默认内存限制(MB)', false),
 ('registration_enabled', 'true', 'boolean', '是否允许用户注册', true),